@@ -19,6 +19,7 @@ import (
 	"github.com/xhanio/framingo/pkg/utils/certutil"
 	"github.com/xhanio/framingo/pkg/utils/log"
 	"github.com/xhanio/framingo/pkg/utils/sliceutil"
+	"github.com/xhanio/framingo/pkg/utils/strutil"
 
 	"github.com/xhanio/framingo/example/pkg/services/example"
 	"github.com/xhanio/framingo/example/pkg/services/repository"
@@ -40,6 +41,7 @@ func (m *manager) initServices() error {
 			m.config.GetInt("log.rotation.max_backups"),
 			m.config.GetInt("log.rotation.max_age"),
 		),
+		log.WithLogCompression(m.config.GetBool("log.rotation.compress")),
 	)
 	infra.Debug = (m.log.Level() == zapcore.DebugLevel)
 
@@ -67,10 +69,10 @@ func (m *manager) initServices() error {
 				m.config.GetString("db.source.user"),
 				m.config.GetString("DB_USER"),
 			),
-			Password: sliceutil.First(
+			Password: strutil.Secret(sliceutil.First(
 				m.config.GetString("db.source.password"),
 				m.config.GetString("DB_PASSWORD"),
-			),
+			)),
 			DBName: sliceutil.First(
 				m.config.GetString("db.source.dbname"),
 				m.config.GetString("DB_DBNAME"),
@@ -0,0 +1,278 @@
+// Package compress provides a middleware compressing responses with gzip or
+// deflate, whichever the client prefers in Accept-Encoding. Responses shorter
+// than a minimum length and content types that are compressed already, such
+// as images or archives, are sent as they are. Register it and list
+// "compress" in the router.yaml of the servers that should compress.
+package compress
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/labstack/echo/v4"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/api"
+	"github.com/xhanio/framingo/pkg/types/common"
+	"github.com/xhanio/framingo/pkg/utils/reflectutil"
+)
+
+var _ api.Middleware = (*compress)(nil)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+type compress struct {
+	level     int
+	minLength int
+	skipTypes []string
+
+	gzips    sync.Pool
+	deflates sync.Pool
+}
+
+// New returns a middleware compressing responses of at least minLength bytes
+// at level, from 1 (fastest) to 9 (best), 0 meaning the default level.
+func New(level int, minLength int, opts ...Option) api.Middleware {
+	if level == 0 || level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	m := &compress{
+		level:     level,
+		minLength: max(minLength, 0),
+		skipTypes: []string{
+			"image/",
+			"video/",
+			"audio/",
+			"font/woff",
+			"application/gzip",
+			"application/x-gzip",
+			"application/zip",
+			"application/zstd",
+			"application/x-7z-compressed",
+			"application/x-rar-compressed",
+			"application/x-bzip2",
+			"application/x-xz",
+		},
+	}
+	m.apply(opts...)
+	m.gzips.New = func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, m.level)
+		return w
+	}
+	m.deflates.New = func() any {
+		w, _ := flate.NewWriter(io.Discard, m.level)
+		return w
+	}
+	return m
+}
+
+func (m *compress) Name() string {
+	pkg, _ := reflectutil.Locate(m)
+	return path.Base(pkg)
+}
+
+func (m *compress) Dependencies() []common.Service {
+	return nil
+}
+
+func (m *compress) Func(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if req.Header.Get(echo.HeaderUpgrade) != "" {
+			// websocket upgrades hijack the connection
+			return next(c)
+		}
+		res := c.Response()
+		res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+		encoding := negotiate(req.Header.Get(echo.HeaderAcceptEncoding))
+		if encoding == "" || req.Method == http.MethodHead {
+			return next(c)
+		}
+		w := &writer{ResponseWriter: res.Writer, m: m, encoding: encoding}
+		res.Writer = w
+		defer func() {
+			if err := w.close(); err != nil {
+				c.Logger().Errorf("failed to compress response: %s", err)
+			}
+			res.Writer = w.ResponseWriter
+		}()
+		return next(c)
+	}
+}
+
+// negotiate picks gzip or deflate by their quality in header, gzip winning
+// ties, or "" if the client accepts neither.
+func negotiate(header string) string {
+	var best string
+	var bestQ float64
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encodingGzip && name != encodingDeflate && name != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q <= 0 {
+			// q=0 means not acceptable
+			continue
+		}
+		if name == "*" {
+			name = encodingGzip
+		}
+		if q > bestQ || (q == bestQ && name == encodingGzip) {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+func (m *compress) skip(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range m.skipTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// writer holds the response back until minLength bytes are written, then
+// decides whether to compress it. Shorter responses are written as they are
+// once the handler returns.
+type writer struct {
+	http.ResponseWriter
+	m        *compress
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	zw      interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.m.minLength {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.zw != nil {
+		return w.zw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide sends the header, compressing the body from now on if compress is
+// set and the response allows it, and writes what was held back.
+func (w *writer) decide(compress bool) error {
+	w.decided = true
+	h := w.Header()
+	if compress && h.Get(echo.HeaderContentEncoding) == "" && !w.m.skip(h.Get(echo.HeaderContentType)) {
+		if h.Get(echo.HeaderContentType) == "" {
+			// sniff before the body is compressed, as net/http would afterwards
+			h.Set(echo.HeaderContentType, http.DetectContentType(w.buf))
+		}
+		h.Set(echo.HeaderContentEncoding, w.encoding)
+		h.Del(echo.HeaderContentLength)
+		if w.encoding == encodingGzip {
+			zw := w.m.gzips.Get().(*gzip.Writer)
+			zw.Reset(w.ResponseWriter)
+			w.zw = zw
+		} else {
+			zw := w.m.deflates.Get().(*flate.Writer)
+			zw.Reset(w.ResponseWriter)
+			w.zw = zw
+		}
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.zw != nil {
+		_, err = w.zw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return errors.Wrap(err)
+}
+
+// close writes a response still held back uncompressed, or ends the
+// compressed stream and returns its encoder to the pool.
+func (w *writer) close() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+	if w.zw == nil {
+		return nil
+	}
+	err := w.zw.Close()
+	switch zw := w.zw.(type) {
+	case *gzip.Writer:
+		w.m.gzips.Put(zw)
+	case *flate.Writer:
+		w.m.deflates.Put(zw)
+	}
+	w.zw = nil
+	return errors.Wrap(err)
+}
+
+// Flush sends what was written so far, uncompressed if the response has not
+// reached minLength yet, so streamed responses are not held back.
+func (w *writer) Flush() {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.zw != nil {
+		_ = w.zw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,102 @@
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/framingo/pkg/types/api"
+)
+
+func serve(t *testing.T, m api.Middleware, acceptEncoding string, h echo.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set(echo.HeaderAcceptEncoding, acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, m.Func(h)(c))
+	return rec
+}
+
+func items(n int) []map[string]any {
+	var result []map[string]any
+	for i := range n {
+		result = append(result, map[string]any{"id": i, "name": "item", "tags": []string{"a", "b"}})
+	}
+	return result
+}
+
+func TestCompress(t *testing.T) {
+	m := New(0, 1024)
+	assert.Equal(t, "compress", m.Name())
+
+	large := func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, items(200))
+	}
+	rec := serve(t, m, "deflate;q=0.5, gzip", large)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, echo.HeaderAcceptEncoding, rec.Header().Get(echo.HeaderVary))
+	assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+	zr, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"name":"item"`)
+	assert.Greater(t, len(body), rec.Body.Len())
+
+	// too small to be worth compressing
+	rec = serve(t, m, "gzip", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	m := New(0, 16)
+	large := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, items(50))
+	}
+
+	rec := serve(t, m, "gzip;q=0.2, deflate", large)
+	assert.Equal(t, "deflate", rec.Header().Get(echo.HeaderContentEncoding))
+	body, err := io.ReadAll(flate.NewReader(rec.Body))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"name":"item"`)
+
+	for _, accept := range []string{"", "br", "gzip;q=0"} {
+		rec = serve(t, m, accept, large)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), accept)
+		assert.Contains(t, rec.Body.String(), `"name":"item"`, accept)
+	}
+}
+
+func TestCompressSkipContentTypes(t *testing.T) {
+	m := New(0, 16, WithSkipContentTypes("application/pdf"))
+	for _, contentType := range []string{"image/png", "application/zip", "application/pdf"} {
+		rec := serve(t, m, "gzip", func(c echo.Context) error {
+			return c.Blob(http.StatusOK, contentType, []byte(strings.Repeat("x", 64)))
+		})
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), contentType)
+		assert.Equal(t, strings.Repeat("x", 64), rec.Body.String(), contentType)
+	}
+	// already encoded by the handler
+	rec := serve(t, m, "gzip", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentEncoding, "br")
+		return c.Blob(http.StatusOK, echo.MIMETextPlain, []byte(strings.Repeat("x", 64)))
+	})
+	assert.Equal(t, "br", rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, strings.Repeat("x", 64), rec.Body.String())
+}
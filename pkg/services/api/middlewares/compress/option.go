@@ -0,0 +1,18 @@
+package compress
+
+type Option func(*compress)
+
+func (m *compress) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(m)
+	}
+}
+
+// WithSkipContentTypes adds content types whose responses are sent
+// uncompressed, matched as prefixes, e.g. "application/pdf". Images, video,
+// audio, woff fonts and common archive formats are skipped by default.
+func WithSkipContentTypes(types ...string) Option {
+	return func(m *compress) {
+		m.skipTypes = append(m.skipTypes, types...)
+	}
+}
@@ -0,0 +1,61 @@
+// Package csrf provides a double-submit cookie CSRF middleware. Safe methods
+// (GET, HEAD, OPTIONS, TRACE) receive the token in a cookie, and unsafe ones
+// must echo it back through the configured token lookup. Register it and list
+// "csrf" in router.yaml to enable it.
+package csrf
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/api"
+	"github.com/xhanio/framingo/pkg/types/common"
+	"github.com/xhanio/framingo/pkg/utils/reflectutil"
+)
+
+var _ api.Middleware = (*csrf)(nil)
+
+type csrf struct {
+	config middleware.CSRFConfig
+	fn     echo.MiddlewareFunc
+}
+
+func New(opts ...Option) api.Middleware {
+	m := &csrf{
+		config: middleware.CSRFConfig{
+			TokenLookup:    "header:" + echo.HeaderXCSRFToken,
+			CookieName:     "_csrf",
+			CookiePath:     "/",
+			CookieSameSite: http.SameSiteStrictMode,
+		},
+	}
+	m.apply(opts...)
+	m.config.ErrorHandler = m.reject
+	m.fn = middleware.CSRFWithConfig(m.config)
+	return m
+}
+
+func (m *csrf) Name() string {
+	pkg, _ := reflectutil.Locate(m)
+	return path.Base(pkg)
+}
+
+func (m *csrf) Dependencies() []common.Service {
+	return nil
+}
+
+func (m *csrf) Func(next echo.HandlerFunc) echo.HandlerFunc {
+	return m.fn(next)
+}
+
+// reject maps echo's csrf errors to the error categories of the api server.
+func (m *csrf) reject(err error, c echo.Context) error {
+	if he, ok := err.(*echo.HTTPError); ok && he.Code == http.StatusBadRequest {
+		return errors.BadRequest.Newf("%v", he.Message)
+	}
+	return errors.Forbidden.Newf("invalid csrf token")
+}
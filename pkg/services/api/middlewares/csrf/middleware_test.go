@@ -0,0 +1,78 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+)
+
+func serve(h echo.HandlerFunc, req *http.Request) (*httptest.ResponseRecorder, error) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	return rec, h(c)
+}
+
+func TestCSRF(t *testing.T) {
+	m := New()
+	assert.Equal(t, "csrf", m.Name())
+	h := m.Func(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	// safe requests receive the token cookie
+	rec, err := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "_csrf", cookies[0].Name)
+	token := cookies[0].Value
+	require.NotEmpty(t, token)
+
+	// unsafe requests without the token are rejected
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookies[0])
+	_, err = serve(h, req)
+	assert.True(t, errors.Is(err, errors.BadRequest), "expected bad request, got %v", err)
+
+	// a token not matching the cookie is rejected
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookies[0])
+	req.Header.Set(echo.HeaderXCSRFToken, "forged")
+	_, err = serve(h, req)
+	assert.True(t, errors.Is(err, errors.Forbidden), "expected forbidden, got %v", err)
+
+	// the double-submitted token passes
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookies[0])
+	req.Header.Set(echo.HeaderXCSRFToken, token)
+	rec, err = serve(h, req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCSRFTokenLookup(t *testing.T) {
+	m := New(WithTokenLookup("form:_csrf"), WithCookie("token", "/app"))
+	h := m.Func(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	rec, err := serve(h, httptest.NewRequest(http.MethodGet, "/app", nil))
+	require.NoError(t, err)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "token", cookies[0].Name)
+	assert.Equal(t, "/app", cookies[0].Path)
+
+	req := httptest.NewRequest(http.MethodPost, "/app", strings.NewReader("_csrf="+cookies[0].Value))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.AddCookie(cookies[0])
+	rec, err = serve(h, req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,50 @@
+package csrf
+
+import "net/http"
+
+type Option func(*csrf)
+
+func (m *csrf) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(m)
+	}
+}
+
+// WithTokenLookup sets where unsafe requests carry the token, in the form
+// "<source>:<name>" with header, query or form as source. Multiple lookups
+// are comma separated. Defaults to "header:X-CSRF-Token".
+func WithTokenLookup(lookup string) Option {
+	return func(m *csrf) {
+		m.config.TokenLookup = lookup
+	}
+}
+
+// WithCookie sets the name and path of the token cookie, "_csrf" and "/" by
+// default.
+func WithCookie(name, path string) Option {
+	return func(m *csrf) {
+		m.config.CookieName = name
+		m.config.CookiePath = path
+	}
+}
+
+// WithCookieDomain sets the domain of the token cookie.
+func WithCookieDomain(domain string) Option {
+	return func(m *csrf) {
+		m.config.CookieDomain = domain
+	}
+}
+
+// WithSecureCookie only sends the token cookie over https.
+func WithSecureCookie() Option {
+	return func(m *csrf) {
+		m.config.CookieSecure = true
+	}
+}
+
+// WithSameSite sets the SameSite mode of the token cookie, strict by default.
+func WithSameSite(mode http.SameSite) Option {
+	return func(m *csrf) {
+		m.config.CookieSameSite = mode
+	}
+}
@@ -0,0 +1,50 @@
+// Package secureheaders provides a middleware setting the standard browser
+// security headers: Strict-Transport-Security on https requests,
+// X-Content-Type-Options, X-Frame-Options and optionally
+// Content-Security-Policy and Referrer-Policy. Register it and list
+// "secureheaders" in router.yaml to enable it.
+package secureheaders
+
+import (
+	"path"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/xhanio/framingo/pkg/types/api"
+	"github.com/xhanio/framingo/pkg/types/common"
+	"github.com/xhanio/framingo/pkg/utils/reflectutil"
+)
+
+var _ api.Middleware = (*secureHeaders)(nil)
+
+type secureHeaders struct {
+	config middleware.SecureConfig
+	fn     echo.MiddlewareFunc
+}
+
+func New(opts ...Option) api.Middleware {
+	m := &secureHeaders{
+		config: middleware.SecureConfig{
+			ContentTypeNosniff: "nosniff",
+			XFrameOptions:      "SAMEORIGIN",
+			HSTSMaxAge:         365 * 24 * 60 * 60,
+		},
+	}
+	m.apply(opts...)
+	m.fn = middleware.SecureWithConfig(m.config)
+	return m
+}
+
+func (m *secureHeaders) Name() string {
+	pkg, _ := reflectutil.Locate(m)
+	return path.Base(pkg)
+}
+
+func (m *secureHeaders) Dependencies() []common.Service {
+	return nil
+}
+
+func (m *secureHeaders) Func(next echo.HandlerFunc) echo.HandlerFunc {
+	return m.fn(next)
+}
@@ -0,0 +1,57 @@
+package secureheaders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/framingo/pkg/types/api"
+)
+
+func serve(t *testing.T, m api.Middleware, req *http.Request) http.Header {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, m.Func(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c))
+	return rec.Header()
+}
+
+func TestSecureHeaders(t *testing.T) {
+	m := New()
+	assert.Equal(t, "secureheaders", m.Name())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXForwardedProto, "https")
+	h := serve(t, m, req)
+	assert.Equal(t, "nosniff", h.Get(echo.HeaderXContentTypeOptions))
+	assert.Equal(t, "SAMEORIGIN", h.Get(echo.HeaderXFrameOptions))
+	assert.Equal(t, "max-age=31536000; includeSubdomains", h.Get(echo.HeaderStrictTransportSecurity))
+	assert.Empty(t, h.Get(echo.HeaderContentSecurityPolicy))
+
+	// hsts is only sent over https
+	h = serve(t, m, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, h.Get(echo.HeaderStrictTransportSecurity))
+}
+
+func TestSecureHeadersOptions(t *testing.T) {
+	m := New(
+		WithHSTS(time.Hour, false, true),
+		WithFrameOptions("DENY"),
+		WithContentSecurityPolicy("default-src 'self'"),
+		WithReferrerPolicy("no-referrer"),
+	)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXForwardedProto, "https")
+	h := serve(t, m, req)
+	assert.Equal(t, "DENY", h.Get(echo.HeaderXFrameOptions))
+	assert.Equal(t, "default-src 'self'", h.Get(echo.HeaderContentSecurityPolicy))
+	assert.Equal(t, "no-referrer", h.Get(echo.HeaderReferrerPolicy))
+	assert.Equal(t, "max-age=3600; preload", h.Get(echo.HeaderStrictTransportSecurity))
+}
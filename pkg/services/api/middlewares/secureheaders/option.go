@@ -0,0 +1,44 @@
+package secureheaders
+
+import "time"
+
+type Option func(*secureHeaders)
+
+func (m *secureHeaders) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(m)
+	}
+}
+
+// WithHSTS sets the Strict-Transport-Security max age, one year by default. A
+// zero maxAge disables the header. It is only sent on https requests,
+// including ones forwarded with X-Forwarded-Proto: https.
+func WithHSTS(maxAge time.Duration, includeSubdomains, preload bool) Option {
+	return func(m *secureHeaders) {
+		m.config.HSTSMaxAge = int(maxAge.Seconds())
+		m.config.HSTSExcludeSubdomains = !includeSubdomains
+		m.config.HSTSPreloadEnabled = preload
+	}
+}
+
+// WithFrameOptions sets X-Frame-Options, SAMEORIGIN by default. An empty value
+// disables the header.
+func WithFrameOptions(value string) Option {
+	return func(m *secureHeaders) {
+		m.config.XFrameOptions = value
+	}
+}
+
+// WithContentSecurityPolicy sets Content-Security-Policy.
+func WithContentSecurityPolicy(policy string) Option {
+	return func(m *secureHeaders) {
+		m.config.ContentSecurityPolicy = policy
+	}
+}
+
+// WithReferrerPolicy sets Referrer-Policy.
+func WithReferrerPolicy(policy string) Option {
+	return func(m *secureHeaders) {
+		m.config.ReferrerPolicy = policy
+	}
+}
@@ -1,6 +1,10 @@
 package server
 
 import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -9,6 +13,13 @@ import (
 	"github.com/xhanio/framingo/pkg/types/common"
 )
 
+// error response formats supported by content negotiation
+const (
+	errorFormatJSON = "json"
+	errorFormatXML  = "xml"
+	errorFormatText = "text"
+)
+
 func (s *server) errorHandler(err error, c echo.Context) {
 	if c.Response().Committed || err == nil {
 		return
@@ -28,7 +39,50 @@ func (s *server) errorHandler(err error, c echo.Context) {
 		}
 		s.print(req, resp)
 	}
-	if err := c.JSON(resp.Status, resp.Error); err != nil {
-		s.log.Errorf("failed to send json response: %v", err)
+	// the status code is resolved once above so every format reports the same one
+	var sendErr error
+	switch format := negotiateErrorFormat(c.Request().Header.Get(echo.HeaderAccept)); format {
+	case errorFormatXML:
+		sendErr = c.XML(resp.Status, resp.Error)
+	case errorFormatText:
+		sendErr = c.String(resp.Status, fmt.Sprintf("%v\n", resp.Error))
+	default:
+		sendErr = c.JSON(resp.Status, resp.Error)
+	}
+	if sendErr != nil {
+		s.log.Errorf("failed to send error response: %v", sendErr)
+	}
+}
+
+// negotiateErrorFormat picks the error response format with the highest
+// quality value in the Accept header, falling back to json.
+func negotiateErrorFormat(accept string) string {
+	format, best := errorFormatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		var f string
+		switch mediaType {
+		case echo.MIMEApplicationJSON, "*/*", "application/*":
+			f = errorFormatJSON
+		case echo.MIMEApplicationXML, echo.MIMETextXML:
+			f = errorFormatXML
+		case echo.MIMETextPlain, "text/*":
+			f = errorFormatText
+		default:
+			continue
+		}
+		if q > best {
+			format, best = f, q
+		}
 	}
+	return format
 }
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/utils/errutil"
+)
+
+func failingHandler(c echo.Context) error {
+	return errors.NotFound.New(
+		errors.WithMessage("user not found"),
+		errors.WithCode("USER_NOT_FOUND", map[string]string{"id": "42"}),
+	)
+}
+
+func TestNegotiateErrorFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", errorFormatJSON},
+		{"*/*", errorFormatJSON},
+		{"application/json", errorFormatJSON},
+		{"application/xml", errorFormatXML},
+		{"text/xml; charset=utf-8", errorFormatXML},
+		{"text/plain", errorFormatText},
+		{"text/html, text/plain;q=0.5", errorFormatText},
+		{"application/json;q=0.4, application/xml;q=0.9", errorFormatXML},
+		{"text/plain;q=0.8, application/json", errorFormatJSON},
+		{"image/png", errorFormatJSON},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, negotiateErrorFormat(tt.accept), "accept %q", tt.accept)
+	}
+}
+
+func TestErrorHandler_ContentNegotiation(t *testing.T) {
+	base, cleanup := startServer(t, &mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /api
+handlers:
+  - method: GET
+    path: /users
+    func: Fail`),
+		handlers: map[string]any{"Fail": failingHandler},
+	})
+	defer cleanup()
+
+	get := func(t *testing.T, accept string) (*http.Response, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, base+"/api/users", nil)
+		require.NoError(t, err)
+		req.Header.Set(echo.HeaderAccept, accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	t.Run("json", func(t *testing.T) {
+		resp, body := get(t, echo.MIMEApplicationJSON)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+		var e map[string]any
+		require.NoError(t, json.Unmarshal(body, &e))
+		assert.Equal(t, "USER_NOT_FOUND", e["code"])
+		assert.Equal(t, "user not found", e["message"])
+		assert.Equal(t, map[string]any{"id": "42"}, e["details"])
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		resp, body := get(t, echo.MIMEApplicationXML)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get(echo.HeaderContentType), echo.MIMEApplicationXML)
+		var e struct {
+			XMLName xml.Name `xml:"error"`
+			Status  int      `xml:"status"`
+			Code    string   `xml:"code"`
+			Kind    string   `xml:"kind"`
+			Message string   `xml:"message"`
+			Details []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"details>detail"`
+		}
+		require.NoError(t, xml.Unmarshal(body, &e))
+		assert.Equal(t, http.StatusNotFound, e.Status)
+		assert.Equal(t, "USER_NOT_FOUND", e.Code)
+		assert.Equal(t, errors.NotFound.Error(), e.Kind)
+		assert.Equal(t, "user not found", e.Message)
+		require.Len(t, e.Details, 1)
+		assert.Equal(t, "id", e.Details[0].Key)
+		assert.Equal(t, "42", e.Details[0].Value)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		resp, body := get(t, echo.MIMETextPlain)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get(echo.HeaderContentType), echo.MIMETextPlain)
+		assert.Contains(t, string(body), "user not found")
+		assert.Contains(t, string(body), "USER_NOT_FOUND")
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		resp, _ := get(t, "image/png")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+	})
+}
+
+func TestErrorHandler_Localized(t *testing.T) {
+	errutil.SetTranslator(func(id, lang string, args ...any) string {
+		catalog := map[string]string{
+			"en": "user %d not found",
+			"de": "Benutzer %d nicht gefunden",
+		}
+		if id != "user.not_found" || catalog[lang] == "" {
+			return ""
+		}
+		return fmt.Sprintf(catalog[lang], args...)
+	})
+	defer errutil.SetTranslator(nil)
+	base, cleanup := startServer(t, &mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /api
+handlers:
+  - method: GET
+    path: /users
+    func: Fail`),
+		handlers: map[string]any{"Fail": func(c echo.Context) error {
+			return errutil.WithMessageID(failingHandler(c), "user.not_found", 42)
+		}},
+	})
+	defer cleanup()
+
+	get := func(t *testing.T, lang string) map[string]any {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, base+"/api/users", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		var e map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+		// the code and details stay language independent
+		assert.Equal(t, "USER_NOT_FOUND", e["code"])
+		assert.Equal(t, map[string]any{"id": "42"}, e["details"])
+		return e
+	}
+
+	assert.Equal(t, "user 42 not found", get(t, "en-US,en;q=0.9")["message"])
+	assert.Equal(t, "Benutzer 42 nicht gefunden", get(t, "fr;q=0.9, de-AT")["message"])
+	assert.Equal(t, "user not found", get(t, "fr")["message"])
+	assert.Equal(t, "user not found", get(t, "")["message"])
+}
@@ -2,10 +2,13 @@ package server
 
 import (
 	"context"
+	"maps"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coder/websocket"
@@ -30,11 +33,15 @@ type manager struct {
 
 	servers map[string]*server // map of server name to server instance
 
-	handlerFuncs    map[api.HandlerKey]echo.HandlerFunc
-	middlewareFuncs map[string]echo.MiddlewareFunc
+	routesMu         sync.Mutex // serializes route changes, guards handlerFuncs
+	handlerFuncs     map[api.HandlerKey]echo.HandlerFunc
+	middlewareFuncs  map[string]echo.MiddlewareFunc
+	middlewareOrders map[string]int
 
 	sync.Mutex // lock for rate limiters
 	limits     map[string]*rate.Limiter
+
+	draining atomic.Bool
 }
 
 // New creates a new server instance with the given options
@@ -44,11 +51,12 @@ func New(opts ...Option) Manager {
 
 func newManager(opts ...Option) *manager {
 	m := &manager{
-		log:             log.Default,
-		servers:         make(map[string]*server),
-		handlerFuncs:    make(map[api.HandlerKey]echo.HandlerFunc),
-		middlewareFuncs: make(map[string]echo.MiddlewareFunc),
-		limits:          make(map[string]*rate.Limiter),
+		log:              log.Default,
+		servers:          make(map[string]*server),
+		handlerFuncs:     make(map[api.HandlerKey]echo.HandlerFunc),
+		middlewareFuncs:  make(map[string]echo.MiddlewareFunc),
+		middlewareOrders: make(map[string]int),
+		limits:           make(map[string]*rate.Limiter),
 	}
 	m.apply(opts...)
 	return m
@@ -89,25 +97,44 @@ func (m *manager) Dependencies() []common.Service {
 // from the persisted handler metadata. Required because net/http forbids
 // reusing a server after Shutdown — on restart we need fresh echos.
 func (m *manager) Init(ctx context.Context) error {
+	m.draining.Store(false)
+	m.routesMu.Lock()
+	defer m.routesMu.Unlock()
 	for _, s := range m.servers {
 		m.buildEcho(s)
+		s.mu.RLock()
 		for key, h := range s.handlers {
 			g := s.groups[key]
-			if err := m.installHandler(s, g, h); err != nil {
+			if err := m.installHandler(s.echo, s, g, h, m.handlerFuncs[key]); err != nil {
+				s.mu.RUnlock()
 				return err
 			}
 		}
+		s.mu.RUnlock()
 	}
 	return nil
 }
 
 // buildEcho creates a fresh echo instance for the given server and applies
-// the pre + core middlewares. Replaces any prior s.echo.
+// the pre + core middlewares. Replaces any prior s.echo and drops any route
+// set swapped in by ReloadRouters, since s.echo now routes s.handlers itself.
 func (m *manager) buildEcho(s *server) {
-	mw := newMiddleware(s)
 	e := m.newEcho()
+	e.Pre(s.probe, s.limitBody, s.track, s.delegate)
+	m.configureEcho(s, e)
+	s.echo = e
+	s.routes.Store(nil)
+}
+
+// configureEcho applies the server's error handler and the pre + core
+// middlewares to e.
+func (m *manager) configureEcho(s *server, e *echo.Echo) {
+	mw := newMiddleware(s)
 	e.HTTPErrorHandler = s.errorHandler
-	e.Pre(middleware.RemoveTrailingSlash())
+	if s.ipExtractor != nil {
+		e.IPExtractor = s.ipExtractor
+	}
+	e.Pre(middleware.RemoveTrailingSlash(), s.static)
 	var middlewares []echo.MiddlewareFunc
 	// Apply CORS middleware in debug mode
 	if m.debug {
@@ -121,7 +148,6 @@ func (m *manager) buildEcho(s *server) {
 		mw.Throttle,
 	)
 	e.Use(middlewares...)
-	s.echo = e
 }
 
 // Add adds a new echo server instance with the given configuration
@@ -131,11 +157,15 @@ func (m *manager) Add(name string, opts ...ServerOption) error {
 		log:      m.log,
 		groups:   make(map[api.HandlerKey]*api.HandlerGroup),
 		handlers: make(map[api.HandlerKey]*api.Handler),
+		draining: &m.draining,
 	}
 	s.apply(opts...)
 	if s.endpoint == nil {
 		return errors.Newf("server must have a valid endpoint")
 	}
+	if err := s.buildIPExtractor(); err != nil {
+		return err
+	}
 	m.buildEcho(s)
 	m.servers[name] = s
 	return nil
@@ -159,73 +189,109 @@ func (m *manager) List() []Server {
 	return servers
 }
 
+// InFlight returns the number of requests the named server is serving, or 0
+// if there is no such server.
+func (m *manager) InFlight(serverName string) int {
+	s, ok := m.servers[serverName]
+	if !ok {
+		return 0
+	}
+	return int(s.inFlight.Load())
+}
+
+// Drain makes the readiness probes and Ready report the manager as unavailable
+// while the servers keep serving, e.g. from a Kubernetes pre-stop hook. It is
+// cleared by Init, so a restarted manager is ready again.
+func (m *manager) Drain() {
+	m.draining.Store(true)
+}
+
+func (m *manager) Draining() bool {
+	return m.draining.Load()
+}
+
+// Ready implements common.Readiness.
+func (m *manager) Ready() error {
+	if m.draining.Load() {
+		return errors.Unavailable.Newf("%s is draining", m.Name())
+	}
+	return nil
+}
+
 // ============================================================================
 // Router Registration
 // ============================================================================
 
-// registerRouter loads the router's configuration and registers its handlers
-func (m *manager) registerRouter(router api.Router) (*api.HandlerGroup, error) {
+// registerRouter loads the router's configuration and resolves its handler
+// functions. Nothing is recorded on m, so a failing router leaves it untouched.
+func (m *manager) registerRouter(router api.Router) (*api.HandlerGroup, map[api.HandlerKey]echo.HandlerFunc, error) {
 	// Get embedded router.yaml configuration
 	data := router.Config()
 	if len(data) == 0 {
-		return nil, errors.Newf("router %s has empty config", router.Name())
+		return nil, nil, errors.Newf("router %s has empty config", router.Name())
 	}
 	// Parse YAML config
 	var group *api.HandlerGroup
 	if err := yaml.Unmarshal(data, &group); err != nil {
-		return nil, errors.Wrapf(err, "failed to parse router config")
+		return nil, nil, errors.Wrapf(err, "failed to parse router config")
 	}
 	if group == nil {
-		return nil, errors.Newf("http configuration not found in router.yaml")
+		return nil, nil, errors.Newf("http configuration not found in router.yaml")
 	}
 	// Get handler functions from router
 	handlers := router.Handlers()
 	if handlers == nil {
-		return nil, errors.Newf("router.Handlers() returned nil")
+		return nil, nil, errors.Newf("router.Handlers() returned nil")
 	}
 	// Register each handler function
+	funcs := make(map[api.HandlerKey]echo.HandlerFunc, len(group.Handlers))
 	for _, handler := range group.Handlers {
 		handler.Method = strings.ToUpper(handler.Method)
 		if !validHTTPMethod(handler.Method) {
-			return nil, errors.Newf("invalid HTTP method %q for handler %s", handler.Method, handler.Func)
+			return nil, nil, errors.Newf("invalid HTTP method %q for handler %s", handler.Method, handler.Func)
 		}
 		fn, ok := handlers[handler.Func]
 		if !ok {
-			return nil, errors.NotImplemented.Newf("handler function %s not found in router.Handlers()", handler.Func)
+			return nil, nil, errors.NotImplemented.Newf("handler function %s not found in router.Handlers()", handler.Func)
 		}
 		key := api.NewHandlerKey(group, handler)
 		switch f := fn.(type) {
 		case echo.HandlerFunc:
 			if handler.Method == api.MethodWS {
-				return nil, errors.Newf("handler %s declared as WS but signature is not WebSocket", handler.Func)
+				return nil, nil, errors.Newf("handler %s declared as WS but signature is not WebSocket", handler.Func)
 			}
-			m.handlerFuncs[key] = f
+			funcs[key] = f
 		case func(echo.Context) error:
 			if handler.Method == api.MethodWS {
-				return nil, errors.Newf("handler %s declared as WS but signature is not WebSocket", handler.Func)
+				return nil, nil, errors.Newf("handler %s declared as WS but signature is not WebSocket", handler.Func)
 			}
-			m.handlerFuncs[key] = f
+			funcs[key] = f
 		case func(echo.Context, *websocket.Conn) error:
 			if handler.Method != api.MethodWS {
-				return nil, errors.Newf("handler %s has WebSocket signature but method is %s", handler.Func, handler.Method)
+				return nil, nil, errors.Newf("handler %s has WebSocket signature but method is %s", handler.Func, handler.Method)
 			}
-			m.handlerFuncs[key] = m.wrapWebSocket(f)
+			funcs[key] = m.wrapWebSocket(f)
 		default:
-			return nil, errors.Newf("handler %s has unsupported signature", handler.Func)
+			return nil, nil, errors.Newf("handler %s has unsupported signature", handler.Func)
 		}
 	}
 	m.log.Debugf("registered router %s with %d handlers", router.Name(), len(group.Handlers))
-	return group, nil
+	return group, funcs, nil
 }
 
-// RegisterRouters registers one or more routers with the server
+// RegisterRouters registers one or more routers with the server. On a server
+// whose routes were swapped by ReloadRouters, the route set is rebuilt with
+// the new routes and swapped in again.
 func (m *manager) RegisterRouters(routers ...api.Router) error {
+	m.routesMu.Lock()
+	defer m.routesMu.Unlock()
 	for _, r := range routers {
 		// Register router and get handler group
-		g, err := m.registerRouter(r)
+		g, funcs, err := m.registerRouter(r)
 		if err != nil {
 			return err
 		}
+		maps.Copy(m.handlerFuncs, funcs)
 		// Determine which server to use
 		serverName := g.Server
 		if serverName == "" {
@@ -238,11 +304,22 @@ func (m *manager) RegisterRouters(routers ...api.Router) error {
 			return errors.Newf("server %s not found, please call AddServer first", serverName)
 		}
 
+		s.mu.Lock()
 		for _, h := range g.Handlers {
 			key := api.NewHandlerKey(g, h)
 			s.groups[key] = g
 			s.handlers[key] = h
-			if err := m.installHandler(s, g, h); err != nil {
+		}
+		s.mu.Unlock()
+		if s.routes.Load() != nil {
+			if err := m.rebuildRoutes(s); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, h := range g.Handlers {
+			key := api.NewHandlerKey(g, h)
+			if err := m.installHandler(s.echo, s, g, h, m.handlerFuncs[key]); err != nil {
 				return err
 			}
 		}
@@ -250,21 +327,108 @@ func (m *manager) RegisterRouters(routers ...api.Router) error {
 	return nil
 }
 
-// installHandler registers a single handler on the server's echo instance.
-// Used by both RegisterRouters (initial wiring) and Init (rebuild on restart).
-func (m *manager) installHandler(s *server, g *api.HandlerGroup, h *api.Handler) error {
+// rebuildRoutes swaps in a fresh route set holding every route of s, for
+// servers routed by a set swapped in by ReloadRouters. Callers must hold
+// m.routesMu.
+func (m *manager) rebuildRoutes(s *server) error {
+	e := m.newEcho()
+	m.configureEcho(s, e)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, h := range s.handlers {
+		if err := m.installHandler(e, s, s.groups[key], h, m.handlerFuncs[key]); err != nil {
+			return err
+		}
+	}
+	s.routes.Store(e)
+	return nil
+}
+
+// routeSet is the route set of a server rebuilt by ReloadRouters.
+type routeSet struct {
+	echo     *echo.Echo
+	groups   map[api.HandlerKey]*api.HandlerGroup
+	handlers map[api.HandlerKey]*api.Handler
+}
+
+// ReloadRouters replaces the whole route set of every server the given routers
+// target with the routes they declare. Servers no router targets keep their
+// routes. Each route set is built on a fresh echo instance and swapped in only
+// once every router has been loaded, so a failing router changes nothing.
+//
+// The listener and its open connections are kept: requests already being
+// handled finish on the routes they started on, including open WebSockets,
+// and every request read after the swap, even on a kept-alive connection, is
+// routed by the new set.
+func (m *manager) ReloadRouters(routers ...api.Router) error {
+	m.routesMu.Lock()
+	defer m.routesMu.Unlock()
+	funcs := make(map[api.HandlerKey]echo.HandlerFunc)
+	sets := make(map[*server]*routeSet)
+	for _, r := range routers {
+		g, gfuncs, err := m.registerRouter(r)
+		if err != nil {
+			return err
+		}
+		maps.Copy(funcs, gfuncs)
+		if g.Server == "" {
+			return errors.Newf("server name not specified in router configuration")
+		}
+		s, ok := m.servers[g.Server]
+		if !ok {
+			return errors.Newf("server %s not found, please call AddServer first", g.Server)
+		}
+		set, ok := sets[s]
+		if !ok {
+			e := m.newEcho()
+			m.configureEcho(s, e)
+			set = &routeSet{
+				echo:     e,
+				groups:   make(map[api.HandlerKey]*api.HandlerGroup),
+				handlers: make(map[api.HandlerKey]*api.Handler),
+			}
+			sets[s] = set
+		}
+		for _, h := range g.Handlers {
+			key := api.NewHandlerKey(g, h)
+			set.groups[key] = g
+			set.handlers[key] = h
+		}
+	}
+	for s, set := range sets {
+		for key, h := range set.handlers {
+			if err := m.installHandler(set.echo, s, set.groups[key], h, funcs[key]); err != nil {
+				return err
+			}
+		}
+	}
+	maps.Copy(m.handlerFuncs, funcs)
+	for s, set := range sets {
+		s.mu.Lock()
+		s.groups = set.groups
+		s.handlers = set.handlers
+		s.routes.Store(set.echo)
+		s.mu.Unlock()
+		m.log.Infof("reloaded %d handlers on server %s", len(set.handlers), s.name)
+	}
+	return nil
+}
+
+// installHandler registers handler function hf of h on echo instance e of
+// server s. Used by RegisterRouters (initial wiring), Init (rebuild on restart)
+// and ReloadRouters (fresh route set).
+func (m *manager) installHandler(e *echo.Echo, s *server, g *api.HandlerGroup, h *api.Handler, hf echo.HandlerFunc) error {
 	// Create echo group with API prefix.
 	// Trim trailing slash so Echo's literal prefix+path concatenation
 	// doesn't produce double slashes (e.g., "/" + "/health" → "//health").
-	prefix := strings.TrimSuffix(path.Join(s.endpoint.Path, g.Prefix), "/")
-	group := s.echo.Group(prefix)
+	prefix := strings.TrimSuffix(path.Join(s.basePath(), g.Prefix), "/")
+	group := e.Group(prefix)
 
 	mwfuncs, err := m.collectMiddlewares(h, g)
 	if err != nil {
 		return err
 	}
 
-	key := api.NewHandlerKey(g, h)
 	// Normalize root path "/" to "" so the route registers at the
 	// group prefix without a trailing slash. Combined with the
 	// RemoveTrailingSlash pre-middleware, both /prefix and /prefix/
@@ -272,8 +436,7 @@ func (m *manager) installHandler(s *server, g *api.HandlerGroup, h *api.Handler)
 	routePath := strings.TrimSuffix(h.Path, "/")
 	m.log.Infof("register handler %s %s", h.Method, path.Join(prefix, h.Path))
 
-	hf, ok := m.handlerFuncs[key]
-	if !ok {
+	if hf == nil {
 		return nil
 	}
 
@@ -289,28 +452,25 @@ func (m *manager) installHandler(s *server, g *api.HandlerGroup, h *api.Handler)
 	return nil
 }
 
-// collectMiddlewares gathers handler-specific and group-level middlewares
+// collectMiddlewares gathers handler-specific then group-level middlewares,
+// stably sorted by their api.OrderedMiddleware order. The first middleware of
+// the result runs first.
 func (m *manager) collectMiddlewares(h *api.Handler, g *api.HandlerGroup) ([]echo.MiddlewareFunc, error) {
-	var mwfuncs []echo.MiddlewareFunc
-
-	// Collect handler-specific middlewares
-	for _, name := range h.Middlewares {
-		if mw, ok := m.middlewareFuncs[name]; ok {
-			mwfuncs = append(mwfuncs, mw)
-		} else {
+	var names []string
+	names = append(names, h.Middlewares...)
+	names = append(names, g.Middlewares...)
+	for _, name := range names {
+		if _, ok := m.middlewareFuncs[name]; !ok {
 			return nil, errors.NotImplemented.Newf("middleware %s not found", name)
 		}
 	}
-
-	// Collect group-level middlewares
-	for _, name := range g.Middlewares {
-		if mw, ok := m.middlewareFuncs[name]; ok {
-			mwfuncs = append(mwfuncs, mw)
-		} else {
-			return nil, errors.NotImplemented.Newf("middleware %s not found", name)
-		}
+	sort.SliceStable(names, func(i, j int) bool {
+		return m.middlewareOrders[names[i]] < m.middlewareOrders[names[j]]
+	})
+	mwfuncs := make([]echo.MiddlewareFunc, 0, len(names))
+	for _, name := range names {
+		mwfuncs = append(mwfuncs, m.middlewareFuncs[name])
 	}
-
 	return mwfuncs, nil
 }
 
@@ -358,6 +518,9 @@ func (m *manager) RegisterMiddlewares(middlewares ...api.Middleware) error {
 			return errors.Conflict.Newf("middleware %s already registered", name)
 		}
 		m.middlewareFuncs[name] = mw.Func
+		if o, ok := mw.(api.OrderedMiddleware); ok {
+			m.middlewareOrders[name] = o.Order()
+		}
 	}
 	return nil
 }
@@ -7,12 +7,14 @@ import (
 	"net"
 	"net/http"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/coder/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
 
 	"github.com/xhanio/framingo/pkg/types/common"
 	"github.com/xhanio/framingo/pkg/utils/log"
@@ -51,17 +53,24 @@ func freePort(t *testing.T) uint {
 // startServer sets up a manager with routers, starts the server, and returns
 // the base URL and a cleanup function.
 func startServer(t *testing.T, routers ...*mockRouter) (baseURL string, cleanup func()) {
+	t.Helper()
+	return startServerWith(t, http.DefaultClient, "http", nil, routers...)
+}
+
+// startServerWith is startServer with extra server options, polling readiness
+// with the given client and scheme.
+func startServerWith(t *testing.T, client *http.Client, scheme string, opts []ServerOption, routers ...*mockRouter) (baseURL string, cleanup func()) {
 	t.Helper()
 	port := freePort(t)
 	m := testManager()
-	require.NoError(t, m.Add("http", WithEndpoint("127.0.0.1", port, "/")))
+	require.NoError(t, m.Add("http", append([]ServerOption{WithEndpoint("127.0.0.1", port, "/")}, opts...)...))
 	for _, r := range routers {
 		require.NoError(t, m.RegisterRouters(r))
 	}
 	require.NoError(t, m.Start(context.Background()))
-	baseURL = fmt.Sprintf("http://127.0.0.1:%d", port)
+	baseURL = fmt.Sprintf("%s://127.0.0.1:%d", scheme, port)
 	require.Eventually(t, func() bool {
-		resp, err := http.Get(baseURL + "/")
+		resp, err := client.Get(baseURL + "/")
 		if err != nil {
 			return false
 		}
@@ -418,3 +427,275 @@ handlers:
 	assert.ErrorAs(t, err, &closeErr)
 	assert.Equal(t, websocket.StatusInternalError, closeErr.Code)
 }
+
+// mockMiddleware records its name into trace when it runs.
+type mockMiddleware struct {
+	name  string
+	trace *[]string
+}
+
+func (mw *mockMiddleware) Name() string                   { return mw.name }
+func (mw *mockMiddleware) Dependencies() []common.Service { return nil }
+func (mw *mockMiddleware) Func(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		*mw.trace = append(*mw.trace, mw.name)
+		return next(c)
+	}
+}
+
+type orderedMiddleware struct {
+	mockMiddleware
+	order int
+}
+
+func (mw *orderedMiddleware) Order() int { return mw.order }
+
+func TestMiddleware_Order(t *testing.T) {
+	var trace []string
+	port := freePort(t)
+	m := testManager()
+	require.NoError(t, m.Add("http", WithEndpoint("127.0.0.1", port, "/")))
+	require.NoError(t, m.RegisterMiddlewares(
+		&orderedMiddleware{mockMiddleware{"auth", &trace}, -10},
+		&mockMiddleware{"deflate", &trace},
+		&mockMiddleware{"feature", &trace},
+		&orderedMiddleware{mockMiddleware{"audit", &trace}, 10},
+	))
+	require.NoError(t, m.RegisterRouters(&mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /api
+middlewares: [audit, feature]
+handlers:
+  - method: GET
+    path: /test
+    func: Test
+    middlewares: [deflate, auth]`),
+		handlers: map[string]any{"Test": okHandler},
+	}))
+	require.NoError(t, m.Start(context.Background()))
+	defer func() { require.NoError(t, m.Stop(true)) }()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/test", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// auth first by order, unordered ones in declaration order (handler, then group), audit last
+	assert.Equal(t, []string{"auth", "deflate", "feature", "audit"}, trace)
+}
+
+func TestReloadRouters(t *testing.T) {
+	port := freePort(t)
+	m := testManager()
+	require.NoError(t, m.Add("http", WithEndpoint("127.0.0.1", port, "/")))
+	require.NoError(t, m.RegisterRouters(&mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /
+handlers:
+  - method: GET
+    path: /health
+    func: Health`),
+		handlers: map[string]any{"Health": okHandler},
+	}))
+	require.NoError(t, m.Start(context.Background()))
+	defer func() { require.NoError(t, m.Stop(true)) }()
+	base := fmt.Sprintf("http://127.0.0.1:%d", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(base + "/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	code, _ := httpDo(t, "GET", base+"/version")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	reloaded := &mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /
+handlers:
+  - method: GET
+    path: /version
+    func: Version`),
+		handlers: map[string]any{"Version": func(c echo.Context) error {
+			return c.String(http.StatusOK, "v2")
+		}},
+	}
+	require.NoError(t, m.ReloadRouters(reloaded))
+
+	code, body := httpDo(t, "GET", base+"/version")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "v2", body)
+	code, _ = httpDo(t, "GET", base+"/health")
+	assert.Equal(t, http.StatusNotFound, code, "routes left out of the reload are removed")
+
+	// a failing router leaves the current route set in place
+	err := m.ReloadRouters(&mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /
+handlers:
+  - method: GET
+    path: /health
+    func: Missing`),
+		handlers: map[string]any{},
+	})
+	assert.True(t, errors.Is(err, errors.NotImplemented))
+	code, _ = httpDo(t, "GET", base+"/version")
+	assert.Equal(t, http.StatusOK, code)
+	s, err := m.Get("http")
+	require.NoError(t, err)
+	require.Len(t, s.Routers(), 1)
+	assert.Equal(t, "/version", s.Routers()[0].Handlers[0].Path)
+
+	// routers registered after a reload join the reloaded route set
+	require.NoError(t, m.RegisterRouters(&mockRouter{
+		name: "late",
+		config: []byte(`server: http
+prefix: /late
+handlers:
+  - method: GET
+    path: /ping
+    func: Ping`),
+		handlers: map[string]any{"Ping": func(c echo.Context) error {
+			return c.String(http.StatusOK, "pong")
+		}},
+	}))
+	code, body = httpDo(t, "GET", base+"/late/ping")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "pong", body)
+	code, _ = httpDo(t, "GET", base+"/version")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestInFlightAndDrain(t *testing.T) {
+	port := freePort(t)
+	m := testManager()
+	require.NoError(t, m.Add("http", WithEndpoint("127.0.0.1", port, "/"), WithProbes("/livez", "/readyz")))
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, m.RegisterRouters(&mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /
+handlers:
+  - method: GET
+    path: /slow
+    func: Slow`),
+		handlers: map[string]any{"Slow": func(c echo.Context) error {
+			close(entered)
+			<-release
+			return c.String(http.StatusOK, "done")
+		}},
+	}))
+	require.NoError(t, m.Start(context.Background()))
+	defer func() { require.NoError(t, m.Stop(true)) }()
+	base := fmt.Sprintf("http://127.0.0.1:%d", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(base + "/livez")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, m.InFlight("http"), "probes are not counted")
+	assert.Equal(t, 0, m.InFlight("unknown"))
+
+	done := make(chan int)
+	go func() {
+		code, _ := httpDo(t, "GET", base+"/slow")
+		done <- code
+	}()
+	<-entered
+	assert.Equal(t, 1, m.InFlight("http"))
+
+	// draining fails readiness only, the slow request keeps going
+	code, _ := httpDo(t, "GET", base+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	require.NoError(t, m.Ready())
+	m.Drain()
+	assert.True(t, m.Draining())
+	assert.True(t, errors.Is(m.Ready(), errors.Unavailable))
+	code, _ = httpDo(t, "GET", base+"/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, _ = httpDo(t, "GET", base+"/livez")
+	assert.Equal(t, http.StatusOK, code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Eventually(t, func() bool { return m.InFlight("http") == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStatic(t *testing.T) {
+	assets := fstest.MapFS{
+		"index.html":    {Data: []byte("<html>app</html>")},
+		"assets/app.js": {Data: []byte("console.log('app')")},
+	}
+	api := &mockRouter{
+		name: "test",
+		config: []byte(`server: http
+prefix: /api
+handlers:
+  - method: GET
+    path: /ping
+    func: Ping`),
+		handlers: map[string]any{"Ping": okHandler},
+	}
+
+	t.Run("serves assets with spa fallback", func(t *testing.T) {
+		base, cleanup := startServerWith(t, http.DefaultClient, "http", []ServerOption{WithStatic("/", assets, true)}, api)
+		defer cleanup()
+
+		code, body := httpDo(t, http.MethodGet, base+"/assets/app.js")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "console.log('app')", body)
+
+		code, body = httpDo(t, http.MethodGet, base+"/")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "<html>app</html>", body)
+
+		// client-side route of the single page app
+		code, body = httpDo(t, http.MethodGet, base+"/users/42")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "<html>app</html>", body)
+
+		// router handlers take precedence over the static routes
+		code, _ = httpDo(t, http.MethodGet, base+"/api/ping")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("answers 404 without fallback", func(t *testing.T) {
+		base, cleanup := startServerWith(t, http.DefaultClient, "http", []ServerOption{WithStatic("/ui", assets, false)}, api)
+		defer cleanup()
+
+		code, body := httpDo(t, http.MethodGet, base+"/ui/assets/app.js")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "console.log('app')", body)
+
+		code, body = httpDo(t, http.MethodGet, base+"/ui")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "<html>app</html>", body)
+
+		code, _ = httpDo(t, http.MethodGet, base+"/ui/users/42")
+		assert.Equal(t, http.StatusNotFound, code)
+		// sent as is, the client would otherwise resolve the dot segments
+		req, err := http.NewRequest(http.MethodGet, base, nil)
+		require.NoError(t, err)
+		req.URL.Opaque = "/ui/../../etc/passwd"
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -53,6 +53,7 @@ func (mw *middlewares) Info(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 		c.Set(api.ContextKeyRequestInfo, req)
 		c.Set(api.ContextKeyTrace, req.TraceID)
+		c.Set(api.ContextKeyClientIP, req.IP)
 		err := next(c)
 		resp := mw.server.responseInfo(req.StartedAt, c)
 		c.Set(api.ContextKeyResponseInfo, resp)
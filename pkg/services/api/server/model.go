@@ -20,6 +20,14 @@ type Manager interface {
 	Get(name string) (Server, error)
 	List() []Server
 	RegisterRouters(routers ...api.Router) error
+	ReloadRouters(routers ...api.Router) error
 	RegisterMiddlewares(middlewares ...api.Middleware) error
 	Add(name string, opts ...ServerOption) error
+	// InFlight returns the number of requests the named server is serving.
+	InFlight(serverName string) int
+	// Drain marks the manager as draining: readiness probes answer 503 and
+	// Ready fails, while requests keep being served until Stop.
+	Drain()
+	Draining() bool
+	common.Readiness
 }
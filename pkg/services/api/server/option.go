@@ -1,6 +1,8 @@
 package server
 
 import (
+	"io/fs"
+
 	"golang.org/x/time/rate"
 
 	"github.com/xhanio/framingo/pkg/types/api"
@@ -58,6 +60,65 @@ func WithTLS(cert certutil.CertBundle, auth bool) ServerOption {
 	}
 }
 
+// WithHTTP2 enables HTTP/2. On TLS servers it is negotiated through ALPN;
+// without TLS it requires h2c (HTTP/2 over cleartext, e.g. for internal mesh
+// traffic). Servers without this option keep serving HTTP/1.1 only.
+func WithHTTP2(h2c bool) ServerOption {
+	return func(s *server) {
+		s.http2 = true
+		s.h2c = h2c
+	}
+}
+
+// WithProbes serves a liveness and a readiness probe on the given absolute
+// paths, e.g. "/livez" and "/readyz", for Kubernetes to poll. Both answer 200
+// until Manager.Drain is called, after which readiness answers 503 so the pod
+// is taken out of rotation while in-flight requests finish. An empty path
+// disables that probe.
+func WithProbes(livenessPath, readinessPath string) ServerOption {
+	return func(s *server) {
+		s.livenessPath = livenessPath
+		s.readinessPath = readinessPath
+	}
+}
+
+// WithStatic serves the files of fsys under urlPrefix, which is relative to the
+// endpoint prefix like the prefix of a router. fsys is typically an embed.FS
+// narrowed to the asset directory with fs.Sub, so the assets ship inside the
+// binary. Router handlers take precedence over the files. With spaFallback,
+// GET requests under urlPrefix that match neither a handler nor a file are
+// answered with index.html, letting a single page app handle its own
+// client-side routes; without it they are answered with 404.
+func WithStatic(urlPrefix string, fsys fs.FS, spaFallback bool) ServerOption {
+	return func(s *server) {
+		s.staticPrefix = urlPrefix
+		s.staticFS = fsys
+		s.spaFallback = spaFallback
+	}
+}
+
+// WithMaxBodySize caps request bodies at n bytes. Requests declaring a larger
+// Content-Length are answered with 413 right away; bodies that turn out larger
+// while being read fail the read, see StreamUpload. A size of 0 or less leaves
+// bodies unlimited.
+func WithMaxBodySize(n int64) ServerOption {
+	return func(s *server) {
+		s.maxBodySize = n
+	}
+}
+
+// WithTrustedProxies resolves the client IP from the X-Forwarded-For or
+// X-Real-IP header only when the immediate peer, and every proxy in between,
+// is within one of cidrs, e.g. "10.0.0.0/8" or a single "192.168.1.10".
+// Otherwise the socket address is used, so clients cannot spoof the IP that
+// throttling and logging key on. Without this option the headers are trusted
+// from any peer.
+func WithTrustedProxies(cidrs ...string) ServerOption {
+	return func(s *server) {
+		s.trustedProxies = append(s.trustedProxies, cidrs...)
+	}
+}
+
 func WithThrottle(rps float64, burstSize int) ServerOption {
 	return func(s *server) {
 		if rps == 0 || burstSize == 0 {
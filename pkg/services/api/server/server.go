@@ -2,10 +2,19 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"net/http"
 	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/labstack/echo/v4"
+	"github.com/xhanio/errors"
+	"golang.org/x/net/http2"
 
 	"github.com/xhanio/framingo/pkg/types/api"
 	"github.com/xhanio/framingo/pkg/utils/log"
@@ -22,10 +31,28 @@ type server struct {
 	endpoint       *api.Endpoint
 	tlsConfig      *api.ServerTLS
 	throttleConfig *api.ThrottleConfig
-	echo           *echo.Echo
+	http2          bool // serve HTTP/2 via ALPN on TLS
+	h2c            bool // serve HTTP/2 over cleartext
+	echo           *echo.Echo                // owns the listener
+	routes         atomic.Pointer[echo.Echo] // route set swapped in by ReloadRouters, nil when echo routes itself
 
+	mu       sync.RWMutex // guards groups and handlers, swapped together by ReloadRouters
 	groups   map[api.HandlerKey]*api.HandlerGroup
 	handlers map[api.HandlerKey]*api.Handler
+
+	livenessPath  string
+	readinessPath string
+	draining      *atomic.Bool // shared with the manager, see Manager.Drain
+	inFlight      atomic.Int64
+
+	staticPrefix string
+	staticFS     fs.FS // nil when no static files are served, see WithStatic
+	spaFallback  bool
+
+	maxBodySize int64 // 0 when request bodies are unlimited, see WithMaxBodySize
+
+	trustedProxies []string
+	ipExtractor    echo.IPExtractor // nil to trust forwarding headers from any peer, see WithTrustedProxies
 }
 
 func (s *server) Name() string {
@@ -36,28 +63,222 @@ func (s *server) Endpoint() *api.Endpoint {
 	return s.endpoint
 }
 
-func (s *server) HandlerPath(group *api.HandlerGroup, handler *api.Handler) string {
-	var ep, gp string
-	if s.endpoint != nil {
-		ep = s.endpoint.Path
+// basePath returns the path of the server endpoint, "" if it has none.
+func (s *server) basePath() string {
+	if s.endpoint == nil {
+		return ""
 	}
+	return s.endpoint.Path
+}
+
+func (s *server) HandlerPath(group *api.HandlerGroup, handler *api.Handler) string {
+	var gp string
 	if group != nil {
 		gp = group.Prefix
 	}
-	return path.Join(ep, gp, handler.Path)
+	return path.Join(s.basePath(), gp, handler.Path)
 }
 
 // Routers returns all handler groups and handlers for this server
 func (s *server) Routers() []*api.HandlerGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return maputil.Values(s.groups)
 }
 
+// delegate is the first pre middleware of s.echo. Once ReloadRouters has
+// swapped in a route set, it hands every request over to it, so the listener
+// owned by s.echo keeps serving while the routes change underneath.
+func (s *server) delegate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if e := s.routes.Load(); e != nil {
+			e.ServeHTTP(c.Response(), c.Request())
+			return nil
+		}
+		return next(c)
+	}
+}
+
+// probe is the outermost pre middleware of s.echo. It answers the liveness
+// and readiness probes configured by WithProbes ahead of routing, so they keep
+// working across ReloadRouters and are not counted as in flight. Readiness
+// fails with 503 once the manager is draining, liveness never does.
+func (s *server) probe(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch p := c.Request().URL.Path; {
+		case s.livenessPath != "" && p == s.livenessPath:
+			return c.String(http.StatusOK, "ok")
+		case s.readinessPath != "" && p == s.readinessPath:
+			if s.draining != nil && s.draining.Load() {
+				return c.String(http.StatusServiceUnavailable, "draining")
+			}
+			return c.String(http.StatusOK, "ok")
+		}
+		return next(c)
+	}
+}
+
+// limitBody is a pre middleware of s.echo that enforces WithMaxBodySize. It
+// runs once ahead of the route sets, so the limit holds across ReloadRouters.
+func (s *server) limitBody(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		r := c.Request()
+		if s.maxBodySize <= 0 || r.Body == nil || r.Body == http.NoBody {
+			return next(c)
+		}
+		if r.ContentLength > s.maxBodySize {
+			return RequestTooLarge.Newf("request body of %d bytes exceeds the limit of %d bytes", r.ContentLength, s.maxBodySize)
+		}
+		r.Body = http.MaxBytesReader(c.Response(), r.Body, s.maxBodySize)
+		return next(c)
+	}
+}
+
+// static is a pre middleware that serves the files configured by WithStatic.
+// It runs ahead of routing, like the probes, so static requests skip the
+// router middlewares, which only know about declared handlers. Requests a
+// router handler matches, and paths that match no file unless the SPA
+// fallback is enabled, are passed on to the routes.
+func (s *server) static(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		r := c.Request()
+		if s.staticFS == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			return next(c)
+		}
+		name, ok := s.staticName(r.URL.Path)
+		if !ok || s.routed(c) {
+			return next(c)
+		}
+		err := serveFile(c, s.staticFS, name)
+		if err == echo.ErrNotFound && s.spaFallback {
+			err = serveFile(c, s.staticFS, "index.html")
+		}
+		if err == echo.ErrNotFound {
+			return next(c)
+		}
+		return err
+	}
+}
+
+// staticName maps a request path under the static prefix to a name in the
+// static file system. fs.FS names are relative and may not contain "..", so
+// the path is cleaned against the root first.
+func (s *server) staticName(p string) (string, bool) {
+	prefix := path.Join("/", s.basePath(), s.staticPrefix)
+	p = path.Clean("/" + p)
+	if prefix != "/" {
+		if p != prefix && !strings.HasPrefix(p, prefix+"/") {
+			return "", false
+		}
+		p = path.Join("/", strings.TrimPrefix(p, prefix))
+	}
+	if p == "/" {
+		return ".", true
+	}
+	return p[1:], true
+}
+
+// routed reports whether a router handler of s matches the request.
+func (s *server) routed(c echo.Context) bool {
+	r := c.Request()
+	c.Echo().Router().Find(r.Method, echo.GetPath(r), c)
+	req := &api.RequestInfo{
+		Server:  s.name,
+		Method:  r.Method,
+		RawPath: c.Path(),
+	}
+	h, _ := s.matchHandler(req.Key(s.basePath()))
+	return h != nil
+}
+
+// serveFile writes the named file of fsys, or the index.html of a directory,
+// to the response. It returns echo.ErrNotFound if there is no such file.
+func serveFile(c echo.Context, fsys fs.FS, name string) error {
+	fi, err := fs.Stat(fsys, name)
+	if err == nil && fi.IsDir() {
+		name = path.Join(name, "index.html")
+		fi, err = fs.Stat(fsys, name)
+	}
+	if err != nil || fi.IsDir() {
+		return echo.ErrNotFound
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+	defer f.Close()
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		return errors.NotImplemented.Newf("file %s of the static file system is not seekable", name)
+	}
+	http.ServeContent(c.Response(), c.Request(), fi.Name(), fi.ModTime(), rs)
+	return nil
+}
+
+// track counts the requests s is serving, see Manager.InFlight.
+func (s *server) track(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		return next(c)
+	}
+}
+
+// buildIPExtractor parses the trusted proxies into an echo.IPExtractor that
+// takes the client IP from X-Forwarded-For, or X-Real-IP without it, only
+// through trusted peers.
+func (s *server) buildIPExtractor() error {
+	if len(s.trustedProxies) == 0 {
+		return nil
+	}
+	// echo trusts loopback, link-local and private addresses by default
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range s.trustedProxies {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return errors.InvalidArgument.Newf("invalid trusted proxy %s of server %s", cidr, s.name)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			cidr = fmt.Sprintf("%s/%d", ip, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return errors.InvalidArgument.Wrapf(err, "invalid trusted proxy %s of server %s", cidr, s.name)
+		}
+		opts = append(opts, echo.TrustIPRange(ipNet))
+	}
+	xff := echo.ExtractIPFromXFFHeader(opts...)
+	realIP := echo.ExtractIPFromRealIPHeader(opts...)
+	s.ipExtractor = func(r *http.Request) string {
+		if r.Header.Get(echo.HeaderXForwardedFor) != "" {
+			return xff(r)
+		}
+		return realIP(r)
+	}
+	return nil
+}
+
 // start starts a single HTTP or HTTPS server
 func (s *server) start() error {
 	if s.endpoint == nil {
 		return nil
 	}
 	if s.tlsConfig == nil {
+		if s.http2 && s.h2c {
+			s.log.Infof("serves http [%s] with h2c on %s", s.name, s.endpoint.String())
+			return s.echo.StartH2CServer(s.endpoint.Address(), &http2.Server{})
+		}
+		if s.http2 {
+			s.log.Warnf("http2 on server %s requires tls or h2c, falling back to http/1.1", s.name)
+		}
 		s.log.Infof("serves http [%s] on %s", s.name, s.endpoint.String())
 		return s.echo.Start(s.endpoint.Address())
 	}
@@ -65,6 +286,12 @@ func (s *server) start() error {
 		Addr:      s.endpoint.Address(),
 		TLSConfig: s.tlsConfig.AsConfig(),
 	}
+	if s.http2 {
+		// advertises h2 through ALPN on the tls listener echo creates from TLSConfig
+		if err := http2.ConfigureServer(s.echo.TLSServer, &http2.Server{}); err != nil {
+			return errors.Wrap(err)
+		}
+	}
 	s.log.Infof("serves https [%s] on %s", s.name, s.endpoint.String())
 	return s.echo.StartServer(s.echo.TLSServer)
 }
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+	"golang.org/x/net/http2"
+
+	"github.com/xhanio/framingo/pkg/types/api"
+	"github.com/xhanio/framingo/pkg/utils/certutil"
+)
+
+var protoRouter = &mockRouter{
+	name: "test",
+	config: []byte(`server: http
+prefix: /
+handlers:
+  - method: GET
+    path: /proto
+    func: Proto`),
+	handlers: map[string]any{"Proto": func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Request().Proto)
+	}},
+}
+
+func getProto(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.ProtoMajor, string(body)
+}
+
+func TestHTTP2_TLS(t *testing.T) {
+	root, err := certutil.New(certutil.WithCommonName("root"))
+	require.NoError(t, err)
+	cert, err := root.SignServer(&certutil.ServerRequest{
+		CommonName: "server",
+		IPs:        []net.IP{net.ParseIP("127.0.0.1")},
+	})
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: certutil.NewCertPool(root.Cert())},
+		ForceAttemptHTTP2: true,
+	}}
+
+	t.Run("negotiated via alpn", func(t *testing.T) {
+		base, cleanup := startServerWith(t, client, "https", []ServerOption{WithTLS(cert, false), WithHTTP2(false)}, protoRouter)
+		defer cleanup()
+		major, body := getProto(t, client, base+"/proto")
+		assert.Equal(t, 2, major)
+		assert.Equal(t, "HTTP/2.0", body)
+	})
+
+	t.Run("http/1.1 when disabled", func(t *testing.T) {
+		base, cleanup := startServerWith(t, client, "https", []ServerOption{WithTLS(cert, false)}, protoRouter)
+		defer cleanup()
+		major, body := getProto(t, client, base+"/proto")
+		assert.Equal(t, 1, major)
+		assert.Equal(t, "HTTP/1.1", body)
+	})
+}
+
+func TestHTTP2_H2C(t *testing.T) {
+	// prior knowledge h2c client: speaks HTTP/2 over a plain tcp connection
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	base, cleanup := startServerWith(t, client, "http", []ServerOption{WithHTTP2(true)}, protoRouter)
+	defer cleanup()
+	major, body := getProto(t, client, base+"/proto")
+	assert.Equal(t, 2, major)
+	assert.Equal(t, "HTTP/2.0", body)
+
+	// plain http/1.1 clients keep working
+	major, body = getProto(t, http.DefaultClient, base+"/proto")
+	assert.Equal(t, 1, major)
+	assert.Equal(t, "HTTP/1.1", body)
+}
+
+var ipRouter = &mockRouter{
+	name: "ip",
+	config: []byte(`server: http
+prefix: /
+handlers:
+  - method: GET
+    path: /ip
+    func: IP`),
+	handlers: map[string]any{"IP": func(c echo.Context) error {
+		return c.String(http.StatusOK, fmt.Sprintf("%v %s", c.Get(api.ContextKeyClientIP), c.RealIP()))
+	}},
+}
+
+func getIP(t *testing.T, url string, headers map[string]string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	ip, realIP, _ := strings.Cut(string(body), " ")
+	assert.Equal(t, ip, realIP, "context and RealIP disagree")
+	return ip
+}
+
+func TestTrustedProxies(t *testing.T) {
+	t.Run("trusted peer", func(t *testing.T) {
+		base, cleanup := startServerWith(t, http.DefaultClient, "http", []ServerOption{WithTrustedProxies("127.0.0.1", "10.0.0.0/8")}, ipRouter)
+		defer cleanup()
+		assert.Equal(t, "127.0.0.1", getIP(t, base+"/ip", nil))
+		assert.Equal(t, "203.0.113.7", getIP(t, base+"/ip", map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+		assert.Equal(t, "203.0.113.7", getIP(t, base+"/ip", map[string]string{"X-Real-IP": "203.0.113.7"}))
+		// the first untrusted hop from the right wins, a spoofed leftmost entry is ignored
+		assert.Equal(t, "198.51.100.2", getIP(t, base+"/ip", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.2, 10.1.2.3"}))
+	})
+
+	t.Run("untrusted peer", func(t *testing.T) {
+		base, cleanup := startServerWith(t, http.DefaultClient, "http", []ServerOption{WithTrustedProxies("10.0.0.0/8")}, ipRouter)
+		defer cleanup()
+		assert.Equal(t, "127.0.0.1", getIP(t, base+"/ip", map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+		assert.Equal(t, "127.0.0.1", getIP(t, base+"/ip", map[string]string{"X-Real-IP": "203.0.113.7"}))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := testManager().Add("http", WithEndpoint("127.0.0.1", freePort(t), "/"), WithTrustedProxies("10.0.0.0/33"))
+		assert.True(t, errors.Is(err, errors.InvalidArgument))
+		err = testManager().Add("http", WithEndpoint("127.0.0.1", freePort(t), "/"), WithTrustedProxies("proxy"))
+		assert.True(t, errors.Is(err, errors.InvalidArgument))
+	})
+}
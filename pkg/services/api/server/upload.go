@@ -0,0 +1,77 @@
+package server
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/xhanio/errors"
+)
+
+// RequestTooLarge is the category of errors for request bodies exceeding the
+// limit set by WithMaxBodySize, answered with 413.
+var RequestTooLarge = errors.NewCategory("RequestTooLarge", http.StatusRequestEntityTooLarge)
+
+// StreamUpload copies the file part named fieldName of a multipart request to
+// sink as it arrives, so the upload is never held in memory or spooled to a
+// temporary file like with c.FormFile. sink is typically a
+// buffer.PooledBuffer or an *os.File. Parts ahead of fieldName are skipped,
+// parts after it are left unread. It returns the number of bytes written to
+// sink, which on error is what was written before the failure.
+//
+// Bodies exceeding the limit set by WithMaxBodySize fail with
+// RequestTooLarge, malformed or non-multipart bodies and a missing part with
+// errors.BadRequest.
+func StreamUpload(c echo.Context, fieldName string, sink io.Writer) (int64, error) {
+	mr, err := c.Request().MultipartReader()
+	if err != nil {
+		return 0, errors.BadRequest.Wrapf(err, "failed to read multipart body")
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return 0, errors.BadRequest.Newf("multipart body has no part %s", fieldName)
+		}
+		if err != nil {
+			return 0, uploadError(err)
+		}
+		if part.FormName() != fieldName {
+			part.Close()
+			continue
+		}
+		r := &uploadReader{r: part}
+		n, err := io.Copy(sink, r)
+		part.Close()
+		if r.err != nil {
+			return n, uploadError(r.err)
+		}
+		if err != nil {
+			return n, errors.Wrapf(err, "failed to write part %s", fieldName)
+		}
+		return n, nil
+	}
+}
+
+// uploadReader records read errors of the request body, telling them apart
+// from write errors of the sink after io.Copy.
+type uploadReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func uploadError(err error) error {
+	var mbe *http.MaxBytesError
+	if stderrors.As(err, &mbe) {
+		return RequestTooLarge.Newf("request body exceeds the limit of %d bytes", mbe.Limit)
+	}
+	return errors.BadRequest.Wrapf(err, "failed to read multipart body")
+}
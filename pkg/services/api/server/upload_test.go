@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signalSink records what StreamUpload writes and closes started on the first
+// write.
+type signalSink struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	writes  int
+	once    sync.Once
+	started chan struct{}
+}
+
+func (s *signalSink) Write(p []byte) (int, error) {
+	s.once.Do(func() { close(s.started) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes++
+	return s.buf.Write(p)
+}
+
+func (s *signalSink) result() ([]byte, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Bytes(), s.writes
+}
+
+func uploadRouter(sink *signalSink) *mockRouter {
+	return &mockRouter{
+		name: "upload",
+		config: []byte(`server: http
+prefix: /
+handlers:
+  - method: POST
+    path: /upload
+    func: Upload`),
+		handlers: map[string]any{"Upload": func(c echo.Context) error {
+			n, err := StreamUpload(c, "file", sink)
+			if err != nil {
+				return err
+			}
+			return c.String(http.StatusOK, strconv.FormatInt(n, 10))
+		}},
+	}
+}
+
+// uploadBody streams a multipart body with a "note" field followed by a "file"
+// part of data through a pipe. The first half of data is written up front;
+// the second half only once wait returns, so the body is never complete
+// before the handler starts consuming it.
+func uploadBody(t *testing.T, data []byte, wait func() bool) (io.Reader, string) {
+	t.Helper()
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := mw.WriteField("note", "skipped")
+		var fw io.Writer
+		if err == nil {
+			fw, err = mw.CreateFormFile("file", "data.bin")
+		}
+		if err == nil {
+			_, err = fw.Write(data[:len(data)/2])
+		}
+		if err == nil && !wait() {
+			err = io.ErrClosedPipe
+		}
+		if err == nil {
+			_, err = fw.Write(data[len(data)/2:])
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, mw.FormDataContentType()
+}
+
+func postUpload(t *testing.T, url string, body io.Reader, contentType string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(url, contentType, body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestStreamUpload(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 8<<20/16) // 8MB
+	sink := &signalSink{started: make(chan struct{})}
+	baseURL, cleanup := startServer(t, uploadRouter(sink))
+	defer cleanup()
+
+	var streamed atomic.Bool
+	body, contentType := uploadBody(t, data, func() bool {
+		select {
+		case <-sink.started:
+			streamed.Store(true)
+		case <-time.After(5 * time.Second):
+		}
+		return streamed.Load()
+	})
+	status, resp := postUpload(t, baseURL+"/upload", body, contentType)
+	require.Equal(t, http.StatusOK, status, resp)
+	assert.True(t, streamed.Load(), "sink received nothing before the body was complete")
+	assert.Equal(t, strconv.Itoa(len(data)), resp)
+	got, writes := sink.result()
+	assert.Greater(t, writes, 1)
+	assert.True(t, bytes.Equal(data, got))
+}
+
+func TestStreamUpload_MaxBodySize(t *testing.T) {
+	sink := &signalSink{started: make(chan struct{})}
+	baseURL, cleanup := startServerWith(t, http.DefaultClient, "http", []ServerOption{WithMaxBodySize(1 << 20)}, uploadRouter(sink))
+	defer cleanup()
+
+	// a streamed body without Content-Length fails while being read
+	data := bytes.Repeat([]byte{'x'}, 2<<20)
+	body, contentType := uploadBody(t, data, func() bool { return true })
+	status, _ := postUpload(t, baseURL+"/upload", body, contentType)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	got, _ := sink.result()
+	assert.Less(t, len(got), 1<<20)
+
+	// a declared Content-Length over the limit is rejected up front
+	status, _ = postUpload(t, baseURL+"/upload", bytes.NewReader(data), contentType)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	// a non multipart body is a bad request
+	status, _ = postUpload(t, baseURL+"/upload", bytes.NewReader([]byte("{}")), "application/json")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
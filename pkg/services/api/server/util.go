@@ -23,7 +23,7 @@ func (s *server) requestInfo(c echo.Context) *api.RequestInfo {
 		traceID = fmt.Sprintf("%s/%s", prevID, traceID)
 	}
 	c.Echo().Router().Find(r.Method, r.URL.EscapedPath(), c)
-	s.log.Debugf("current call (endpoint %s) %s - %s", s.endpoint.Path, r.URL.Path, r.URL.EscapedPath())
+	s.log.Debugf("current call (endpoint %s) %s - %s", s.basePath(), r.URL.Path, r.URL.EscapedPath())
 	req := &api.RequestInfo{
 		Server:    s.name,
 		URI:       r.RequestURI,
@@ -35,7 +35,7 @@ func (s *server) requestInfo(c echo.Context) *api.RequestInfo {
 		StartedAt: time.Now(),
 	}
 	// find the handler and group from this server instance
-	key := req.Key(s.endpoint.Path)
+	key := req.Key(s.basePath())
 	s.log.Debugf("looking for key %s", key.String())
 	h, g := s.matchHandler(key)
 	if h != nil && g != nil {
@@ -113,6 +113,8 @@ func colorDuration(duration time.Duration) string {
 // 3. ANY method match (same path, method=ANY)
 // 4. wildcard path match — longest prefix wins, exact method over ANY
 func (s *server) matchHandler(key api.HandlerKey) (*api.Handler, *api.HandlerGroup) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	// exact match
 	if h, ok := s.handlers[key]; ok {
 		return h, s.groups[key]
@@ -132,7 +134,7 @@ func (s *server) matchHandler(key api.HandlerKey) (*api.Handler, *api.HandlerGro
 	// wildcard path match: iterate stored keys ending with /* and match
 	// against the actual request path. Longest prefix wins (most specific),
 	// and exact method takes priority over ANY at the same prefix length.
-	reqPath := strings.TrimPrefix(key.Path, s.endpoint.Path)
+	reqPath := strings.TrimPrefix(key.Path, s.basePath())
 	if !strings.HasPrefix(reqPath, "/") {
 		reqPath = "/" + reqPath
 	}
@@ -3,12 +3,15 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/xhanio/errors"
 	"go.uber.org/zap/zapcore"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"moul.io/zapgorm2"
+
+	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 func (m *manager) use(dbtype string, dsn string) (gorm.Dialector, error) {
@@ -74,6 +77,42 @@ func (m *manager) Cleanup(schema bool) error {
 	return d.Cleanup(m.ormDB, m.source.DBName, schema)
 }
 
+func (m *manager) Tables() ([]string, error) {
+	d, err := lookupDriver(m.dbtype)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if d.Tables == nil {
+		return nil, errors.NotImplemented.Newf("schema introspection not supported for database type: %s", m.dbtype)
+	}
+	tables, err := d.Tables(m.ormDB, m.source.DBName)
+	if err != nil {
+		return nil, errors.DBFailed.Wrap(err)
+	}
+	return tables, nil
+}
+
+func (m *manager) Columns(table string) ([]model.ColumnInfo, error) {
+	d, err := lookupDriver(m.dbtype)
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if d.Columns == nil {
+		return nil, errors.NotImplemented.Newf("schema introspection not supported for database type: %s", m.dbtype)
+	}
+	columns, err := d.Columns(m.ormDB, m.source.DBName, table)
+	if err != nil {
+		return nil, errors.DBFailed.Wrap(err)
+	}
+	if len(columns) == 0 {
+		return nil, errors.NotFound.Newf("table %s not found", table)
+	}
+	for i := range columns {
+		columns[i].Type = strings.ToLower(columns[i].Type)
+	}
+	return columns, nil
+}
+
 func (m *manager) Reload() error {
 	err := m.Cleanup(true)
 	if err != nil {
@@ -1,6 +1,6 @@
 // Package clickhouse registers the ClickHouse GORM dialector, golang-migrate
-// driver, DSN builder, and cleanup hooks with pkg/services/db. Blank-import it
-// to enable ClickHouse support.
+// driver, DSN builder, cleanup, and introspection hooks with pkg/services/db.
+// Blank-import it to enable ClickHouse support.
 package clickhouse
 
 import (
@@ -14,6 +14,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/xhanio/framingo/pkg/services/db"
+	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 func init() {
@@ -22,6 +23,8 @@ func init() {
 		Migration: migration,
 		DSN:       dsn,
 		Cleanup:   cleanup,
+		Tables:    tables,
+		Columns:   columns,
 	})
 }
 
@@ -40,10 +43,25 @@ func dsn(s db.Source) (string, error) {
 			params["secure"] = "true"
 		}
 	}
-	value := fmt.Sprintf("clickhouse://%s:%s@%s:%d/%s?", s.User, s.Password, s.Host, s.Port, s.DBName)
+	value := fmt.Sprintf("clickhouse://%s:%s@%s:%d/%s?", s.User, s.Password.Reveal(), s.Host, s.Port, s.DBName)
 	return db.AppendParams(value, params, "&", "&"), nil
 }
 
+func tables(gdb *gorm.DB, dbName string) ([]string, error) {
+	tables := []string{}
+	err := gdb.Raw("SELECT name FROM system.tables WHERE database = ? ORDER BY name", dbName).Pluck("name", &tables).Error
+	return tables, err
+}
+
+func columns(gdb *gorm.DB, dbName string, table string) ([]model.ColumnInfo, error) {
+	var columns []model.ColumnInfo
+	err := gdb.Raw(`SELECT name, type, startsWith(type, 'Nullable(') AS nullable, is_in_primary_key AS primary_key
+FROM system.columns
+WHERE database = ? AND table = ?
+ORDER BY position`, dbName, table).Scan(&columns).Error
+	return columns, err
+}
+
 func cleanup(gdb *gorm.DB, dbName string, schema bool) error {
 	if schema {
 		if err := gdb.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", dbName)).Error; err != nil {
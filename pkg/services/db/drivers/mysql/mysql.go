@@ -1,6 +1,6 @@
 // Package mysql registers the MySQL GORM dialector, golang-migrate driver,
-// DSN builder, and cleanup hooks with pkg/services/db. Blank-import it to
-// enable MySQL support.
+// DSN builder, cleanup, and introspection hooks with pkg/services/db.
+// Blank-import it to enable MySQL support.
 package mysql
 
 import (
@@ -14,6 +14,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/xhanio/framingo/pkg/services/db"
+	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 func init() {
@@ -22,6 +23,8 @@ func init() {
 		Migration: migration,
 		DSN:       dsn,
 		Cleanup:   cleanup,
+		Tables:    tables,
+		Columns:   columns,
 	})
 }
 
@@ -40,10 +43,25 @@ func dsn(s db.Source) (string, error) {
 			params["tls"] = "true"
 		}
 	}
-	value := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local", s.User, s.Password, s.Host, s.Port, s.DBName)
+	value := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local", s.User, s.Password.Reveal(), s.Host, s.Port, s.DBName)
 	return db.AppendParams(value, params, "&", "&"), nil
 }
 
+func tables(gdb *gorm.DB, _ string) ([]string, error) {
+	tables := []string{}
+	err := gdb.Raw("SELECT table_name AS name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name").Pluck("name", &tables).Error
+	return tables, err
+}
+
+func columns(gdb *gorm.DB, _ string, table string) ([]model.ColumnInfo, error) {
+	var columns []model.ColumnInfo
+	err := gdb.Raw(`SELECT column_name AS name, column_type AS type, is_nullable = 'YES' AS nullable, column_key = 'PRI' AS primary_key
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY ordinal_position`, table).Scan(&columns).Error
+	return columns, err
+}
+
 func cleanup(gdb *gorm.DB, dbName string, schema bool) error {
 	if schema {
 		var name string
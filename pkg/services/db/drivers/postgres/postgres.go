@@ -1,6 +1,6 @@
 // Package postgres registers the Postgres GORM dialector, golang-migrate
-// driver, DSN builder, and cleanup hooks with pkg/services/db. Blank-import it
-// to enable Postgres support.
+// driver, DSN builder, cleanup, and introspection hooks with pkg/services/db.
+// Blank-import it to enable Postgres support.
 package postgres
 
 import (
@@ -14,6 +14,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/xhanio/framingo/pkg/services/db"
+	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 func init() {
@@ -22,6 +23,8 @@ func init() {
 		Migration: migration,
 		DSN:       dsn,
 		Cleanup:   cleanup,
+		Tables:    tables,
+		Columns:   columns,
 	})
 }
 
@@ -42,10 +45,31 @@ func dsn(s db.Source) (string, error) {
 			params["sslmode"] = "disable"
 		}
 	}
-	value := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s", s.Host, s.Port, s.User, s.Password, s.DBName)
+	value := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s", s.Host, s.Port, s.User, s.Password.Reveal(), s.DBName)
 	return db.AppendParams(value, params, " ", " "), nil
 }
 
+func tables(gdb *gorm.DB, _ string) ([]string, error) {
+	tables := []string{}
+	err := gdb.Raw("SELECT tablename FROM pg_tables WHERE schemaname='public' ORDER BY tablename").Pluck("tablename", &tables).Error
+	return tables, err
+}
+
+func columns(gdb *gorm.DB, _ string, table string) ([]model.ColumnInfo, error) {
+	var columns []model.ColumnInfo
+	err := gdb.Raw(`SELECT c.column_name AS name, c.data_type AS type, c.is_nullable = 'YES' AS nullable,
+	EXISTS (
+		SELECT 1 FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema AND kcu.table_name = tc.table_name
+		WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema AND tc.table_name = c.table_name AND kcu.column_name = c.column_name
+	) AS primary_key
+FROM information_schema.columns c
+WHERE c.table_schema = 'public' AND c.table_name = ?
+ORDER BY c.ordinal_position`, table).Scan(&columns).Error
+	return columns, err
+}
+
 func cleanup(gdb *gorm.DB, _ string, schema bool) error {
 	if schema {
 		if err := gdb.Exec("DROP SCHEMA public CASCADE").Error; err != nil {
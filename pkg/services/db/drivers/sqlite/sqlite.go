@@ -1,7 +1,7 @@
 // Package sqlite registers the SQLite GORM dialector, golang-migrate driver,
-// DSN builder, and cleanup hooks with pkg/services/db. Blank-import it to
-// enable SQLite support without forcing every binary to pull in SQLite when
-// they only use other engines.
+// DSN builder, cleanup, and introspection hooks with pkg/services/db.
+// Blank-import it to enable SQLite support without forcing every binary to
+// pull in SQLite when they only use other engines.
 //
 // The engine is github.com/mattn/go-sqlite3, which wraps the C SQLite library
 // and registers the "sqlite3" database/sql driver. Both the dialector and the
@@ -21,6 +21,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/xhanio/framingo/pkg/services/db"
+	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 func init() {
@@ -29,6 +30,8 @@ func init() {
 		Migration: migration,
 		DSN:       dsn,
 		Cleanup:   cleanup,
+		Tables:    tables,
+		Columns:   columns,
 	})
 }
 
@@ -48,6 +51,19 @@ func dsn(s db.Source) (string, error) {
 	return db.AppendParams(value, s.GetParams(), "?", "&"), nil
 }
 
+func tables(gdb *gorm.DB, _ string) ([]string, error) {
+	tables := []string{}
+	err := gdb.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name").Pluck("name", &tables).Error
+	return tables, err
+}
+
+func columns(gdb *gorm.DB, _ string, table string) ([]model.ColumnInfo, error) {
+	var columns []model.ColumnInfo
+	// primary keys are reported as not null, INTEGER PRIMARY KEY columns alias the rowid and never hold NULL
+	err := gdb.Raw(`SELECT name, type, "notnull" = 0 AND pk = 0 AS nullable, pk > 0 AS primary_key FROM pragma_table_info(?) ORDER BY cid`, table).Scan(&columns).Error
+	return columns, err
+}
+
 func cleanup(gdb *gorm.DB, _ string, schema bool) error {
 	if schema {
 		err := gdb.Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,37 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/model"
+)
+
+type introspectItem struct {
+	ID          int64  `gorm:"primaryKey"`
+	Name        string `gorm:"not null"`
+	Description *string
+}
+
+func TestTablesAndColumns(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	require.NoError(t, mgr.ORM().AutoMigrate(&introspectItem{}))
+
+	tables, err := mgr.Tables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"introspect_items", "items"}, tables)
+
+	columns, err := mgr.Columns("introspect_items")
+	require.NoError(t, err)
+	assert.Equal(t, []model.ColumnInfo{
+		{Name: "id", Type: "integer", Nullable: false, PrimaryKey: true},
+		{Name: "name", Type: "text", Nullable: false},
+		{Name: "description", Type: "text", Nullable: true},
+	}, columns)
+
+	_, err = mgr.Columns("missing")
+	assert.True(t, errors.Is(err, errors.NotFound))
+}
@@ -39,6 +39,7 @@ func (m *manager) Init(ctx context.Context) error {
 			return errors.Wrap(err)
 		}
 	}
+	m.startPoolMonitor(ctx)
 	return nil
 }
 
@@ -56,5 +57,14 @@ func (m *manager) Info(w io.Writer, debug bool) {
 	stats := m.sqlDB.Stats()
 	t.Object(stats)
 	t.NewLine()
+	if m.poolMonitor.Interval > 0 {
+		last, count := m.PoolSaturation()
+		t.Title("pool monitor", "value")
+		t.Row("saturations", count)
+		if last != nil {
+			t.Row("last saturation", last.String())
+		}
+		t.NewLine()
+	}
 	t.Flush()
 }
@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"path"
+	"sync"
 
 	"gorm.io/gorm"
 
@@ -15,14 +17,24 @@ type manager struct {
 	name string
 	log  log.Logger
 
-	dbtype     string
-	source     Source
-	migration  migrationConfig
-	connection connectionConfig
+	dbtype      string
+	source      Source
+	migration   migrationConfig
+	connection  connectionConfig
+	poolMonitor poolMonitorConfig
 
 	dialector gorm.Dialector
 	ormDB     *gorm.DB
 	sqlDB     *sql.DB
+
+	sl     sync.RWMutex // lock for scopes
+	scopes map[string]Scope
+
+	pl             sync.Mutex // lock for the pool monitor
+	monitorCancel  context.CancelFunc
+	monitorDone    chan struct{}
+	lastSaturation *PoolSaturation
+	saturations    uint64
 }
 
 func New(opts ...Option) Manager {
@@ -44,3 +56,11 @@ func (m *manager) Name() string {
 func (m *manager) Dependencies() []common.Service {
 	return nil
 }
+
+func (m *manager) close() error {
+	m.stopPoolMonitor()
+	if m.sqlDB == nil {
+		return nil
+	}
+	return m.sqlDB.Close()
+}
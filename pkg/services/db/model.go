@@ -23,6 +23,10 @@ type migrationConfig struct {
 type Manager interface {
 	// business
 	model.Database
+	// PoolSaturation returns the last saturation of the connection pool and
+	// how many were noticed, or nil without a pool monitor or saturation, see
+	// WithPoolMonitor.
+	PoolSaturation() (*PoolSaturation, uint64)
 	// lifecycle
 	common.Initializable
 	common.Debuggable
@@ -6,6 +6,8 @@ import (
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
+const defaultMaxTenants = 16
+
 type Option func(*manager)
 
 func (m *manager) apply(opts ...Option) {
@@ -70,3 +72,41 @@ func WithConnection(maxOpen int, maxIdle int, maxLifetime time.Duration, maxIdle
 		}
 	}
 }
+
+// WithPoolMonitor checks the connection pool every interval and warns when it
+// is saturated: when queries had to wait for a connection since the previous
+// check, or when at least threshold, a fraction of the max open connections
+// such as 0.9, were in use for several checks in a row. See
+// Manager.PoolSaturation for the last saturation. The monitor runs from Init
+// until the context passed to Init is done.
+func WithPoolMonitor(interval time.Duration, threshold float64) Option {
+	return func(m *manager) {
+		if threshold <= 0 || threshold > 1 {
+			threshold = 0.9
+		}
+		m.poolMonitor = poolMonitorConfig{
+			Interval:  interval,
+			Threshold: threshold,
+		}
+	}
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*router)
+
+// WithMaxTenants caps the number of tenant connections kept open at once.
+func WithMaxTenants(n int) RouterOption {
+	return func(r *router) {
+		if n > 0 {
+			r.max = n
+		}
+	}
+}
+
+// WithTenantOptions sets the options of the Manager built for each tenant.
+// WithType and WithDataSource are always overridden by the Router.
+func WithTenantOptions(opts ...Option) RouterOption {
+	return func(r *router) {
+		r.opts = append(r.opts, opts...)
+	}
+}
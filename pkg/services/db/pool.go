@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// sustainedChecks is how many checks in a row the pool has to be near its
+// limit before the monitor reports it as saturated.
+const sustainedChecks = 3
+
+type poolMonitorConfig struct {
+	Interval  time.Duration
+	Threshold float64
+}
+
+// PoolSaturation is a saturation of the connection pool noticed by the pool
+// monitor, see WithPoolMonitor.
+type PoolSaturation struct {
+	At      time.Time
+	Reason  string
+	InUse   int
+	MaxOpen int
+	// WaitCount and WaitDuration are the waits for a connection since the
+	// previous check.
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+func (s *PoolSaturation) String() string {
+	return fmt.Sprintf("%s at %s: %d/%d connections in use, %d waits for %s",
+		s.Reason, s.At.Format(time.RFC3339), s.InUse, s.MaxOpen, s.WaitCount, s.WaitDuration)
+}
+
+func (m *manager) PoolSaturation() (*PoolSaturation, uint64) {
+	m.pl.Lock()
+	defer m.pl.Unlock()
+	return m.lastSaturation, m.saturations
+}
+
+// startPoolMonitor checks the pool stats every interval until ctx is done or
+// stopPoolMonitor is called. It restarts the monitor of a previous Init.
+func (m *manager) startPoolMonitor(ctx context.Context) {
+	m.stopPoolMonitor()
+	if m.poolMonitor.Interval <= 0 || m.sqlDB == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	m.pl.Lock()
+	m.monitorCancel, m.monitorDone = cancel, done
+	m.pl.Unlock()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(m.poolMonitor.Interval)
+		defer ticker.Stop()
+		prev := m.sqlDB.Stats()
+		var busy int
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			stats := m.sqlDB.Stats()
+			waits := stats.WaitCount - prev.WaitCount
+			waited := stats.WaitDuration - prev.WaitDuration
+			prev = stats
+			var reason string
+			if stats.MaxOpenConnections > 0 && float64(stats.InUse) >= m.poolMonitor.Threshold*float64(stats.MaxOpenConnections) {
+				busy++
+			} else {
+				busy = 0
+			}
+			switch {
+			case waits > 0:
+				reason = "queries waited for a connection"
+			case busy >= sustainedChecks:
+				reason = "connections stayed near the limit"
+				busy = 0
+			default:
+				continue
+			}
+			m.saturated(&PoolSaturation{
+				At:           time.Now(),
+				Reason:       reason,
+				InUse:        stats.InUse,
+				MaxOpen:      stats.MaxOpenConnections,
+				WaitCount:    waits,
+				WaitDuration: waited,
+			})
+		}
+	}()
+}
+
+func (m *manager) stopPoolMonitor() {
+	m.pl.Lock()
+	cancel, done := m.monitorCancel, m.monitorDone
+	m.monitorCancel, m.monitorDone = nil, nil
+	m.pl.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (m *manager) saturated(s *PoolSaturation) {
+	m.pl.Lock()
+	m.lastSaturation = s
+	m.saturations++
+	m.pl.Unlock()
+	m.log.Warnf("connection pool saturated: %s", s)
+}
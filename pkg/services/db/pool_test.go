@@ -0,0 +1,58 @@
+package db_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/framingo/pkg/services/db"
+	_ "github.com/xhanio/framingo/pkg/services/db/drivers/sqlite"
+	"github.com/xhanio/framingo/pkg/utils/confutil"
+)
+
+func TestPoolMonitor(t *testing.T) {
+	v := viper.New()
+	v.Set("db.connection.max_open", 1)
+	v.Set("db.connection.max_idle", 1)
+	ctx, cancel := context.WithCancel(confutil.WrapContext(context.Background(), v))
+	defer cancel()
+
+	mgr := db.New(
+		db.WithType(db.SQLite),
+		db.WithDataSource(db.Source{}),
+		db.WithPoolMonitor(10*time.Millisecond, 0.9),
+	)
+	require.NoError(t, mgr.Init(ctx))
+	last, count := mgr.PoolSaturation()
+	assert.Nil(t, last)
+	assert.Zero(t, count)
+
+	// hold the only connection so the next query has to wait for it
+	conn, err := mgr.DB().Conn(ctx)
+	require.NoError(t, err)
+	queried := make(chan error, 1)
+	go func() {
+		queried <- mgr.DB().PingContext(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		last, _ := mgr.PoolSaturation()
+		return last != nil && last.WaitCount > 0
+	}, 2*time.Second, 10*time.Millisecond)
+	last, count = mgr.PoolSaturation()
+	assert.Positive(t, count)
+	assert.Equal(t, 1, last.MaxOpen)
+	assert.Equal(t, 1, last.InUse)
+
+	require.NoError(t, conn.Close())
+	require.NoError(t, <-queried)
+
+	var buf bytes.Buffer
+	mgr.Info(&buf, false)
+	assert.Contains(t, buf.String(), "last saturation")
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/xhanio/errors"
 	"gorm.io/gorm"
+
+	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 // Driver bundles the per-database-engine hooks needed by the manager.
@@ -18,6 +20,8 @@ type Driver struct {
 	Migration func(sqlDB *sql.DB) (database.Driver, error)
 	DSN       func(s Source) (string, error)
 	Cleanup   func(db *gorm.DB, dbName string, schema bool) error
+	Tables    func(db *gorm.DB, dbName string) ([]string, error)
+	Columns   func(db *gorm.DB, dbName string, table string) ([]model.ColumnInfo, error)
 }
 
 type registry struct {
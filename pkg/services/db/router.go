@@ -0,0 +1,200 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/xhanio/errors"
+	"gorm.io/gorm"
+
+	"github.com/xhanio/framingo/pkg/types/common"
+)
+
+// SourceResolver returns the data source of a tenant's database.
+type SourceResolver func(tenant string) (Source, error)
+
+// Router hands out connections to per-tenant databases.
+type Router interface {
+	// ForTenant returns a connection to the database of the tenant carried by
+	// ctx, connecting on first use, and the func releasing it. A connection
+	// evicted while held is closed once its last holder releases it.
+	ForTenant(ctx context.Context) (*gorm.DB, func(), error)
+	// Close closes every open tenant connection.
+	Close() error
+}
+
+type tenantConn struct {
+	tenant string
+	ready  chan struct{} // closed once m and err are set
+	m      *manager
+	err    error
+
+	refs    int  // holders that have not released it yet, guarded by router.mu
+	evicted bool // pushed out of the cache, closed on the last release
+}
+
+type router struct {
+	dbtype  string
+	resolve SourceResolver
+	max     int
+	opts    []Option
+
+	mu    sync.Mutex
+	lru   *list.List // front is the most recently used *tenantConn
+	conns map[string]*list.Element
+}
+
+// NewRouter returns a Router that connects to the database of each tenant
+// with a Manager built from opts and the Source returned by resolve. At most
+// WithMaxTenants connections are kept open; the least recently used one is
+// closed to make room for another tenant.
+func NewRouter(dbtype string, resolve SourceResolver, opts ...RouterOption) Router {
+	r := &router{
+		dbtype:  dbtype,
+		resolve: resolve,
+		max:     defaultMaxTenants,
+		lru:     list.New(),
+		conns:   make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithTenant returns a copy of ctx carrying the tenant ID used by Router.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, common.ContextKeyTenant, tenant)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(common.ContextKeyTenant).(string)
+	return tenant, ok && tenant != ""
+}
+
+func (r *router) ForTenant(ctx context.Context) (*gorm.DB, func(), error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, nil, errors.InvalidArgument.Newf("no tenant found in context")
+	}
+	c, idle := r.acquire(context.WithoutCancel(ctx), tenant)
+	for _, e := range idle {
+		go r.closeEvicted(e)
+	}
+	release := sync.OnceFunc(func() { r.release(c) })
+	<-c.ready
+	if c.err != nil {
+		release()
+		return nil, nil, c.err
+	}
+	return c.m.ormDB.WithContext(ctx), release, nil
+}
+
+// acquire returns the connection of tenant, marking it as most recently used
+// and counting the caller as one of its holders. A new connection is opened
+// outside the lock so a slow tenant does not block the others; callers wait on
+// ready. Connections pushed out of the cache that nobody holds are returned for
+// the caller to close once the lock is released; held ones are closed by their
+// last release.
+func (r *router) acquire(ctx context.Context, tenant string) (*tenantConn, []*tenantConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.conns[tenant]; ok {
+		r.lru.MoveToFront(e)
+		c := e.Value.(*tenantConn)
+		c.refs++
+		return c, nil
+	}
+	c := &tenantConn{tenant: tenant, ready: make(chan struct{}), refs: 1}
+	r.conns[tenant] = r.lru.PushFront(c)
+	var idle []*tenantConn
+	for r.lru.Len() > r.max {
+		e := r.lru.Back()
+		r.lru.Remove(e)
+		old := e.Value.(*tenantConn)
+		delete(r.conns, old.tenant)
+		old.evicted = true
+		if old.refs == 0 {
+			idle = append(idle, old)
+		}
+	}
+	go r.open(ctx, c)
+	return c, idle
+}
+
+// release drops a holder of c, closing c if it was the last one of an evicted
+// connection.
+func (r *router) release(c *tenantConn) {
+	r.mu.Lock()
+	c.refs--
+	closing := c.refs == 0 && c.evicted
+	r.mu.Unlock()
+	if closing {
+		r.closeEvicted(c)
+	}
+}
+
+// open connects c with the pool settings found in the config carried by ctx.
+func (r *router) open(ctx context.Context, c *tenantConn) {
+	defer close(c.ready)
+	source, err := r.resolve(c.tenant)
+	if err != nil {
+		c.err = errors.Wrapf(err, "failed to resolve source of tenant %s", c.tenant)
+		r.forget(c)
+		return
+	}
+	opts := append([]Option{WithName(r.dbtype + "/" + c.tenant)}, r.opts...)
+	opts = append(opts, WithType(r.dbtype), WithDataSource(source))
+	m := New(opts...).(*manager)
+	if err := m.Init(ctx); err != nil {
+		c.err = errors.DBFailed.Wrapf(err, "failed to connect to database of tenant %s", c.tenant)
+		r.forget(c)
+		return
+	}
+	c.m = m
+}
+
+// forget drops a failed connection so the next call retries it.
+func (r *router) forget(c *tenantConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.conns[c.tenant]; ok && e.Value == c {
+		r.lru.Remove(e)
+		delete(r.conns, c.tenant)
+	}
+}
+
+func (r *router) closeEvicted(c *tenantConn) {
+	if err := r.closeConn(c); err != nil {
+		c.m.log.Errorf("failed to close database of evicted tenant %s: %v", c.tenant, err)
+	}
+}
+
+func (r *router) closeConn(c *tenantConn) error {
+	<-c.ready
+	if c.m == nil {
+		return nil
+	}
+	return c.m.close()
+}
+
+func (r *router) Close() error {
+	r.mu.Lock()
+	conns := make([]*tenantConn, 0, r.lru.Len())
+	for e := r.lru.Front(); e != nil; e = e.Next() {
+		conns = append(conns, e.Value.(*tenantConn))
+	}
+	r.lru.Init()
+	clear(r.conns)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, c := range conns {
+		if err := r.closeConn(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Combine(errs...)
+}
@@ -0,0 +1,107 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/services/db"
+	_ "github.com/xhanio/framingo/pkg/services/db/drivers/sqlite"
+)
+
+func TestRouterForTenant(t *testing.T) {
+	dir := t.TempDir()
+	var resolved []string
+	router := db.NewRouter(db.SQLite, func(tenant string) (db.Source, error) {
+		resolved = append(resolved, tenant)
+		return db.Source{DBName: filepath.Join(dir, tenant+".db")}, nil
+	}, db.WithMaxTenants(1))
+	defer router.Close()
+
+	ctxA := db.WithTenant(context.Background(), "a")
+	ctxB := db.WithTenant(context.Background(), "b")
+
+	for _, ctx := range []context.Context{ctxA, ctxB} {
+		gdb, release, err := router.ForTenant(ctx)
+		require.NoError(t, err)
+		require.NoError(t, gdb.Exec(`CREATE TABLE items (name TEXT NOT NULL)`).Error)
+		release()
+	}
+
+	// routing to "a" again evicts "b" and reconnects to a's own database
+	gdb, release, err := router.ForTenant(ctxA)
+	require.NoError(t, err)
+	require.NoError(t, gdb.Exec(`INSERT INTO items(name) VALUES (?)`, "a-item").Error)
+	release()
+
+	gdb, release, err = router.ForTenant(ctxB)
+	require.NoError(t, err)
+	var n int64
+	require.NoError(t, gdb.Raw(`SELECT COUNT(*) FROM items`).Scan(&n).Error)
+	assert.Zero(t, n, "tenant b should not see tenant a's rows")
+	release()
+
+	gdb, release, err = router.ForTenant(ctxA)
+	require.NoError(t, err)
+	var names []string
+	require.NoError(t, gdb.Raw(`SELECT name FROM items`).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"a-item"}, names)
+	release()
+
+	assert.Equal(t, []string{"a", "b", "a", "b", "a"}, resolved)
+}
+
+func TestRouterEvictHeld(t *testing.T) {
+	dir := t.TempDir()
+	router := db.NewRouter(db.SQLite, func(tenant string) (db.Source, error) {
+		return db.Source{DBName: filepath.Join(dir, tenant+".db")}, nil
+	}, db.WithMaxTenants(1))
+	defer router.Close()
+
+	gdbA, releaseA, err := router.ForTenant(db.WithTenant(context.Background(), "a"))
+	require.NoError(t, err)
+	_, releaseB, err := router.ForTenant(db.WithTenant(context.Background(), "b"))
+	require.NoError(t, err)
+	defer releaseB()
+
+	// "a" is evicted but still held, so it stays open until released
+	require.NoError(t, gdbA.Exec(`CREATE TABLE items (name TEXT NOT NULL)`).Error)
+	releaseA()
+	releaseA() // releasing twice is a no-op
+	assert.Error(t, gdbA.Exec(`SELECT 1`).Error)
+}
+
+func TestRouterWithoutTenant(t *testing.T) {
+	router := db.NewRouter(db.SQLite, func(string) (db.Source, error) {
+		return db.Source{}, nil
+	})
+	defer router.Close()
+
+	_, _, err := router.ForTenant(context.Background())
+	assert.True(t, errors.Is(err, errors.InvalidArgument))
+}
+
+func TestRouterResolveError(t *testing.T) {
+	calls := 0
+	router := db.NewRouter(db.SQLite, func(string) (db.Source, error) {
+		calls++
+		if calls == 1 {
+			return db.Source{}, errors.NotFound.Newf("unknown tenant")
+		}
+		return db.Source{}, nil
+	})
+	defer router.Close()
+
+	ctx := db.WithTenant(context.Background(), "a")
+	_, _, err := router.ForTenant(ctx)
+	assert.True(t, errors.Is(err, errors.NotFound))
+
+	// a failed connection is not cached
+	_, release, err := router.ForTenant(ctx)
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+
+	"github.com/xhanio/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Scope is a reusable gorm query modifier, see gorm.DB.Scopes.
+type Scope = func(*gorm.DB) *gorm.DB
+
+// RegisterScope makes scope available to WithScopes under name, replacing a
+// scope previously registered with that name.
+func (m *manager) RegisterScope(name string, scope Scope) error {
+	if name == "" || scope == nil {
+		return errors.InvalidArgument.Newf("scope requires a name and a function")
+	}
+	m.sl.Lock()
+	defer m.sl.Unlock()
+	if m.scopes == nil {
+		m.scopes = make(map[string]Scope)
+	}
+	m.scopes[name] = scope
+	return nil
+}
+
+// WithScopes returns a session on the transaction carried by ctx, if any, with
+// the named scopes applied in order. It fails without applying any if one of
+// them is not registered.
+func (m *manager) WithScopes(ctx context.Context, names ...string) (*gorm.DB, error) {
+	m.sl.RLock()
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		scope, ok := m.scopes[name]
+		if !ok {
+			m.sl.RUnlock()
+			return nil, errors.NotFound.Newf("scope %s is not registered", name)
+		}
+		scopes = append(scopes, scope)
+	}
+	m.sl.RUnlock()
+	return m.FromContext(ctx).Scopes(scopes...), nil
+}
+
+// Paginate returns a scope selecting the given 1-based page of size rows.
+// Pages below 1 are treated as the first one, and a size of 0 or less
+// disables pagination.
+func Paginate(page, size int) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if size <= 0 {
+			return db
+		}
+		return db.Offset((max(page, 1) - 1) * size).Limit(size)
+	}
+}
+
+// OrderBy returns a scope ordering by column, quoted as an identifier so it is
+// safe to take from a request.
+func OrderBy(column string, desc bool) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+}
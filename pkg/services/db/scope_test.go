@@ -0,0 +1,37 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/services/db"
+)
+
+func TestScopes(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, mgr.ORM().Exec(`INSERT INTO items (name) VALUES (?)`, name).Error)
+	}
+	require.NoError(t, mgr.RegisterScope("page2", db.Paginate(2, 2)))
+	require.NoError(t, mgr.RegisterScope("newest", db.OrderBy("id", true)))
+	assert.True(t, errors.Is(mgr.RegisterScope("", db.Paginate(1, 1)), errors.InvalidArgument))
+
+	ctx := context.Background()
+	names := func(scopes ...string) []string {
+		tx, err := mgr.WithScopes(ctx, scopes...)
+		require.NoError(t, err)
+		var result []string
+		require.NoError(t, tx.Table("items").Pluck("name", &result).Error)
+		return result
+	}
+	assert.Equal(t, []string{"c", "d"}, names("page2"))
+	assert.Equal(t, []string{"c", "b"}, names("newest", "page2"))
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, names("newest"))
+
+	_, err := mgr.WithScopes(ctx, "newest", "missing")
+	assert.True(t, errors.Is(err, errors.NotFound))
+}
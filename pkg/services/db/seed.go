@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/xhanio/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// Seed inserts fixtures (pointers to models or slices of models) in a single transaction,
+// or on the transaction carried by ctx. Seeding is idempotent: rows that
+// conflict with existing ones are left as they are, so fixtures should set
+// their primary keys. Rows of soft-deleted models that were deleted are
+// restored instead, so seeded reference data is always visible.
+func (m *manager) Seed(ctx context.Context, fixtures ...any) error {
+	switch m.dbtype {
+	case Postgres, SQLite, MySQL:
+	default:
+		return errors.NotImplemented.Newf("seed not supported for database type: %s", m.dbtype)
+	}
+	return m.Transaction(ctx, func(ctx context.Context) error {
+		for _, fixture := range fixtures {
+			tx := m.FromContext(ctx)
+			oc, err := seedConflict(tx, fixture)
+			if err != nil {
+				return err
+			}
+			if err := tx.Clauses(oc).Create(fixture).Error; err != nil {
+				return errors.Wrap(err)
+			}
+		}
+		return nil
+	})
+}
+
+// seedConflict skips rows that already exist, or undeletes them on their
+// primary key if the model of fixture is soft-deleted.
+func seedConflict(tx *gorm.DB, fixture any) (clause.OnConflict, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(fixture); err != nil {
+		return clause.OnConflict{}, errors.InvalidArgument.Wrapf(err, "invalid fixture %T", fixture)
+	}
+	for _, field := range stmt.Schema.Fields {
+		if field.FieldType != deletedAtType || field.DBName == "" || len(stmt.Schema.PrimaryFields) == 0 {
+			continue
+		}
+		oc := clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{field.DBName: nil}),
+		}
+		for _, pk := range stmt.Schema.PrimaryFields {
+			oc.Columns = append(oc.Columns, clause.Column{Name: pk.DBName})
+		}
+		return oc, nil
+	}
+	return clause.OnConflict{DoNothing: true}, nil
+}
+
+// Reset deletes all rows, see Cleanup, then seeds fixtures, giving tests a
+// known starting point in one call.
+func (m *manager) Reset(fixtures ...any) error {
+	if err := m.Cleanup(false); err != nil {
+		return errors.Wrap(err)
+	}
+	return m.Seed(context.Background(), fixtures...)
+}
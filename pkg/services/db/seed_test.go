@@ -0,0 +1,56 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type seedRole struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+func (seedRole) TableName() string { return "seed_roles" }
+
+func seedRoles() []seedRole {
+	return []seedRole{{ID: 1, Name: "admin"}, {ID: 2, Name: "viewer"}}
+}
+
+func TestResetAndSeed(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	require.NoError(t, mgr.ORM().AutoMigrate(&seedRole{}, &upsertItem{}))
+	ctx := context.Background()
+
+	require.NoError(t, mgr.ORM().Exec(`INSERT INTO items (name) VALUES ('leftover')`).Error)
+	require.NoError(t, mgr.Reset(&upsertItem{ID: 1, SKU: "a", Name: "apple"}, seedRoles()))
+	assert.Equal(t, int64(0), countItems(t, mgr), "reset deletes existing rows")
+	var roles []seedRole
+	require.NoError(t, mgr.ORM().Order("id").Find(&roles).Error)
+	require.Len(t, roles, 2)
+	assert.Equal(t, "viewer", roles[1].Name)
+
+	// seeding again neither fails nor overwrites existing rows
+	require.NoError(t, mgr.ORM().Model(&seedRole{ID: 1}).Update("name", "root").Error)
+	require.NoError(t, mgr.Seed(ctx, seedRoles()))
+	var role seedRole
+	require.NoError(t, mgr.ORM().First(&role, 1).Error)
+	assert.Equal(t, "root", role.Name)
+
+	// soft-deleted fixtures are restored
+	require.NoError(t, mgr.ORM().Delete(&seedRole{ID: 2}).Error)
+	require.ErrorIs(t, mgr.ORM().First(&seedRole{}, 2).Error, gorm.ErrRecordNotFound)
+	require.NoError(t, mgr.Seed(ctx, &seedRole{ID: 2, Name: "viewer"}))
+	var restored seedRole
+	require.NoError(t, mgr.ORM().First(&restored, 2).Error)
+	assert.Equal(t, "viewer", restored.Name)
+
+	var items []upsertItem
+	require.NoError(t, mgr.ORM().Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, "apple", items[0].Name)
+}
@@ -6,6 +6,8 @@ import (
 	"sort"
 
 	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/utils/strutil"
 )
 
 const (
@@ -19,7 +21,7 @@ type Source struct {
 	Host     string
 	Port     uint
 	User     string
-	Password string `print:"-"`
+	Password strutil.Secret // masked when printed, see strutil.Secret
 	DBName   string
 	Secure   bool
 	Params   map[string]string
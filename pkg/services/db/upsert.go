@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+
+	"github.com/xhanio/errors"
+	"gorm.io/gorm/clause"
+)
+
+// Upsert inserts value (a model or a slice of models) and, for rows that
+// conflict on conflictColumns, updates updateColumns with the incoming values.
+// An empty updateColumns updates every column. It runs on the transaction
+// carried by ctx, if any.
+//
+// Postgres and SQLite render ON CONFLICT (conflictColumns) DO UPDATE. MySQL
+// renders ON DUPLICATE KEY UPDATE, which resolves conflicts against any
+// unique key, so conflictColumns must match a unique index there too.
+// ClickHouse has no upsert semantics and is not supported.
+func (m *manager) Upsert(ctx context.Context, value any, conflictColumns []string, updateColumns []string) error {
+	switch m.dbtype {
+	case Postgres, SQLite, MySQL:
+	default:
+		return errors.NotImplemented.Newf("upsert not supported for database type: %s", m.dbtype)
+	}
+	if len(conflictColumns) == 0 {
+		return errors.InvalidArgument.Newf("upsert requires at least one conflict column")
+	}
+	oc := clause.OnConflict{}
+	for _, col := range conflictColumns {
+		oc.Columns = append(oc.Columns, clause.Column{Name: col})
+	}
+	if len(updateColumns) == 0 {
+		oc.UpdateAll = true
+	} else {
+		oc.DoUpdates = clause.AssignmentColumns(updateColumns)
+	}
+	if err := m.FromContext(ctx).Clauses(oc).Create(value).Error; err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
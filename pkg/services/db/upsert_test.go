@@ -0,0 +1,78 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upsertItem struct {
+	ID    int64  `gorm:"primaryKey"`
+	SKU   string `gorm:"uniqueIndex"`
+	Name  string
+	Stock int
+}
+
+func (upsertItem) TableName() string { return "upsert_items" }
+
+func TestUpsert_InsertThenUpdate(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	require.NoError(t, mgr.ORM().AutoMigrate(&upsertItem{}))
+	ctx := context.Background()
+
+	// first call inserts
+	require.NoError(t, mgr.Upsert(ctx, &upsertItem{SKU: "a", Name: "apple", Stock: 1}, []string{"sku"}, []string{"stock"}))
+	// second call conflicts on sku and only updates stock
+	require.NoError(t, mgr.Upsert(ctx, &upsertItem{SKU: "a", Name: "avocado", Stock: 5}, []string{"sku"}, []string{"stock"}))
+
+	var items []upsertItem
+	require.NoError(t, mgr.ORM().Order("sku").Find(&items).Error)
+	require.Len(t, items, 1)
+	assert.Equal(t, "apple", items[0].Name)
+	assert.Equal(t, 5, items[0].Stock)
+}
+
+func TestUpsert_Batch(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	require.NoError(t, mgr.ORM().AutoMigrate(&upsertItem{}))
+	ctx := context.Background()
+
+	require.NoError(t, mgr.Upsert(ctx, []upsertItem{
+		{SKU: "a", Name: "apple", Stock: 1},
+		{SKU: "b", Name: "banana", Stock: 2},
+	}, []string{"sku"}, []string{"name", "stock"}))
+	require.NoError(t, mgr.Upsert(ctx, []upsertItem{
+		{SKU: "b", Name: "blueberry", Stock: 3},
+		{SKU: "c", Name: "cherry", Stock: 4},
+	}, []string{"sku"}, []string{"name", "stock"}))
+
+	var items []upsertItem
+	require.NoError(t, mgr.ORM().Order("sku").Find(&items).Error)
+	require.Len(t, items, 3)
+	assert.Equal(t, "apple", items[0].Name)
+	assert.Equal(t, "blueberry", items[1].Name)
+	assert.Equal(t, 3, items[1].Stock)
+	assert.Equal(t, "cherry", items[2].Name)
+}
+
+func TestUpsert_InTransaction(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	require.NoError(t, mgr.ORM().AutoMigrate(&upsertItem{}))
+
+	err := mgr.Transaction(context.Background(), func(ctx context.Context) error {
+		return mgr.Upsert(ctx, &upsertItem{SKU: "a", Name: "apple"}, []string{"sku"}, nil)
+	})
+	require.NoError(t, err)
+
+	var n int64
+	require.NoError(t, mgr.ORM().Model(&upsertItem{}).Count(&n).Error)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestUpsert_RequiresConflictColumns(t *testing.T) {
+	mgr := newTransactionTestMgr(t, 1)
+	require.NoError(t, mgr.ORM().AutoMigrate(&upsertItem{}))
+	assert.Error(t, mgr.Upsert(context.Background(), &upsertItem{SKU: "a"}, nil, nil))
+}
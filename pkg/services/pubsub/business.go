@@ -3,10 +3,22 @@ package pubsub
 import (
 	"context"
 
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/services/pubsub/driver"
 	"github.com/xhanio/framingo/pkg/types/entity"
 )
 
 func (m *manager) Publish(ctx context.Context, from, topic, kind string, payload any) error {
+	m.cl.RLock()
+	if m.closed {
+		m.cl.RUnlock()
+		return errors.Unavailable.Newf("pubsub %s is closed", m.Name())
+	}
+	m.publishing.Add(1)
+	m.cl.RUnlock()
+	defer m.publishing.Done()
+
 	m.published.Add(1)
 	if err := m.bus.Publish(ctx, from, topic, kind, payload); err != nil {
 		m.log.Errorf("failed to publish to backend: topic=%s error=%v", topic, err)
@@ -19,6 +31,32 @@ func (m *manager) Subscribe(name, topic string) (<-chan entity.PubsubMessage, er
 	return m.bus.Subscribe(name, topic)
 }
 
+// SubscribeFiltered subscribes name to topic like Subscribe, delivering only
+// the messages filter accepts, see driver.Filter.
+func (m *manager) SubscribeFiltered(name, topic string, filter driver.Filter) (<-chan entity.PubsubMessage, error) {
+	return m.bus.SubscribeFiltered(name, topic, filter)
+}
+
+// SubscribeVersioned subscribes name to topic like SubscribeFiltered,
+// delivering only the messages within versions, see driver.Versions.
+func (m *manager) SubscribeVersioned(name, topic string, versions driver.Versions, filter driver.Filter) (<-chan entity.PubsubMessage, error) {
+	return m.bus.SubscribeVersioned(name, topic, versions, filter)
+}
+
+func (m *manager) TopicMetrics(topic string) entity.PubsubTopicMetrics {
+	if s, ok := m.bus.(driver.Stats); ok {
+		return s.TopicMetrics(topic)
+	}
+	return entity.PubsubTopicMetrics{Topic: topic}
+}
+
+func (m *manager) AllTopicMetrics() map[string]entity.PubsubTopicMetrics {
+	if s, ok := m.bus.(driver.Stats); ok {
+		return s.AllTopicMetrics()
+	}
+	return map[string]entity.PubsubTopicMetrics{}
+}
+
 func (m *manager) Unsubscribe(name, topic string) error {
 	return m.bus.Unsubscribe(name, topic)
 }
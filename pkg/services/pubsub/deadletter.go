@@ -0,0 +1,46 @@
+package pubsub
+
+import (
+	"context"
+
+	"github.com/xhanio/framingo/pkg/types/entity"
+)
+
+// DeadLetterKind is the message kind of dead letters.
+const DeadLetterKind = "pubsub.dead_letter"
+
+// DeadLetter wraps a message whose handler failed, see WithDeadLetter.
+type DeadLetter struct {
+	Subscriber string               `json:"subscriber"`
+	Error      string               `json:"error"`
+	Message    entity.PubsubMessage `json:"message"`
+}
+
+func (DeadLetter) Kind() string { return DeadLetterKind }
+
+// deadLetterer is implemented by managers that forward failed deliveries.
+type deadLetterer interface {
+	deadLetter(subscriber string, msg entity.PubsubMessage, cause error)
+}
+
+// deadLetter re-publishes a failed delivery to the dead-letter topic. A failed
+// dead letter is dropped instead, so a failing dead-letter subscriber can't loop.
+func (m *manager) deadLetter(subscriber string, msg entity.PubsubMessage, cause error) {
+	if m.deadLetterTopic == "" {
+		return
+	}
+	if msg.Kind == DeadLetterKind {
+		m.log.Warnf("dropping failed dead letter: subscriber=%s error=%v", subscriber, cause)
+		return
+	}
+	dl := DeadLetter{
+		Subscriber: subscriber,
+		Error:      cause.Error(),
+		Message:    msg,
+	}
+	if err := m.Publish(context.Background(), m.Name(), m.deadLetterTopic, DeadLetterKind, dl); err != nil {
+		m.log.Errorf("failed to publish dead letter: subscriber=%s error=%v", subscriber, err)
+		return
+	}
+	m.deadLettered.Add(1)
+}
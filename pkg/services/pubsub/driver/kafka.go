@@ -48,7 +48,7 @@ func NewKafka(brokers []string, groupID string, log log.Logger, opts ...Option)
 	writer := &kafka.Writer{
 		Addr:                   kafka.TCP(brokers...),
 		Topic:                  kafkaTopic,
-		Balancer:               &kafka.LeastBytes{},
+		Balancer:               &orderedBalancer{unkeyed: &kafka.LeastBytes{}},
 		AllowAutoTopicCreation: true,
 	}
 
@@ -59,8 +59,13 @@ func NewKafka(brokers []string, groupID string, log log.Logger, opts ...Option)
 		StartOffset: kafka.LastOffset,
 	})
 
+	d := newDispatcher(log, opts...)
+	if d.opts.guarantee == AtLeastOnce {
+		writer.RequiredAcks = kafka.RequireAll
+	}
+
 	return &kafkaDriver{
-		dispatcher: newDispatcher(log, opts...),
+		dispatcher: d,
 		kafkaTopic: kafkaTopic,
 		writer:     writer,
 		reader:     reader,
@@ -69,6 +74,14 @@ func NewKafka(brokers []string, groupID string, log log.Logger, opts ...Option)
 }
 
 func (b *kafkaDriver) Subscribe(name string, topic string) (<-chan entity.PubsubMessage, error) {
+	return b.SubscribeFiltered(name, topic, nil)
+}
+
+func (b *kafkaDriver) SubscribeFiltered(name string, topic string, filter Filter) (<-chan entity.PubsubMessage, error) {
+	return b.SubscribeVersioned(name, topic, Versions{}, filter)
+}
+
+func (b *kafkaDriver) SubscribeVersioned(name string, topic string, versions Versions, filter Filter) (<-chan entity.PubsubMessage, error) {
 	if name == "" {
 		return nil, nil
 	}
@@ -76,7 +89,7 @@ func (b *kafkaDriver) Subscribe(name string, topic string) (<-chan entity.Pubsub
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	sub := newSubscriber(name, b.opts)
+	sub := newSubscriber(name, versions, filter, b.opts)
 	b.topics[topic] = append(b.topics[topic], sub)
 
 	return sub.ch, nil
@@ -177,8 +190,11 @@ func (b *kafkaDriver) remove(topic string, target *subscriber) {
 
 func (b *kafkaDriver) Publish(ctx context.Context, from string, topic string, kind string, payload any) error {
 	// Local delivery
-	msg := entity.PubsubMessage{From: from, Topic: topic, Kind: kind, Payload: payload}
+	msg := entity.PubsubMessage{From: from, Topic: topic, Kind: kind, Payload: payload, Version: versionOf(payload)}
 
+	ordered, done := b.order(topic)
+	defer done()
+	b.published(topic)
 	b.mu.RLock()
 	lagged := b.fanout(b.topics, from, msg)
 	b.mu.RUnlock()
@@ -197,6 +213,7 @@ func (b *kafkaDriver) Publish(ctx context.Context, from string, topic string, ki
 		Publisher: from,
 		Topic:     topic,
 		Kind:      kind,
+		Version:   msg.Version,
 		Payload:   rawPayload,
 	}
 
@@ -209,7 +226,32 @@ func (b *kafkaDriver) Publish(ctx context.Context, from string, topic string, ki
 		ctx = context.Background()
 	}
 
-	return b.writer.WriteMessages(ctx, kafka.Message{Value: data})
+	// Kafka acknowledges a write as a whole rather than per receiver: under
+	// AtLeastOnce the writer requires the ack of every in-sync replica, and
+	// only failed writes are retried.
+	return b.deliver(ctx, topic, 0, func(ctx context.Context) (int64, error) {
+		km := kafka.Message{Value: data}
+		if ordered != "" {
+			// keyed messages share a partition, see orderedBalancer
+			km.Key = []byte(ordered)
+		}
+		return 0, b.writer.WriteMessages(ctx, km)
+	})
+}
+
+// orderedBalancer hashes keyed messages, which belong to an ordered topic, to
+// a fixed partition, since Kafka only orders messages within a partition.
+// Other messages are spread by unkeyed.
+type orderedBalancer struct {
+	keyed   kafka.Hash
+	unkeyed kafka.Balancer
+}
+
+func (o *orderedBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	if len(msg.Key) > 0 {
+		return o.keyed.Balance(msg, partitions...)
+	}
+	return o.unkeyed.Balance(msg, partitions...)
 }
 
 func (b *kafkaDriver) Start(ctx context.Context) error {
@@ -219,6 +261,16 @@ func (b *kafkaDriver) Start(ctx context.Context) error {
 	return nil
 }
 
+func (b *kafkaDriver) Drain(ctx context.Context) error {
+	b.mu.RLock()
+	var subs []*subscriber
+	for _, s := range b.topics {
+		subs = append(subs, s...)
+	}
+	b.mu.RUnlock()
+	return drainAll(ctx, subs)
+}
+
 func (b *kafkaDriver) Stop(wait bool) error {
 	if b.cancel != nil {
 		b.cancel()
@@ -277,6 +329,7 @@ func (b *kafkaDriver) handleKafkaMessage(data []byte) {
 		Topic:   em.Topic,
 		Kind:    em.Kind,
 		Payload: em.Payload,
+		Version: em.Version,
 	}
 
 	b.mu.RLock()
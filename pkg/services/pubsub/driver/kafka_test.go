@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xhanio/framingo/pkg/utils/log"
@@ -190,3 +191,17 @@ func TestKafkaStopWithoutStart(t *testing.T) {
 	err = b.Stop(true)
 	assert.NoError(t, err)
 }
+
+func TestKafkaOrderedBalancer(t *testing.T) {
+	b := &orderedBalancer{unkeyed: &kafka.RoundRobin{}}
+	partitions := []int{0, 1, 2, 3}
+
+	keyed := b.Balance(kafka.Message{Key: []byte("orders")}, partitions...)
+	unkeyed := make(map[int]bool)
+	for range 8 {
+		assert.Equal(t, keyed, b.Balance(kafka.Message{Key: []byte("orders")}, partitions...),
+			"an ordered topic stays on one partition")
+		unkeyed[b.Balance(kafka.Message{}, partitions...)] = true
+	}
+	assert.Len(t, unkeyed, len(partitions), "other messages are spread")
+}
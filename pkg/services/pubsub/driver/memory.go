@@ -17,6 +17,9 @@ type memoryDriver struct {
 	topics *trie.Trie[[]*subscriber]
 }
 
+// NewMemory returns a driver that delivers within the process only. Delivery is
+// always BestEffort: there is no cross-instance hop to acknowledge or retry, so
+// WithDeliveryGuarantee has no effect.
 func NewMemory(log log.Logger, opts ...Option) Driver {
 	return &memoryDriver{
 		dispatcher: newDispatcher(log, opts...),
@@ -25,6 +28,14 @@ func NewMemory(log log.Logger, opts ...Option) Driver {
 }
 
 func (b *memoryDriver) Subscribe(name string, topic string) (<-chan entity.PubsubMessage, error) {
+	return b.SubscribeFiltered(name, topic, nil)
+}
+
+func (b *memoryDriver) SubscribeFiltered(name string, topic string, filter Filter) (<-chan entity.PubsubMessage, error) {
+	return b.SubscribeVersioned(name, topic, Versions{}, filter)
+}
+
+func (b *memoryDriver) SubscribeVersioned(name string, topic string, versions Versions, filter Filter) (<-chan entity.PubsubMessage, error) {
 	if name == "" {
 		return nil, nil
 	}
@@ -32,7 +43,7 @@ func (b *memoryDriver) Subscribe(name string, topic string) (<-chan entity.Pubsu
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	sub := newSubscriber(name, b.opts)
+	sub := newSubscriber(name, versions, filter, b.opts)
 
 	if node, ok := b.topics.Find(topic); ok {
 		subscribers := append(node.Value(), sub)
@@ -105,10 +116,13 @@ func (b *memoryDriver) Unsubscribe(name string, topic string) error {
 }
 
 func (b *memoryDriver) Publish(_ context.Context, from string, topic string, kind string, payload any) error {
-	msg := entity.PubsubMessage{From: from, Topic: topic, Kind: kind, Payload: payload}
+	msg := entity.PubsubMessage{From: from, Topic: topic, Kind: kind, Payload: payload, Version: versionOf(payload)}
 
 	var lagged []laggard
 
+	_, done := b.order(topic)
+	defer done()
+	b.published(topic)
 	b.mu.RLock()
 	sections := strings.Split(topic, "/")
 	for i := range sections {
@@ -169,6 +183,18 @@ func (b *memoryDriver) remove(topic string, target *subscriber) {
 	}
 }
 
+func (b *memoryDriver) Drain(ctx context.Context) error {
+	b.mu.RLock()
+	var subs []*subscriber
+	for _, key := range b.topics.Keys() {
+		if node, ok := b.topics.Find(key); ok {
+			subs = append(subs, node.Value()...)
+		}
+	}
+	b.mu.RUnlock()
+	return drainAll(ctx, subs)
+}
+
 func (b *memoryDriver) Start(ctx context.Context) error {
 	return nil
 }
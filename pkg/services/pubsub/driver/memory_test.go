@@ -2,11 +2,15 @@ package driver
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/framingo/pkg/types/entity"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -180,3 +184,112 @@ func TestMemoryDoubleStop(t *testing.T) {
 	err = b.Stop(true)
 	assert.NoError(t, err)
 }
+
+func TestMemoryOrderedDelivery(t *testing.T) {
+	const publishers, perPublisher = 4, 200
+	b := NewMemory(log.Default, WithOrderedDelivery("orders"))
+
+	chA, err := b.Subscribe("a", "orders")
+	require.NoError(t, err)
+	chB, err := b.Subscribe("b", "orders")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for p := range publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perPublisher {
+				_ = b.Publish(context.Background(), fmt.Sprintf("p%d", p), "orders/42", "seq", i)
+			}
+		}()
+	}
+
+	receive := func(ch <-chan entity.PubsubMessage) []string {
+		var got []string
+		next := make(map[string]int)
+		for range publishers * perPublisher {
+			select {
+			case msg := <-ch:
+				assert.Equal(t, next[msg.From], msg.Payload, "messages of %s out of order", msg.From)
+				next[msg.From] = msg.Payload.(int) + 1
+				got = append(got, fmt.Sprintf("%s:%d", msg.From, msg.Payload))
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for message")
+			}
+		}
+		return got
+	}
+	gotA := receive(chA)
+	gotB := receive(chB)
+	wg.Wait()
+	assert.Equal(t, gotA, gotB, "subscribers see the same total order")
+}
+
+type versionedEvent struct {
+	Name string `json:"name"`
+	V    int    `json:"v"`
+}
+
+func (e versionedEvent) Kind() string { return "versioned" }
+func (e versionedEvent) Version() int { return e.V }
+
+func TestMemoryVersions(t *testing.T) {
+	b := NewMemory(log.Default)
+
+	v1Only, err := b.SubscribeVersioned("v1-only", "topic", Versions{Max: 1}, nil)
+	require.NoError(t, err)
+	var migrated int
+	v2, err := b.SubscribeVersioned("v2", "topic", Versions{Min: 2, Migrate: func(msg entity.PubsubMessage) (entity.PubsubMessage, bool) {
+		migrated++
+		e := msg.Payload.(versionedEvent)
+		msg.Payload = versionedEvent{Name: e.Name + " (migrated)", V: 2}
+		msg.Version = 2
+		return msg, true
+	}}, nil)
+	require.NoError(t, err)
+	strict, err := b.SubscribeVersioned("strict", "topic", Versions{Min: 2}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(context.Background(), "pub", "topic", "versioned", versionedEvent{Name: "old", V: 1}))
+	require.NoError(t, b.Publish(context.Background(), "pub", "topic", "versioned", versionedEvent{Name: "new", V: 2}))
+
+	receive := func(ch <-chan entity.PubsubMessage) entity.PubsubMessage {
+		t.Helper()
+		select {
+		case msg := <-ch:
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for message")
+		}
+		return entity.PubsubMessage{}
+	}
+	none := func(ch <-chan entity.PubsubMessage) {
+		t.Helper()
+		select {
+		case msg := <-ch:
+			t.Fatalf("unexpected message %v", msg.Payload)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// the v1-only subscriber skips the v2 event
+	msg := receive(v1Only)
+	assert.Equal(t, 1, msg.Version)
+	assert.Equal(t, "old", msg.Payload.(versionedEvent).Name)
+	none(v1Only)
+
+	// the v2 subscriber gets the v1 event migrated
+	msg = receive(v2)
+	assert.Equal(t, 2, msg.Version)
+	assert.Equal(t, "old (migrated)", msg.Payload.(versionedEvent).Name)
+	msg = receive(v2)
+	assert.Equal(t, "new", msg.Payload.(versionedEvent).Name)
+	none(v2)
+	assert.Equal(t, 1, migrated)
+
+	// without a migration older events are skipped
+	msg = receive(strict)
+	assert.Equal(t, "new", msg.Payload.(versionedEvent).Name)
+	none(strict)
+}
@@ -7,6 +7,35 @@ import (
 	"github.com/xhanio/framingo/pkg/types/entity"
 )
 
+// Filter reports whether a subscriber receives msg. It is evaluated by the
+// publishing side before msg is queued for the subscriber, so rejected messages
+// never wake it up and are not counted as deliveries. Filters run on the
+// publisher's goroutine while the driver's read lock is held and must be fast
+// and must not block or call back into the driver. Messages relayed from other
+// instances by the redis/kafka drivers carry their payload as json.RawMessage.
+type Filter func(msg entity.PubsubMessage) bool
+
+// Migration upgrades msg, which is older than the subscriber's Versions.Min,
+// to a version it understands, or returns false to skip it. Like a Filter it
+// runs on the publisher's goroutine under the driver's read lock and must be
+// fast and must not block or call back into the driver.
+type Migration func(msg entity.PubsubMessage) (entity.PubsubMessage, bool)
+
+// Versions bounds the message versions a subscriber understands, letting
+// instances of different releases share a topic during a rolling upgrade.
+// Messages are versioned by payloads implementing common.Versioned; others
+// are version 0. The zero Versions accepts every message.
+type Versions struct {
+	// Min is the oldest version delivered as is. Older messages are handed to
+	// Migrate, and skipped without it.
+	Min int
+	// Max is the newest version delivered, 0 for no bound. Newer messages are
+	// skipped, as an older release cannot read them.
+	Max int
+	// Migrate upgrades messages older than Min.
+	Migrate Migration
+}
+
 // Driver defines the interface for subscription storage and event delivery.
 type Driver interface {
 	// business
@@ -15,6 +44,15 @@ type Driver interface {
 	// a channel that will receive messages published to matching topics.
 	Subscribe(name string, topic string) (<-chan entity.PubsubMessage, error)
 
+	// SubscribeFiltered registers a subscriber like Subscribe that only
+	// receives the messages filter accepts. A nil filter accepts every message.
+	SubscribeFiltered(name string, topic string, filter Filter) (<-chan entity.PubsubMessage, error)
+
+	// SubscribeVersioned registers a subscriber like SubscribeFiltered that
+	// only receives messages within versions, migrating older ones. filter
+	// sees messages after their migration.
+	SubscribeVersioned(name string, topic string, versions Versions, filter Filter) (<-chan entity.PubsubMessage, error)
+
 	// GetSubscribers returns the names of all subscribers matching the given topic,
 	// including those subscribed to parent topics.
 	GetSubscribers(topic string) []string
@@ -31,6 +69,11 @@ type Driver interface {
 	// canceled mid-publish.
 	Publish(ctx context.Context, from string, topic string, kind string, payload any) error
 
+	// Drain waits until the messages already queued for local subscribers
+	// have been handed to their channels, or ctx is done. It does not stop
+	// new deliveries, so callers stop publishing first.
+	Drain(ctx context.Context) error
+
 	// lifecycle
 	common.Daemon
 }
@@ -1,5 +1,11 @@
 package driver
 
+import (
+	"time"
+
+	"github.com/xhanio/framingo/pkg/types/entity"
+)
+
 // OnFull selects what a driver does when a subscriber's pending queue is full,
 // meaning the subscriber is not draining its channel fast enough.
 type OnFull int
@@ -14,6 +20,29 @@ const (
 	DropSubscriber
 )
 
+// DeliveryGuarantee selects how hard a driver tries to hand a message to the
+// other instances sharing its backend.
+type DeliveryGuarantee int
+
+const (
+	// BestEffort sends the message once and returns whatever the backend
+	// reports. A message published while no instance is listening is lost.
+	BestEffort DeliveryGuarantee = iota
+	// AtLeastOnce retries the cross-instance hop until the backend
+	// acknowledges it or the retry limit is reached. For Redis that is
+	// enough subscribed connections of other instances, see WithMinAcks; for
+	// Kafka it is the write being stored by every in-sync replica, which
+	// does not mean another instance has read it. Receivers may see a
+	// message more than once. The memory driver has no cross-instance hop
+	// and only supports BestEffort.
+	AtLeastOnce
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = 100 * time.Millisecond
+)
+
 // defaultQueueCap bounds a subscriber's pending queue. The queue grows on
 // demand, so this is a ceiling rather than a preallocation: reaching it means
 // the subscriber has stopped draining entirely, not that it is briefly slow.
@@ -27,12 +56,27 @@ type Stats interface {
 	// Evicted returns the number of subscribers removed because they could
 	// not keep up.
 	Evicted() uint64
+	// Retried returns the number of cross-instance delivery attempts
+	// repeated under AtLeastOnce.
+	Retried() uint64
+	// TopicMetrics returns the traffic counters of a published topic.
+	TopicMetrics(topic string) entity.PubsubTopicMetrics
+	// AllTopicMetrics returns the traffic counters of every topic published
+	// to or delivered on so far, keyed by topic.
+	AllTopicMetrics() map[string]entity.PubsubTopicMetrics
 }
 
 type options struct {
 	onFull   OnFull
 	queueCap int
 	chanBuf  int
+
+	guarantee  DeliveryGuarantee
+	minAcks    int64
+	maxRetries int
+	retryDelay time.Duration
+
+	ordered []string
 }
 
 func newOptions(opts ...Option) *options {
@@ -40,6 +84,11 @@ func newOptions(opts ...Option) *options {
 		onFull:   DropMessage,
 		queueCap: defaultQueueCap,
 		chanBuf:  channelBufferSize,
+
+		guarantee:  BestEffort,
+		minAcks:    1,
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -73,3 +122,59 @@ func WithChannelBuffer(n int) Option {
 		}
 	}
 }
+
+// WithDeliveryGuarantee sets how the cross-instance hop is delivered. The
+// default is BestEffort. The memory driver ignores AtLeastOnce.
+func WithDeliveryGuarantee(v DeliveryGuarantee) Option {
+	return func(o *options) { o.guarantee = v }
+}
+
+// WithMinAcks sets how many receivers must acknowledge a message before an
+// AtLeastOnce publish returns. For Redis an ack is a subscribed connection
+// of another instance reported by PUBLISH, the subscriptions of the
+// publishing instance are not counted. Kafka ignores it, its broker
+// acknowledges a write as a whole.
+func WithMinAcks(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.minAcks = int64(n)
+		}
+	}
+}
+
+// WithOrderedDelivery makes every local subscriber of topic, or of any topic
+// below it, receive the messages published through this driver in one total
+// order, the order those publishes complete in.
+//
+// Each subscriber already drains a single-consumer queue, so messages from one
+// publisher never overtake each other locally. What ordering adds is agreement
+// within the process: publishes under topic are serialized by a per-process
+// lock, so concurrent publishers cannot interleave differently for different
+// local subscribers. The ordering does not span instances: publishes from other
+// instances are not serialized with local ones, and local subscribers are
+// handed a message before its cross-instance hop, so subscribers on different
+// instances may see them interleaved differently. Kafka writes ordered topics
+// to one partition, which keeps each instance's own publishes in order there.
+// The cost is throughput: publishers to an ordered topic wait for each other,
+// and an AtLeastOnce retry holds up every publish queued behind it. Keep
+// ordered topics narrow, e.g. one per state machine.
+func WithOrderedDelivery(topic string) Option {
+	return func(o *options) {
+		if topic != "" {
+			o.ordered = append(o.ordered, topic)
+		}
+	}
+}
+
+// WithRetry bounds how many times an AtLeastOnce publish is retried after the
+// first attempt, and how long it waits between attempts.
+func WithRetry(maxRetries int, delay time.Duration) Option {
+	return func(o *options) {
+		if maxRetries >= 0 {
+			o.maxRetries = maxRetries
+		}
+		if delay >= 0 {
+			o.retryDelay = delay
+		}
+	}
+}
@@ -3,6 +3,7 @@ package driver
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"sync"
 
 	"github.com/redis/go-redis/v9"
@@ -42,6 +43,14 @@ func NewRedis(client *redis.Client, log log.Logger, opts ...Option) (Driver, err
 }
 
 func (b *redisDriver) Subscribe(name string, topic string) (<-chan entity.PubsubMessage, error) {
+	return b.SubscribeFiltered(name, topic, nil)
+}
+
+func (b *redisDriver) SubscribeFiltered(name string, topic string, filter Filter) (<-chan entity.PubsubMessage, error) {
+	return b.SubscribeVersioned(name, topic, Versions{}, filter)
+}
+
+func (b *redisDriver) SubscribeVersioned(name string, topic string, versions Versions, filter Filter) (<-chan entity.PubsubMessage, error) {
 	if name == "" {
 		return nil, nil
 	}
@@ -49,7 +58,7 @@ func (b *redisDriver) Subscribe(name string, topic string) (<-chan entity.Pubsub
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	sub := newSubscriber(name, b.opts)
+	sub := newSubscriber(name, versions, filter, b.opts)
 	b.topics[topic] = append(b.topics[topic], sub)
 
 	pattern := b.getTopicPattern(topic)
@@ -196,6 +205,16 @@ func (b *redisDriver) Start(ctx context.Context) error {
 	return nil
 }
 
+func (b *redisDriver) Drain(ctx context.Context) error {
+	b.mu.RLock()
+	var subs []*subscriber
+	for _, s := range b.topics {
+		subs = append(subs, s...)
+	}
+	b.mu.RUnlock()
+	return drainAll(ctx, subs)
+}
+
 func (b *redisDriver) Stop(wait bool) error {
 	if b.cancel != nil {
 		b.cancel()
@@ -230,8 +249,11 @@ func (b *redisDriver) Stop(wait bool) error {
 
 // Publish dispatches locally and sends to Redis for cross-instance delivery.
 func (b *redisDriver) Publish(ctx context.Context, from string, topic string, kind string, payload any) error {
-	msg := entity.PubsubMessage{From: from, Topic: topic, Kind: kind, Payload: payload}
+	msg := entity.PubsubMessage{From: from, Topic: topic, Kind: kind, Payload: payload, Version: versionOf(payload)}
 
+	_, done := b.order(topic)
+	defer done()
+	b.published(topic)
 	b.mu.RLock()
 	lagged := b.fanout(b.topics, from, msg)
 	b.mu.RUnlock()
@@ -249,6 +271,7 @@ func (b *redisDriver) Publish(ctx context.Context, from string, topic string, ki
 		Publisher: from,
 		Topic:     topic,
 		Kind:      kind,
+		Version:   msg.Version,
 		Payload:   rawPayload,
 	}
 
@@ -262,7 +285,32 @@ func (b *redisDriver) Publish(ctx context.Context, from string, topic string, ki
 	}
 
 	channel := b.getRedisChannel(topic)
-	return b.client.Publish(ctx, channel, data).Err()
+	return b.deliver(ctx, topic, b.opts.minAcks, func(ctx context.Context) (int64, error) {
+		acks, err := b.client.Publish(ctx, channel, data).Result()
+		if err != nil {
+			return 0, err
+		}
+		// PUBLISH counts the pattern subscriptions of this instance too,
+		// which are no cross-instance receivers
+		return acks - b.localReceivers(channel), nil
+	})
+}
+
+// localReceivers returns how many of the pattern subscriptions of this
+// instance match channel.
+func (b *redisDriver) localReceivers(channel string) int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.pubsub == nil {
+		return 0
+	}
+	var n int64
+	for pattern := range b.patterns {
+		if strings.HasPrefix(channel, strings.TrimSuffix(pattern, "*")) {
+			n++
+		}
+	}
+	return n
 }
 
 func (b *redisDriver) listenForMessages() {
@@ -294,6 +342,7 @@ func (b *redisDriver) handleRedisMessage(msg *redis.Message) {
 		Topic:   eventMsg.Topic,
 		Kind:    eventMsg.Kind,
 		Payload: eventMsg.Payload,
+		Version: eventMsg.Version,
 	}
 
 	b.mu.RLock()
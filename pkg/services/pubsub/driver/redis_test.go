@@ -2,12 +2,17 @@ package driver
 
 import (
 	"context"
+	"encoding/json"
+	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -126,6 +131,37 @@ func TestRedisCrossInstance(t *testing.T) {
 	}
 }
 
+func TestRedisCrossInstanceVersion(t *testing.T) {
+	client := getTestRedisClient(t)
+
+	b1, err := NewRedis(client, log.Default)
+	require.NoError(t, err)
+	b2, err := NewRedis(client, log.Default)
+	require.NoError(t, err)
+
+	ch, err := b2.SubscribeVersioned("v1-only", "versioned/topic", Versions{Max: 1}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, b1.Start(context.Background()))
+	require.NoError(t, b2.Start(context.Background()))
+	defer b1.Stop(true)
+	defer b2.Stop(true)
+
+	time.Sleep(200 * time.Millisecond)
+
+	require.NoError(t, b1.Publish(context.Background(), "publisher", "versioned/topic", "versioned", versionedEvent{Name: "new", V: 2}))
+	require.NoError(t, b1.Publish(context.Background(), "publisher", "versioned/topic", "versioned", versionedEvent{Name: "old", V: 1}))
+
+	// the version crosses the wire, so the v2 event is skipped remotely too
+	select {
+	case msg := <-ch:
+		assert.Equal(t, 1, msg.Version)
+		assert.JSONEq(t, `{"name":"old","v":1}`, string(msg.Payload.(json.RawMessage)))
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for cross-instance message")
+	}
+}
+
 func TestRedisStartStop(t *testing.T) {
 	client := getTestRedisClient(t)
 
@@ -186,3 +222,88 @@ func TestRedisStopWithoutStart(t *testing.T) {
 	err = b.Stop(true)
 	assert.NoError(t, err)
 }
+
+// flakyPublish answers PUBLISH without a server. The first calls fail with a
+// network error, up to failures; later calls report acks receivers.
+type flakyPublish struct {
+	failures int32
+	acks     int64
+	calls    atomic.Int32
+}
+
+func (h *flakyPublish) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *flakyPublish) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (h *flakyPublish) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() != "publish" {
+			return next(ctx, cmd)
+		}
+		if h.calls.Add(1) <= h.failures {
+			err := &net.OpError{Op: "write", Net: "tcp", Err: errors.Newf("connection reset")}
+			cmd.SetErr(err)
+			return err
+		}
+		cmd.(*redis.IntCmd).SetVal(h.acks)
+		return nil
+	}
+}
+
+func newFlakyRedis(t *testing.T, hook *flakyPublish, opts ...Option) Driver {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0"})
+	client.AddHook(hook)
+	t.Cleanup(func() { client.Close() })
+	b, err := NewRedis(client, log.Default, opts...)
+	require.NoError(t, err)
+	return b
+}
+
+func TestRedisAtLeastOnceRetry(t *testing.T) {
+	hook := &flakyPublish{failures: 2, acks: 1}
+	b := newFlakyRedis(t, hook, WithDeliveryGuarantee(AtLeastOnce), WithRetry(3, time.Millisecond))
+
+	err := b.Publish(context.Background(), "publisher", "retry/topic", "event", "payload")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), hook.calls.Load())
+	assert.Equal(t, uint64(2), b.(Stats).Retried())
+}
+
+func TestRedisAtLeastOnceExhausted(t *testing.T) {
+	hook := &flakyPublish{acks: 1}
+	b := newFlakyRedis(t, hook, WithDeliveryGuarantee(AtLeastOnce), WithMinAcks(2), WithRetry(2, time.Millisecond))
+
+	err := b.Publish(context.Background(), "publisher", "retry/topic", "event", "payload")
+	assert.True(t, errors.Is(err, errors.Unavailable))
+	assert.Equal(t, int32(3), hook.calls.Load())
+}
+
+func TestRedisAtLeastOnceIgnoresLocalSubscription(t *testing.T) {
+	// the only receiver PUBLISH reports is this instance's own subscription
+	hook := &flakyPublish{acks: 1}
+	b := newFlakyRedis(t, hook, WithDeliveryGuarantee(AtLeastOnce), WithRetry(1, time.Millisecond))
+	rd := b.(*redisDriver)
+	rd.pubsub = rd.client.PSubscribe(context.Background())
+	rd.patterns[rd.getTopicPattern("retry")] = true
+
+	err := b.Publish(context.Background(), "publisher", "retry/topic", "event", "payload")
+	assert.True(t, errors.Is(err, errors.Unavailable))
+	assert.Equal(t, int32(2), hook.calls.Load())
+
+	// one more receiver on another instance satisfies it
+	hook.acks = 2
+	hook.calls.Store(0)
+	require.NoError(t, b.Publish(context.Background(), "publisher", "retry/topic", "event", "payload"))
+	assert.Equal(t, int32(1), hook.calls.Load())
+}
+
+func TestRedisBestEffortNoRetry(t *testing.T) {
+	hook := &flakyPublish{failures: 1, acks: 1}
+	b := newFlakyRedis(t, hook)
+
+	err := b.Publish(context.Background(), "publisher", "retry/topic", "event", "payload")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), hook.calls.Load())
+}
@@ -1,12 +1,19 @@
 package driver
 
 import (
+	"context"
 	"encoding/json"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/common"
 	"github.com/xhanio/framingo/pkg/types/entity"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -36,8 +43,10 @@ const (
 // The pump also owns close(ch). Closing from anywhere else would race the
 // pump's send.
 type subscriber struct {
-	name string
-	ch   chan entity.PubsubMessage
+	name     string
+	ch       chan entity.PubsubMessage
+	filter   Filter // nil delivers every message
+	versions Versions
 
 	queueCap int
 	onFull   OnFull
@@ -45,6 +54,8 @@ type subscriber struct {
 	mu      sync.Mutex
 	cond    *sync.Cond
 	pending []entity.PubsubMessage
+	sending bool          // the pump holds a message not yet in ch
+	idle    chan struct{} // closed once nothing is pending, see drain
 	stopped bool
 	drops   uint64
 
@@ -54,10 +65,12 @@ type subscriber struct {
 	evicting atomic.Bool
 }
 
-func newSubscriber(name string, opts *options) *subscriber {
+func newSubscriber(name string, versions Versions, filter Filter, opts *options) *subscriber {
 	s := &subscriber{
 		name:     name,
 		ch:       make(chan entity.PubsubMessage, opts.chanBuf),
+		filter:   filter,
+		versions: versions,
 		queueCap: opts.queueCap,
 		onFull:   opts.onFull,
 		quit:     make(chan struct{}),
@@ -92,6 +105,27 @@ func (s *subscriber) offer(msg entity.PubsubMessage) (delivery, uint64) {
 	return delivered, 0
 }
 
+// accept applies the versions and the filter of s to msg, returning the
+// message to deliver, migrated if it was older than versions.Min.
+func (s *subscriber) accept(msg entity.PubsubMessage) (entity.PubsubMessage, bool) {
+	if s.versions.Max > 0 && msg.Version > s.versions.Max {
+		return msg, false
+	}
+	if msg.Version < s.versions.Min {
+		if s.versions.Migrate == nil {
+			return msg, false
+		}
+		var ok bool
+		if msg, ok = s.versions.Migrate(msg); !ok {
+			return msg, false
+		}
+	}
+	if s.filter != nil && !s.filter(msg) {
+		return msg, false
+	}
+	return msg, true
+}
+
 // claimEviction reports whether this call is the one responsible for evicting
 // the subscriber. Concurrent publishes can all observe a full queue.
 func (s *subscriber) claimEviction() bool {
@@ -111,7 +145,53 @@ func (s *subscriber) pump() {
 		case <-s.quit:
 			return
 		}
+		s.mu.Lock()
+		s.sending = false
+		if len(s.pending) == 0 {
+			s.wake()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// wake releases the drain callers waiting for the queue to empty. Callers
+// must hold s.mu.
+func (s *subscriber) wake() {
+	if s.idle != nil {
+		close(s.idle)
+		s.idle = nil
+	}
+}
+
+// drain waits until every pending message has been handed to ch, or ctx is
+// done. A stopped subscriber has nothing left to deliver.
+func (s *subscriber) drain(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stopped || (len(s.pending) == 0 && !s.sending) {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.idle == nil {
+		s.idle = make(chan struct{})
+	}
+	idle := s.idle
+	s.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return errutil.FromContext(ctx)
+	}
+}
+
+// drainAll drains subs one after the other, all sharing the deadline of ctx.
+func drainAll(ctx context.Context, subs []*subscriber) error {
+	for _, sub := range subs {
+		if err := sub.drain(ctx); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func (s *subscriber) next() (entity.PubsubMessage, bool) {
@@ -126,6 +206,7 @@ func (s *subscriber) next() (entity.PubsubMessage, bool) {
 	msg := s.pending[0]
 	s.pending[0] = entity.PubsubMessage{}
 	s.pending = s.pending[1:]
+	s.sending = true
 	return msg, true
 }
 
@@ -137,6 +218,7 @@ func (s *subscriber) stop() {
 		s.mu.Lock()
 		s.stopped = true
 		s.pending = nil
+		s.wake()
 		s.mu.Unlock()
 		close(s.quit)
 		s.cond.Broadcast()
@@ -161,6 +243,21 @@ type laggard struct {
 	topic string
 }
 
+// ReplyTopic is the parent topic of the per-request reply topics of
+// pubsub.Manager.Request.
+const ReplyTopic = "pubsub/reply"
+
+// topicCounters tracks the traffic of one published topic.
+type topicCounters struct {
+	publishes  atomic.Uint64
+	deliveries atomic.Uint64
+	errors     atomic.Uint64
+}
+
+// untracked absorbs the counts of reply topics. Every request replies on a
+// topic of its own, so counting them would grow the counter map forever.
+var untracked topicCounters
+
 // dispatcher holds the delivery policy and counters shared by every driver.
 type dispatcher struct {
 	log  log.Logger
@@ -168,10 +265,43 @@ type dispatcher struct {
 
 	dropped atomic.Uint64
 	evicted atomic.Uint64
+	retried atomic.Uint64
+
+	// published topic -> *topicCounters. A sync.Map since counters are bumped
+	// while holding the driver's read lock, by concurrent publishers.
+	topics sync.Map
+
+	// ordered topic -> lock serializing its publishes, see WithOrderedDelivery.
+	// Read-only once built.
+	ordered map[string]*sync.Mutex
+	roots   []string // keys of ordered, broadest first
 }
 
 func newDispatcher(logger log.Logger, opts ...Option) *dispatcher {
-	return &dispatcher{log: logger, opts: newOptions(opts...)}
+	d := &dispatcher{log: logger, opts: newOptions(opts...), ordered: make(map[string]*sync.Mutex)}
+	for _, topic := range d.opts.ordered {
+		if _, ok := d.ordered[topic]; !ok {
+			d.ordered[topic] = &sync.Mutex{}
+			d.roots = append(d.roots, topic)
+		}
+	}
+	sort.Slice(d.roots, func(i, j int) bool { return len(d.roots[i]) < len(d.roots[j]) })
+	return d
+}
+
+// order serializes a publish to topic if it is under an ordered topic. It
+// returns that topic, or "" if topic is unordered, and the func ending the
+// publish. Only the broadest ordered topic is locked, so a publish never holds
+// two of these locks.
+func (d *dispatcher) order(topic string) (string, func()) {
+	for _, root := range d.roots {
+		if topicMatches(root, topic) {
+			mu := d.ordered[root]
+			mu.Lock()
+			return root, mu.Unlock
+		}
+	}
+	return "", func() {}
 }
 
 // Dropped returns the number of messages discarded because a subscriber could
@@ -182,14 +312,64 @@ func (d *dispatcher) Dropped() uint64 { return d.dropped.Load() }
 // keep up.
 func (d *dispatcher) Evicted() uint64 { return d.evicted.Load() }
 
+// Retried returns the number of cross-instance delivery attempts repeated
+// under AtLeastOnce.
+func (d *dispatcher) Retried() uint64 { return d.retried.Load() }
+
+func (d *dispatcher) counters(topic string) *topicCounters {
+	if topicMatches(ReplyTopic, topic) {
+		return &untracked
+	}
+	if c, ok := d.topics.Load(topic); ok {
+		return c.(*topicCounters)
+	}
+	c, _ := d.topics.LoadOrStore(topic, &topicCounters{})
+	return c.(*topicCounters)
+}
+
+// published counts a local publish to topic.
+func (d *dispatcher) published(topic string) {
+	d.counters(topic).publishes.Add(1)
+}
+
+func (d *dispatcher) TopicMetrics(topic string) entity.PubsubTopicMetrics {
+	m := entity.PubsubTopicMetrics{Topic: topic}
+	if c, ok := d.topics.Load(topic); ok {
+		c := c.(*topicCounters)
+		m.Publishes = c.publishes.Load()
+		m.Deliveries = c.deliveries.Load()
+		m.Errors = c.errors.Load()
+	}
+	return m
+}
+
+func (d *dispatcher) AllTopicMetrics() map[string]entity.PubsubTopicMetrics {
+	result := make(map[string]entity.PubsubTopicMetrics)
+	d.topics.Range(func(key, _ any) bool {
+		topic := key.(string)
+		result[topic] = d.TopicMetrics(topic)
+		return true
+	})
+	return result
+}
+
 // offer hands msg to sub and reports whether sub must now be evicted. It never
 // blocks, so it is safe under the driver's read lock. Eviction itself is not:
 // it needs the write lock, and Go's RWMutex is not upgradable.
 func (d *dispatcher) offer(sub *subscriber, subTopic string, msg entity.PubsubMessage) bool {
+	msg, ok := sub.accept(msg)
+	if !ok {
+		return false
+	}
+	counters := d.counters(msg.Topic)
 	switch result, drops := sub.offer(msg); result {
+	case delivered:
+		counters.deliveries.Add(1)
 	case lagging:
+		counters.errors.Add(1)
 		return true
 	case droppedMessage:
+		counters.errors.Add(1)
 		d.dropped.Add(1)
 		if shouldLogDrop(drops) {
 			d.log.Warnf("pubsub: subscriber %q is not draining %q, dropped %q message (%d dropped so far)",
@@ -232,13 +412,68 @@ func (d *dispatcher) claim(l laggard) bool {
 	return true
 }
 
+// deliver runs send, the driver's cross-instance hop, under the configured
+// delivery guarantee. send returns how many receivers acknowledged the
+// message. With BestEffort it runs once and its ack count is ignored; with
+// AtLeastOnce it is retried until it is acknowledged at least minAcks times,
+// the retry limit is reached, or ctx is done. Drivers whose backend
+// acknowledges a write as a whole pass a minAcks of 0, so only failed writes
+// are retried.
+func (d *dispatcher) deliver(ctx context.Context, topic string, minAcks int64, send func(ctx context.Context) (int64, error)) error {
+	err := d.send(ctx, topic, minAcks, send)
+	if err != nil {
+		d.counters(topic).errors.Add(1)
+	}
+	return err
+}
+
+func (d *dispatcher) send(ctx context.Context, topic string, minAcks int64, send func(ctx context.Context) (int64, error)) error {
+	if d.opts.guarantee != AtLeastOnce {
+		_, err := send(ctx)
+		return err
+	}
+	var lastErr error
+	for attempt := 0; attempt <= d.opts.maxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(d.opts.retryDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errutil.FromContext(ctx)
+			case <-timer.C:
+			}
+			d.retried.Add(1)
+		}
+		acks, err := send(ctx)
+		switch {
+		case err != nil:
+			lastErr = err
+		case acks < minAcks:
+			lastErr = errors.Unavailable.Newf("acknowledged by %d of %d required receivers", acks, minAcks)
+		default:
+			return nil
+		}
+		d.log.Debugf("pubsub: delivery attempt %d on %q failed: %v", attempt+1, topic, lastErr)
+	}
+	return errors.Unavailable.Wrapf(lastErr, "failed to deliver to %q after %d attempts", topic, d.opts.maxRetries+1)
+}
+
 type eventMessage struct {
 	Publisher string          `json:"publisher"`
 	Topic     string          `json:"topic"`
 	Kind      string          `json:"kind"`
+	Version   int             `json:"version,omitempty"`
 	Payload   json.RawMessage `json:"payload"`
 }
 
+// versionOf returns the schema version of payload, 0 if it is not versioned.
+func versionOf(payload any) int {
+	if v, ok := payload.(common.Versioned); ok {
+		return v.Version()
+	}
+	return 0
+}
+
 // topicMatches checks if a subscription topic matches a publish topic.
 // "app" matches "app", "app/module", "app/module/component".
 func topicMatches(subTopic, eventTopic string) bool {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/xhanio/errors"
 
 	"github.com/xhanio/framingo/pkg/services/pubsub/driver"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/printutil"
 )
 
@@ -29,16 +31,64 @@ func (m *manager) Stop(wait bool) error {
 	return nil
 }
 
+// Close shuts the manager down without losing messages: it rejects further
+// publishes, waits for the publishes in flight and for every queued message to
+// reach its subscriber's channel, then stops the driver, closing the channels.
+// If ctx is done first, the driver is stopped anyway, dropping what is still
+// queued, and the context error is returned.
+func (m *manager) Close(ctx context.Context) error {
+	m.cl.Lock()
+	m.closed = true
+	m.cl.Unlock()
+
+	published := make(chan struct{})
+	go func() {
+		m.publishing.Wait()
+		close(published)
+	}()
+	var err error
+	select {
+	case <-published:
+		err = m.bus.Drain(ctx)
+	case <-ctx.Done():
+		err = errutil.FromContext(ctx)
+	}
+	if err != nil {
+		m.log.Warnf("pubsub %s closed before pending deliveries drained: %s", m.Name(), err)
+		_ = m.bus.Stop(false)
+		return err
+	}
+	return m.Stop(true)
+}
+
 func (m *manager) Info(w io.Writer, debug bool) {
 	t := printutil.NewTable(w)
 	t.Header(m.Name())
 	t.Title("stat", "value")
 	t.Row("backend", fmt.Sprintf("%T", m.bus))
 	t.Row("published", m.published.Load())
+	if m.deadLetterTopic != "" {
+		t.Row("dead_lettered", m.deadLettered.Load())
+	}
 	if s, ok := m.bus.(driver.Stats); ok {
 		t.Row("dropped", s.Dropped())
 		t.Row("evicted", s.Evicted())
+		t.Row("retried", s.Retried())
 	}
 	t.NewLine()
+	if debug {
+		metrics := m.AllTopicMetrics()
+		topics := make([]string, 0, len(metrics))
+		for topic := range metrics {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		t.Title("topic", "publishes", "deliveries", "errors")
+		for _, topic := range topics {
+			tm := metrics[topic]
+			t.Row(topic, tm.Publishes, tm.Deliveries, tm.Errors)
+		}
+		t.NewLine()
+	}
 	t.Flush()
 }
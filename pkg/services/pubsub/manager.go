@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"path"
+	"sync"
 	"sync/atomic"
 
 	"github.com/xhanio/framingo/pkg/services/pubsub/driver"
@@ -18,7 +19,14 @@ type manager struct {
 
 	bus driver.Driver
 
-	published atomic.Uint64
+	deadLetterTopic string
+
+	published    atomic.Uint64
+	deadLettered atomic.Uint64
+
+	cl         sync.RWMutex   // lock for closed
+	closed     bool           // set by Close, rejects further publishes
+	publishing sync.WaitGroup // publishes in flight
 }
 
 func New(b driver.Driver, opts ...Option) Manager {
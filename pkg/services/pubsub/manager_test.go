@@ -126,6 +126,27 @@ func TestManagerHierarchicalTopics(t *testing.T) {
 	assert.Len(t, drain(t, leafCh, 100*time.Millisecond), 0)
 }
 
+func TestManagerSubscribeFiltered(t *testing.T) {
+	m := newTestManager()
+
+	ch, err := m.SubscribeFiltered("subscriber", "orders", func(msg entity.PubsubMessage) bool {
+		return msg.Kind == "order.paid"
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Publish(context.Background(), "publisher", "orders", "order.created", 1))
+	require.NoError(t, m.Publish(context.Background(), "publisher", "orders/eu", "order.paid", 2))
+	require.NoError(t, m.Publish(context.Background(), "publisher", "orders", "order.shipped", 3))
+
+	msgs := drain(t, ch, 100*time.Millisecond)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "order.paid", msgs[0].Kind)
+	assert.Equal(t, 2, msgs[0].Payload)
+	// dropped messages are filtered before dispatch, not counted as deliveries
+	assert.Equal(t, uint64(1), m.TopicMetrics("orders/eu").Deliveries)
+	assert.Equal(t, uint64(0), m.TopicMetrics("orders").Deliveries)
+}
+
 func TestManagerUnsubscribe(t *testing.T) {
 	m := newTestManager()
 
@@ -230,3 +251,92 @@ func TestManagerInfoReportsDeliveryStats(t *testing.T) {
 	assert.Contains(t, out, "dropped")
 	assert.Contains(t, out, "evicted")
 }
+
+func TestManagerTopicMetrics(t *testing.T) {
+	m := newTestManager()
+	ctx := context.Background()
+
+	app, err := m.Subscribe("app-sub", "app")
+	require.NoError(t, err)
+	module, err := m.Subscribe("module-sub", "app/module")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Publish(ctx, "publisher", "app/module", "test.event", i))
+	}
+	require.NoError(t, m.Publish(ctx, "publisher", "app", "test.event", "x"))
+	// self-delivery is skipped and not counted
+	require.NoError(t, m.Publish(ctx, "app-sub", "app", "test.event", "y"))
+	assert.Len(t, drain(t, app, 100*time.Millisecond), 4)
+	assert.Len(t, drain(t, module, 100*time.Millisecond), 3)
+
+	assert.Equal(t, entity.PubsubTopicMetrics{Topic: "app/module", Publishes: 3, Deliveries: 6}, m.TopicMetrics("app/module"))
+	assert.Equal(t, entity.PubsubTopicMetrics{Topic: "app", Publishes: 2, Deliveries: 1}, m.TopicMetrics("app"))
+	assert.Equal(t, entity.PubsubTopicMetrics{Topic: "unknown"}, m.TopicMetrics("unknown"))
+	assert.Len(t, m.AllTopicMetrics(), 2)
+}
+
+func TestManagerTopicMetricsErrors(t *testing.T) {
+	b := driver.NewMemory(log.Default, driver.WithQueueCap(1), driver.WithChannelBuffer(1))
+	m := newManager(b, WithLogger(log.Default))
+	ctx := context.Background()
+
+	_, err := m.Subscribe("stuck", "jobs")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, m.Publish(ctx, "publisher", "jobs", "test.event", i))
+	}
+	metrics := m.TopicMetrics("jobs")
+	assert.Equal(t, uint64(10), metrics.Publishes)
+	assert.Equal(t, uint64(10), metrics.Deliveries+metrics.Errors)
+	assert.NotZero(t, metrics.Errors)
+}
+
+func TestManagerClose(t *testing.T) {
+	b := driver.NewMemory(log.Default, driver.WithChannelBuffer(1))
+	m := newManager(b, WithName("test-pubsub"))
+	require.NoError(t, m.Start(context.Background()))
+
+	ch, err := m.Subscribe("slow", "topic")
+	require.NoError(t, err)
+	received := make(chan []entity.PubsubMessage)
+	go func() {
+		var msgs []entity.PubsubMessage
+		for msg := range ch {
+			time.Sleep(5 * time.Millisecond)
+			msgs = append(msgs, msg)
+		}
+		received <- msgs
+	}()
+
+	for i := range 10 {
+		require.NoError(t, m.Publish(context.Background(), "publisher", "topic", "test", i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, m.Close(ctx))
+
+	// every message published before Close reached the subscriber
+	msgs := <-received
+	require.Len(t, msgs, 10)
+	for i, msg := range msgs {
+		assert.Equal(t, i, msg.Payload)
+	}
+	assert.Error(t, m.Publish(context.Background(), "publisher", "topic", "test", "late"))
+}
+
+func TestManagerCloseTimeout(t *testing.T) {
+	b := driver.NewMemory(log.Default, driver.WithChannelBuffer(1))
+	m := newManager(b, WithName("test-pubsub"))
+	require.NoError(t, m.Start(context.Background()))
+
+	// nobody reads, so the queue never drains
+	_, err := m.Subscribe("stuck", "topic")
+	require.NoError(t, err)
+	for i := range 3 {
+		require.NoError(t, m.Publish(context.Background(), "publisher", "topic", "test", i))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, m.Close(ctx))
+}
@@ -1,13 +1,42 @@
 package pubsub
 
 import (
+	"context"
+	"time"
+
+	"github.com/xhanio/framingo/pkg/services/pubsub/driver"
 	"github.com/xhanio/framingo/pkg/types/common"
+	"github.com/xhanio/framingo/pkg/types/entity"
 	"github.com/xhanio/framingo/pkg/types/model"
 )
 
 type Manager interface {
 	// business
 	model.Pubsub
+	// SubscribeFiltered subscribes name to topic like Subscribe, but only
+	// delivers the messages filter accepts. The filter is evaluated when a
+	// message is dispatched, so rejected messages never reach the channel.
+	SubscribeFiltered(name, topic string, filter driver.Filter) (<-chan entity.PubsubMessage, error)
+	// SubscribeVersioned subscribes name to topic like SubscribeFiltered, but
+	// only delivers messages within versions, handing older ones to
+	// versions.Migrate. It lets instances of different releases share a topic
+	// during a rolling upgrade, see driver.Versions.
+	SubscribeVersioned(name, topic string, versions driver.Versions, filter driver.Filter) (<-chan entity.PubsubMessage, error)
+	// Request publishes msg to topic and waits for a Reply, see Request.
+	Request(ctx context.Context, svc, topic string, msg common.Message, timeout time.Duration) (entity.PubsubMessage, error)
+	// Reply publishes msg as the reply to the request with correlationID.
+	Reply(ctx context.Context, from, correlationID string, msg common.Message) error
+	// TopicMetrics returns the publish, delivery and delivery error counters
+	// of topic since start. They are zero if the driver keeps no statistics,
+	// and for the reply topics of Request.
+	TopicMetrics(topic string) entity.PubsubTopicMetrics
+	// AllTopicMetrics returns the counters of every topic seen so far.
+	AllTopicMetrics() map[string]entity.PubsubTopicMetrics
+	// Close stops the manager gracefully: further publishes fail, and it
+	// returns once the messages already published reached their subscribers'
+	// channels and the driver is stopped, or with the context error once ctx
+	// is done.
+	Close(ctx context.Context) error
 	// lifecycle
 	common.Daemon
 	common.Initializable
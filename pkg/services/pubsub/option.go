@@ -18,6 +18,14 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
+// WithDeadLetter re-publishes messages whose OnKind handler failed to topic,
+// wrapped in a DeadLetter with the subscriber name and error.
+func WithDeadLetter(topic string) Option {
+	return func(m *manager) {
+		m.deadLetterTopic = topic
+	}
+}
+
 func WithName(name string) Option {
 	return func(m *manager) {
 		m.name = name
@@ -0,0 +1,97 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/services/pubsub/driver"
+	"github.com/xhanio/framingo/pkg/types/common"
+	"github.com/xhanio/framingo/pkg/types/entity"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
+)
+
+// RequestKind is the message kind of requests published by Request.
+const RequestKind = "pubsub.request"
+
+// Request wraps a message published with Manager.Request. Handlers subscribe
+// with OnKind[Request], Decode the payload and answer with Manager.Reply.
+type Request struct {
+	CorrelationID  string          `json:"correlation_id"`
+	PayloadKind    string          `json:"payload_kind"`
+	PayloadVersion int             `json:"payload_version,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func (Request) Kind() string { return RequestKind }
+
+// Version is the schema version of the payload, so requests are published
+// with the version of the message they wrap.
+func (r Request) Version() int { return r.PayloadVersion }
+
+// Decode unmarshals the request payload into v.
+func (r Request) Decode(v any) error {
+	if err := json.Unmarshal(r.Payload, v); err != nil {
+		return errors.InvalidArgument.Wrapf(err, "failed to decode %s request payload", r.PayloadKind)
+	}
+	return nil
+}
+
+// Request publishes msg to topic as a Request and waits for the first reply
+// sent with Reply, or fails with errutil.Timeout once timeout elapses. A
+// timeout of 0 waits until ctx is done. The reply payload is msg itself on
+// the memory driver and raw json on the redis/kafka drivers.
+func (m *manager) Request(ctx context.Context, svc, topic string, msg common.Message, timeout time.Duration) (entity.PubsubMessage, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return entity.PubsubMessage{}, errors.InvalidArgument.Wrapf(err, "failed to encode %s request", msg.Kind())
+	}
+	id := uuid.NewString()
+	// a subscriber per request, so concurrent requests from svc don't share replies
+	name := path.Join(svc, "reply", id)
+	rt := path.Join(driver.ReplyTopic, id)
+	replies, err := m.Subscribe(name, rt)
+	if err != nil {
+		return entity.PubsubMessage{}, errors.Wrap(err)
+	}
+	defer func() {
+		if err := m.Unsubscribe(name, rt); err != nil {
+			m.log.Warnf("failed to unsubscribe reply topic %s: %s", rt, err)
+		}
+	}()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req := Request{
+		CorrelationID: id,
+		PayloadKind:   msg.Kind(),
+		Payload:       payload,
+	}
+	if v, ok := msg.(common.Versioned); ok {
+		req.PayloadVersion = v.Version()
+	}
+	if err := m.Publish(ctx, svc, topic, RequestKind, req); err != nil {
+		return entity.PubsubMessage{}, errors.Wrap(err)
+	}
+	select {
+	case reply, ok := <-replies:
+		if !ok {
+			return entity.PubsubMessage{}, errors.Unavailable.Newf("reply subscription to %s request on topic %s closed", msg.Kind(), topic)
+		}
+		return reply, nil
+	case <-ctx.Done():
+		return entity.PubsubMessage{}, errutil.Wrap(ctx.Err(), errors.WithMessage("no reply to %s request on topic %s", msg.Kind(), topic))
+	}
+}
+
+// Reply answers the request with the given correlation id.
+func (m *manager) Reply(ctx context.Context, from, correlationID string, msg common.Message) error {
+	return m.Publish(ctx, from, path.Join(driver.ReplyTopic, correlationID), msg.Kind(), msg)
+}
@@ -0,0 +1,71 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/utils/errutil"
+)
+
+func TestRequestReply(t *testing.T) {
+	m := newTestManager()
+	ctx := context.Background()
+
+	require.NoError(t, OnKind(m, "echo", "rpc", func(r Request) error {
+		var in userCreated
+		if err := r.Decode(&in); err != nil {
+			return err
+		}
+		return m.Reply(ctx, "echo", r.CorrelationID, userCreated{Name: in.Name + "!"})
+	}))
+	defer m.Unsubscribe("echo", "rpc")
+
+	reply, err := m.Request(ctx, "client", "rpc", userCreated{Name: "foo"}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, userCreated{}.Kind(), reply.Kind)
+	assert.Equal(t, userCreated{Name: "foo!"}, reply.Payload)
+	// reply topics are per request, so they must not pile up in the metrics
+	assert.Len(t, m.AllTopicMetrics(), 1)
+}
+
+type userCreatedV2 struct {
+	userCreated
+}
+
+func (userCreatedV2) Version() int { return 2 }
+
+func TestRequestVersion(t *testing.T) {
+	m := newTestManager()
+	ctx := context.Background()
+
+	requests, err := m.Subscribe("echo", "rpc")
+	require.NoError(t, err)
+	defer m.Unsubscribe("echo", "rpc")
+
+	go func() {
+		_, _ = m.Request(ctx, "client", "rpc", userCreatedV2{userCreated{Name: "foo"}}, 100*time.Millisecond)
+	}()
+	select {
+	case msg := <-requests:
+		assert.Equal(t, RequestKind, msg.Kind)
+		assert.Equal(t, 2, msg.Version)
+		assert.Equal(t, 2, msg.Payload.(Request).PayloadVersion)
+	case <-time.After(time.Second):
+		t.Fatal("request not published")
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	m := newTestManager()
+
+	start := time.Now()
+	_, err := m.Request(context.Background(), "client", "nobody", userCreated{Name: "foo"}, 50*time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errutil.Timeout), "expected timeout, got %v", err)
+	assert.Less(t, time.Since(start), time.Second)
+}
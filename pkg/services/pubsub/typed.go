@@ -0,0 +1,66 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/common"
+	"github.com/xhanio/framingo/pkg/types/entity"
+	"github.com/xhanio/framingo/pkg/types/model"
+	"github.com/xhanio/framingo/pkg/utils/log"
+)
+
+// OnKind subscribes name to topic and invokes handler for every message whose
+// payload is of type M, ignoring all other payloads. Payloads that crossed the
+// wire as raw json (redis/kafka drivers) are decoded into M when the message
+// kind equals M's kind. The dispatch loop exits once Unsubscribe(name, topic)
+// closes the subscription. Handler errors are logged, and forwarded to the
+// dead-letter topic if one is configured with WithDeadLetter.
+func OnKind[M common.Message](ps model.Pubsub, name, topic string, handler func(M) error) error {
+	ch, err := ps.Subscribe(name, topic)
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	go func() {
+		for msg := range ch {
+			typed, ok := asKind[M](msg)
+			if !ok {
+				continue
+			}
+			if err := handler(typed); err != nil {
+				log.Default.Errorf("error handling message: subscriber=%s kind=%s error=%v", name, msg.Kind, err)
+				if dl, ok := ps.(deadLetterer); ok {
+					dl.deadLetter(name, msg, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func asKind[M common.Message](msg entity.PubsubMessage) (M, bool) {
+	if typed, ok := msg.Payload.(M); ok {
+		return typed, true
+	}
+	var zero M
+	raw, ok := msg.Payload.(json.RawMessage)
+	t := reflect.TypeOf(zero)
+	if !ok || t == nil {
+		// not a wire payload, or M is an interface without a concrete type to decode into
+		return zero, false
+	}
+	ptr := reflect.New(t)
+	if t.Kind() == reflect.Pointer {
+		ptr.Elem().Set(reflect.New(t.Elem()))
+	}
+	typed := ptr.Elem().Interface().(M)
+	if typed.Kind() != msg.Kind {
+		return zero, false
+	}
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return zero, false
+	}
+	return ptr.Elem().Interface().(M), true
+}
@@ -0,0 +1,110 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/framingo/pkg/services/pubsub/driver"
+	"github.com/xhanio/framingo/pkg/types/entity"
+	"github.com/xhanio/framingo/pkg/utils/log"
+)
+
+type userCreated struct {
+	Name string `json:"name"`
+}
+
+func (userCreated) Kind() string { return "user.created" }
+
+type userDeleted struct {
+	Name string `json:"name"`
+}
+
+func (*userDeleted) Kind() string { return "user.deleted" }
+
+func TestOnKind(t *testing.T) {
+	m := newTestManager()
+
+	var created, deleted atomic.Int32
+	var createdName atomic.Value
+	require.NoError(t, OnKind(m, "created-handler", "users", func(e userCreated) error {
+		created.Add(1)
+		createdName.Store(e.Name)
+		return nil
+	}))
+	require.NoError(t, OnKind(m, "deleted-handler", "users", func(e *userDeleted) error {
+		deleted.Add(1)
+		return nil
+	}))
+
+	ctx := context.Background()
+	require.NoError(t, m.Publish(ctx, "publisher", "users", userCreated{}.Kind(), userCreated{Name: "foo"}))
+	require.NoError(t, m.Publish(ctx, "publisher", "users", "raw", "not a message"))
+
+	assert.Eventually(t, func() bool { return created.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "foo", createdName.Load())
+	assert.Never(t, func() bool { return deleted.Load() != 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	require.NoError(t, m.Publish(ctx, "publisher", "users", (&userDeleted{}).Kind(), &userDeleted{Name: "foo"}))
+	assert.Eventually(t, func() bool { return deleted.Load() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), created.Load())
+
+	require.NoError(t, m.Unsubscribe("created-handler", "users"))
+	require.NoError(t, m.Unsubscribe("deleted-handler", "users"))
+}
+
+func TestAsKindDecodesWirePayload(t *testing.T) {
+	raw, err := json.Marshal(userCreated{Name: "bar"})
+	require.NoError(t, err)
+
+	e, ok := asKind[userCreated](entity.PubsubMessage{Kind: "user.created", Payload: json.RawMessage(raw)})
+	assert.True(t, ok)
+	assert.Equal(t, "bar", e.Name)
+
+	p, ok := asKind[*userDeleted](entity.PubsubMessage{Kind: "user.deleted", Payload: json.RawMessage(raw)})
+	assert.True(t, ok)
+	assert.Equal(t, "bar", p.Name)
+
+	_, ok = asKind[*userDeleted](entity.PubsubMessage{Kind: "user.created", Payload: json.RawMessage(raw)})
+	assert.False(t, ok)
+}
+
+func TestOnKindDeadLetter(t *testing.T) {
+	m := newManager(driver.NewMemory(log.Default), WithName("test-pubsub"), WithDeadLetter("dead-letters"))
+
+	require.NoError(t, OnKind(m, "failing-handler", "users", func(e userCreated) error {
+		return fmt.Errorf("cannot handle %s", e.Name)
+	}))
+	var dlFailures atomic.Int32
+	dls := make(chan DeadLetter, 4)
+	require.NoError(t, OnKind(m, "dead-letter-handler", "dead-letters", func(dl DeadLetter) error {
+		dls <- dl
+		dlFailures.Add(1)
+		// a failing dead-letter subscriber must not produce another dead letter
+		return fmt.Errorf("dead letter handler failed")
+	}))
+
+	require.NoError(t, m.Publish(context.Background(), "publisher", "users", userCreated{}.Kind(), userCreated{Name: "foo"}))
+
+	select {
+	case dl := <-dls:
+		assert.Equal(t, "failing-handler", dl.Subscriber)
+		assert.Equal(t, "cannot handle foo", dl.Error)
+		assert.Equal(t, "users", dl.Message.Topic)
+		assert.Equal(t, userCreated{}.Kind(), dl.Message.Kind)
+		assert.Equal(t, userCreated{Name: "foo"}, dl.Message.Payload)
+	case <-time.After(time.Second):
+		t.Fatal("expected a dead letter")
+	}
+	assert.Never(t, func() bool { return dlFailures.Load() > 1 }, 200*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), m.deadLettered.Load())
+
+	require.NoError(t, m.Unsubscribe("failing-handler", "users"))
+	require.NoError(t, m.Unsubscribe("dead-letter-handler", "dead-letters"))
+}
@@ -21,6 +21,11 @@ func (m *manager) Register(services ...common.Service) {
 				m.c.addDependency(service, dep)
 			}
 		}
+		for _, dep := range optionalDependencies(service) {
+			if dep != nil {
+				m.c.addDependency(service, dep)
+			}
+		}
 	}
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/xhanio/framingo/pkg/types/common"
 	"github.com/xhanio/framingo/pkg/types/entity"
 	"github.com/xhanio/framingo/pkg/utils/confutil"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -20,6 +21,7 @@ type controller struct {
 	config          *viper.Viper
 	mu              sync.Mutex
 	shutdownTimeout time.Duration
+	serviceTimeout  time.Duration // bounds every init, start and stop call, 0 for no bound
 	graph           graph.Graph[common.Service]
 	services        []common.Service
 	stats           map[string]*entity.SupervisorStats
@@ -52,6 +54,13 @@ func (c *controller) addDependency(service, dep common.Service) {
 	c.graph.Add(service, dep)
 }
 
+func optionalDependencies(service common.Service) []common.Service {
+	if s, ok := service.(common.OptionallyDependent); ok {
+		return s.OptionalDependencies()
+	}
+	return nil
+}
+
 func (c *controller) topoSort() error {
 	err := c.graph.TopoSort()
 	if err != nil {
@@ -62,18 +71,46 @@ func (c *controller) topoSort() error {
 }
 
 func (c *controller) find(name string) common.Service {
-	for _, service := range c.services {
-		if service.Name() == name {
-			return service
-		}
-	}
-	return nil
+	service, _ := c.graph.Get(name)
+	return service
 }
 
 func (c *controller) stat(name string) *entity.SupervisorStats {
 	return c.stats[name]
 }
 
+// call runs the op of service with the deadline set by WithServiceTimeout. A
+// call that misses it is abandoned with an errutil.Timeout error while its
+// goroutine keeps running in the background, and the context passed to fn is
+// canceled so the service can give up. A call returning in time keeps its
+// context, daemons run on the one given to Start.
+func (c *controller) call(ctx context.Context, service common.Service, op string, fn func(ctx context.Context) error) error {
+	if c.serviceTimeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var missed bool
+	defer func() {
+		if missed {
+			cancel()
+		}
+	}()
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+	timer := time.NewTimer(c.serviceTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		missed = true
+		c.log.Warnf("%s of service %s timed out after %s", op, service.Name(), c.serviceTimeout)
+		return errutil.Timeout.Newf("%s of service %s timed out after %s", op, service.Name(), c.serviceTimeout)
+	}
+}
+
 func (c *controller) init(ctx context.Context, service common.Service) (bool, error) {
 	svc, ok := service.(common.Initializable)
 	if !ok {
@@ -87,9 +124,14 @@ func (c *controller) init(ctx context.Context, service common.Service) (bool, er
 	if c.config != nil {
 		ctx = confutil.WrapContext(ctx, c.config)
 	}
+	if c.serviceTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.serviceTimeout)
+		defer cancel()
+	}
 	stat := c.stat(service.Name())
 	stat.InitializedAt = time.Now()
-	err := svc.Init(ctx)
+	err := c.call(ctx, service, "init", svc.Init)
 	stat.InitDuration = time.Since(stat.InitializedAt)
 	stat.Initialized = err == nil
 	stat.InitializationErr = err
@@ -107,7 +149,7 @@ func (c *controller) start(service common.Service) (bool, error) {
 	stat.Started = true
 	stat.Stopped = false
 	stat.StartedAt = time.Now()
-	stat.StartErr = svc.Start(context.Background())
+	stat.StartErr = c.call(context.Background(), service, "start", svc.Start)
 	stat.StartDuration = time.Since(stat.StartedAt)
 	stat.Ready = stat.StartErr == nil
 	return true, stat.StartErr
@@ -123,7 +165,7 @@ func (c *controller) stop(service common.Service, wait bool) (bool, error) {
 	stat.Stopped = true
 	stat.Ready = false
 	stat.StoppedAt = time.Now()
-	stat.StopErr = svc.Stop(wait)
+	stat.StopErr = c.call(context.Background(), service, "stop", func(context.Context) error { return svc.Stop(wait) })
 	stat.StopDuration = time.Since(stat.StoppedAt)
 	return true, stat.StopErr
 }
@@ -134,7 +176,7 @@ func (c *controller) restart(ctx context.Context, service common.Service) error
 	stat := c.stat(service.Name())
 	c.log.Infof("restarting service %s (attempt %d)", service.Name(), stat.Restarts+1)
 	if svc, ok := service.(common.Daemon); ok {
-		if err := svc.Stop(true); err != nil {
+		if err := c.call(context.Background(), service, "stop", func(context.Context) error { return svc.Stop(true) }); err != nil {
 			c.log.Errorf("failed to stop service %s for restart: %s", service.Name(), err)
 		}
 		stat.Stopped = false
@@ -164,7 +206,7 @@ func (c *controller) initAll(ctx context.Context) error {
 		ready := true
 		for _, dep := range service.Dependencies() {
 			if dep == nil {
-				panic(errors.Newf("%s dependency should not be nil, pls remove optional service from Dependencies()", service.Name()))
+				panic(errors.Newf("%s dependency should not be nil, pls move optional service to OptionalDependencies()", service.Name()))
 			}
 			if stat := c.stat(dep.Name()); stat != nil && !stat.Initialized {
 				c.log.Debugf("%s dependency %s is not initialized", service.Name(), dep.Name())
@@ -0,0 +1,72 @@
+package supervisor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/common"
+)
+
+func (m *manager) ExportDiagram(w io.Writer, format DiagramFormat) error {
+	return m.c.diagram(w, format)
+}
+
+// diagram renders the graph in topological order once sorted, so the output
+// is stable across calls.
+func (c *controller) diagram(w io.Writer, format DiagramFormat) error {
+	nodes := c.services
+	if len(nodes) == 0 {
+		nodes = c.graph.Nodes()
+	}
+	var b strings.Builder
+	switch format {
+	case DOT:
+		b.WriteString("digraph services {\n")
+		for _, node := range nodes {
+			color := "green"
+			if !c.healthy(node) {
+				color = "red"
+			}
+			fmt.Fprintf(&b, "\t%q [style=filled, fillcolor=%s];\n", node.Name(), color)
+		}
+		for _, edge := range c.graph.Edges() {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", edge.From.Name(), edge.To.Name())
+		}
+		b.WriteString("}\n")
+	case Mermaid:
+		// mermaid ids cannot hold the slashes of service names, names go in the labels
+		ids := make(map[string]string, len(nodes))
+		b.WriteString("graph TD\n")
+		for i, node := range nodes {
+			ids[node.Name()] = fmt.Sprintf("s%d", i)
+			class := "healthy"
+			if !c.healthy(node) {
+				class = "failing"
+			}
+			label := strings.ReplaceAll(node.Name(), `"`, "#quot;")
+			fmt.Fprintf(&b, "\t%s[\"%s\"]:::%s\n", ids[node.Name()], label, class)
+		}
+		for _, edge := range c.graph.Edges() {
+			fmt.Fprintf(&b, "\t%s --> %s\n", ids[edge.From.Name()], ids[edge.To.Name()])
+		}
+		b.WriteString("\tclassDef healthy fill:#2e7d32,color:#fff\n")
+		b.WriteString("\tclassDef failing fill:#c62828,color:#fff\n")
+	default:
+		return errors.InvalidArgument.Newf("unknown diagram format %d", format)
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.Wrap(err)
+}
+
+// healthy reports whether service passed its last health check. Unlike the
+// HealthcheckErr of its stats, failing dependencies do not count against it.
+func (c *controller) healthy(service common.Service) bool {
+	stat := c.stat(service.Name())
+	if stat == nil {
+		return true
+	}
+	return stat.Healthcheck() == nil && stat.LivenessErr == nil && stat.ReadinessErr == nil
+}
@@ -13,6 +13,18 @@ func (m *manager) Init(ctx context.Context) error {
 }
 
 func (m *manager) Start(ctx context.Context) error {
+	if err := m.start(ctx); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	if m.stopped == nil {
+		m.stopped = make(chan struct{})
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *manager) start(ctx context.Context) error {
 	if m.cancel != nil {
 		m.log.Warnf("%s already started", m.Name())
 		return nil
@@ -39,6 +51,17 @@ func (m *manager) Start(ctx context.Context) error {
 }
 
 func (m *manager) Stop(wait bool) error {
+	err := m.stop(wait)
+	m.mu.Lock()
+	if m.stopped != nil {
+		close(m.stopped)
+		m.stopped = nil
+	}
+	m.mu.Unlock()
+	return err
+}
+
+func (m *manager) stop(wait bool) error {
 	if m.cancel == nil {
 		m.log.Warnf("%s already stopped", m.Name())
 		return nil
@@ -53,19 +76,30 @@ func (m *manager) Stop(wait bool) error {
 
 func (m *manager) Restart(ctx context.Context) error {
 	m.log.Infof("restarting %s", m.Name())
-	if err := m.Stop(true); err != nil {
+	// restart without releasing Wait
+	if err := m.stop(true); err != nil {
 		m.log.Errorf("failed to stop %s for restart: %s", m.Name(), err)
 	}
 	if err := m.Init(ctx); err != nil {
 		return err
 	}
-	if err := m.Start(ctx); err != nil {
+	if err := m.start(ctx); err != nil {
 		return err
 	}
 	m.log.Infof("%s restarted", m.Name())
 	return nil
 }
 
+func (m *manager) Wait() {
+	m.mu.Lock()
+	stopped := m.stopped
+	m.mu.Unlock()
+	if stopped != nil {
+		<-stopped
+	}
+	m.wg.Wait()
+}
+
 func (m *manager) Info(w io.Writer, debug bool) {
 	t := printutil.NewTable(w)
 	t.Header("service status")
@@ -19,6 +19,9 @@ type manager struct {
 	cancel context.CancelFunc
 	wg     *sync.WaitGroup
 
+	mu      sync.Mutex    // lock for stopped
+	stopped chan struct{} // closed once Stop completes, nil while not started
+
 	c       *controller
 	monitor *monitor
 }
@@ -10,9 +10,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
 
 	"github.com/xhanio/framingo/pkg/types/common"
 	"github.com/xhanio/framingo/pkg/types/entity"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -80,6 +82,67 @@ func (s *initOnlyService) Init(ctx context.Context) error {
 	return s.initErr
 }
 
+// optionalService declares optional dependencies on top of mockService
+type optionalService struct {
+	*mockService
+	optional []common.Service
+}
+
+func (s *optionalService) OptionalDependencies() []common.Service { return s.optional }
+
+// blockingService is a Daemon whose Start and Stop block until release is
+// closed.
+type blockingService struct {
+	name    string
+	deps    []common.Service
+	release chan struct{}
+}
+
+func (s *blockingService) Name() string                   { return s.name }
+func (s *blockingService) Dependencies() []common.Service { return s.deps }
+
+func (s *blockingService) Start(ctx context.Context) error {
+	<-s.release
+	return nil
+}
+
+func (s *blockingService) Stop(wait bool) error {
+	<-s.release
+	return nil
+}
+
+// ctxService blocks in Init and Start until its context is done, unless
+// block is false, in which case Start returns right away keeping its context.
+type ctxService struct {
+	name     string
+	block    bool
+	deadline bool
+	initErr  chan error
+	startCtx context.Context
+	stopped  chan struct{}
+}
+
+func (s *ctxService) Name() string                   { return s.name }
+func (s *ctxService) Dependencies() []common.Service { return nil }
+
+func (s *ctxService) Init(ctx context.Context) error {
+	_, s.deadline = ctx.Deadline()
+	<-ctx.Done()
+	s.initErr <- ctx.Err()
+	return ctx.Err()
+}
+
+func (s *ctxService) Start(ctx context.Context) error {
+	s.startCtx = ctx
+	if s.block {
+		<-ctx.Done()
+		close(s.stopped)
+	}
+	return nil
+}
+
+func (s *ctxService) Stop(wait bool) error { return nil }
+
 func testLogger() log.Logger {
 	return log.New(log.WithLevel(-1))
 }
@@ -264,6 +327,45 @@ func TestDependencyInitOrder(t *testing.T) {
 	})
 }
 
+func TestOptionalDependencies(t *testing.T) {
+	t.Run("nil optional dependency does not block init", func(t *testing.T) {
+		m := newTestManager()
+		svc := &optionalService{mockService: newMockService("svc"), optional: []common.Service{nil}}
+		m.Register(svc)
+		require.NoError(t, m.TopoSort())
+
+		require.NoError(t, m.Init(context.Background()))
+		assert.Equal(t, 1, svc.initCalled)
+		assert.Len(t, m.Services(), 1)
+	})
+
+	t.Run("present optional dependency orders startup", func(t *testing.T) {
+		m := newTestManager()
+		cache := newMockService("cache")
+		svc := &optionalService{mockService: newMockService("svc"), optional: []common.Service{cache, nil}}
+		m.Register(svc)
+		require.NoError(t, m.TopoSort())
+
+		names := make([]string, 0, 2)
+		for _, s := range m.Services() {
+			names = append(names, s.Name())
+		}
+		assert.Equal(t, []string{"cache", "svc"}, names)
+	})
+
+	t.Run("failed optional dependency does not block init", func(t *testing.T) {
+		m := newTestManager()
+		cache := newMockService("cache")
+		cache.initErr = fmt.Errorf("cache failed")
+		svc := &optionalService{mockService: newMockService("svc"), optional: []common.Service{cache}}
+		m.Register(cache, svc)
+		require.NoError(t, m.TopoSort())
+
+		assert.Error(t, m.Init(context.Background()))
+		assert.Equal(t, 1, svc.initCalled)
+	})
+}
+
 func TestStopOrder(t *testing.T) {
 	t.Run("stops in reverse topological order", func(t *testing.T) {
 		m := newTestManager()
@@ -524,6 +626,76 @@ func TestShutdownTimeout(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestServiceTimeout(t *testing.T) {
+	m := newTestManager(WithServiceTimeout(50 * time.Millisecond))
+	dep := newMockService("dep")
+	blocking := &blockingService{name: "blocking", deps: []common.Service{dep}, release: make(chan struct{})}
+	defer close(blocking.release)
+	svc := newMockService("svc")
+	svc.deps = []common.Service{blocking}
+	m.Register(dep, blocking, svc)
+	require.NoError(t, m.TopoSort())
+	require.NoError(t, m.Init(context.Background()))
+
+	// the blocking start times out and the next service is started anyway
+	started := time.Now()
+	err := m.Start(context.Background())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errutil.Timeout))
+	assert.Less(t, time.Since(started), time.Second)
+	assert.Equal(t, 1, svc.startCalled)
+
+	stats, _ := m.Stats()
+	byName := make(map[string]*entity.SupervisorStats)
+	for _, stat := range stats {
+		byName[stat.Name] = stat
+	}
+	assert.True(t, errors.Is(byName["blocking"].StartErr, errutil.Timeout))
+	assert.False(t, byName["blocking"].Ready)
+	assert.NoError(t, byName["svc"].StartErr)
+
+	// the blocking stop times out and its dependency is stopped anyway
+	err = m.c.stopAll(true)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, errutil.Timeout))
+	assert.Equal(t, 1, svc.stopCalled)
+	assert.Equal(t, 1, dep.stopCalled)
+	assert.True(t, errors.Is(byName["blocking"].StopErr, errutil.Timeout))
+}
+
+func TestServiceTimeoutContext(t *testing.T) {
+	m := newTestManager(WithServiceTimeout(50 * time.Millisecond))
+	svc := &ctxService{name: "svc", initErr: make(chan error, 1)}
+	m.Register(svc)
+	require.NoError(t, m.TopoSort())
+
+	// init runs with the timeout as its deadline
+	err := m.Init(context.Background())
+	assert.True(t, errors.Is(err, errutil.Timeout))
+	select {
+	case err := <-svc.initErr:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("context of the timed out init was not canceled")
+	}
+	assert.True(t, svc.deadline)
+
+	// a start missing the deadline has its context canceled
+	svc.block, svc.stopped = true, make(chan struct{})
+	assert.True(t, errors.Is(m.StartService("svc"), errutil.Timeout))
+	select {
+	case <-svc.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("context of the timed out start was not canceled")
+	}
+
+	// while a start in time keeps it for the lifetime of the daemon
+	svc.block = false
+	require.NoError(t, m.StartService("svc"))
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, svc.startCtx.Err())
+}
+
 func TestDoubleStartStop(t *testing.T) {
 	m := newTestManager()
 	svc := newMockService("svc")
@@ -592,6 +764,11 @@ func TestOptions(t *testing.T) {
 		assert.Equal(t, 5*time.Second, m.c.shutdownTimeout)
 	})
 
+	t.Run("WithServiceTimeout", func(t *testing.T) {
+		m := newTestManager(WithServiceTimeout(3 * time.Second))
+		assert.Equal(t, 3*time.Second, m.c.serviceTimeout)
+	})
+
 	t.Run("WithMonitorInterval", func(t *testing.T) {
 		m := newTestManager(WithMonitorInterval(10 * time.Second))
 		assert.Equal(t, 10*time.Second, m.monitor.interval)
@@ -629,3 +806,90 @@ func TestHealthcheckDependencyRecursion(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "dep dead")
 }
+
+func TestWait(t *testing.T) {
+	t.Run("returns immediately when not started", func(t *testing.T) {
+		m := newTestManager()
+		done := make(chan struct{})
+		go func() {
+			m.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Wait blocked on a supervisor that was never started")
+		}
+	})
+
+	t.Run("returns promptly after stop", func(t *testing.T) {
+		m := newTestManager()
+		svc := newMockService("a")
+		m.Register(svc)
+		require.NoError(t, m.TopoSort())
+		require.NoError(t, m.Init(context.Background()))
+		require.NoError(t, m.Start(context.Background()))
+
+		done := make(chan struct{})
+		go func() {
+			m.Wait()
+			close(done)
+		}()
+
+		// survives a restart
+		require.NoError(t, m.Restart(context.Background()))
+		select {
+		case <-done:
+			t.Fatal("Wait returned before Stop")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		require.NoError(t, m.Stop(true))
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Wait did not return after Stop")
+		}
+		assert.Equal(t, 2, svc.stopCalled)
+	})
+}
+
+func TestExportDiagram(t *testing.T) {
+	db := newMockService("db")
+	cache := newMockService("cache")
+	cache.startErr = fmt.Errorf("connection refused")
+	api := newMockService("svc/api")
+	api.deps = []common.Service{db, cache}
+
+	m := newTestManager()
+	m.Register(db, cache, api)
+	require.NoError(t, m.TopoSort())
+	require.NoError(t, m.Init(context.Background()))
+	require.Error(t, m.Start(context.Background()))
+	defer m.Stop(true)
+
+	var dot bytes.Buffer
+	require.NoError(t, m.ExportDiagram(&dot, DOT))
+	assert.Equal(t, `digraph services {
+	"cache" [style=filled, fillcolor=red];
+	"db" [style=filled, fillcolor=green];
+	"svc/api" [style=filled, fillcolor=green];
+	"svc/api" -> "db";
+	"svc/api" -> "cache";
+}
+`, dot.String())
+
+	var mermaid bytes.Buffer
+	require.NoError(t, m.ExportDiagram(&mermaid, Mermaid))
+	assert.Equal(t, `graph TD
+	s0["cache"]:::failing
+	s1["db"]:::healthy
+	s2["svc/api"]:::healthy
+	s2 --> s1
+	s2 --> s0
+	classDef healthy fill:#2e7d32,color:#fff
+	classDef failing fill:#c62828,color:#fff
+`, mermaid.String())
+
+	assert.Error(t, m.ExportDiagram(&mermaid, DiagramFormat(-1)))
+}
@@ -1,10 +1,20 @@
 package supervisor
 
 import (
+	"io"
+
 	"github.com/xhanio/framingo/pkg/types/common"
 	"github.com/xhanio/framingo/pkg/types/model"
 )
 
+// DiagramFormat is the output format of Manager.ExportDiagram.
+type DiagramFormat int
+
+const (
+	DOT     DiagramFormat = iota // Graphviz
+	Mermaid                      // Mermaid flowchart, e.g. for Markdown docs
+)
+
 type Manager interface {
 	// business
 	model.Supervisor
@@ -12,4 +22,11 @@ type Manager interface {
 	common.Initializable
 	common.Daemon
 	common.Debuggable
+	// Wait blocks until Stop has completed and the supervisor's goroutines have
+	// returned. It returns immediately if the supervisor is not started.
+	Wait()
+	// ExportDiagram renders the service dependency graph to w, with an arrow
+	// from every service to each of its dependencies. Healthy services are
+	// drawn green and services failing their last health check red.
+	ExportDiagram(w io.Writer, format DiagramFormat) error
 }
@@ -32,6 +32,21 @@ func WithShutdownTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithServiceTimeout bounds every Init, Start and Stop call of a service to
+// timeout, so a single hanging service cannot wedge the lifecycle. A call that
+// misses it is recorded as an errutil.Timeout error in the stats of the
+// service and the supervisor moves on to the next one, as if the call had
+// failed. Init runs with the timeout as its context deadline, and the context
+// of a timed out Init or Start is canceled, but the call itself cannot be
+// interrupted and keeps running in the background until it notices, so a
+// timed out service may leak goroutines and resources. A
+// timeout of 0 or less waits for the calls indefinitely, which is the default.
+func WithServiceTimeout(timeout time.Duration) Option {
+	return func(m *manager) {
+		m.c.serviceTimeout = timeout
+	}
+}
+
 func WithMonitorInterval(interval time.Duration) Option {
 	return func(m *manager) {
 		m.monitor.interval = interval
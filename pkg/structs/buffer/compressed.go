@@ -0,0 +1,166 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is the compression format of a compressed buffer. All codecs are
+// implemented in pure Go by github.com/klauspost/compress, so they need no
+// cgo or system libraries.
+type Codec int
+
+const (
+	Gzip   Codec = iota // gzip, levels 1 (fastest) to 9 (best)
+	Zstd                // zstandard, levels 1 (fastest) to 22 (best), mapped to the closest encoder level
+	Snappy              // snappy framing format, which has no levels
+)
+
+func (c Codec) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case Snappy:
+		return "snappy"
+	}
+	return fmt.Sprintf("codec(%d)", int(c))
+}
+
+type compressed struct {
+	codec Codec
+	level int
+
+	data   bytes.Buffer // compressed stream
+	w      io.WriteCloser
+	r      io.Reader
+	closeR func()
+	closed bool
+}
+
+// NewCompressed returns a buffer that compresses the data written to it with
+// codec at level, 0 meaning the codec's default, and decompresses it again on
+// Read. Only the compressed stream is kept in memory.
+//
+// The first Read ends the compressed stream, after which Write fails until
+// Reset, so data is written in one phase and read back in the next, like one
+// half of a double buffer.
+func NewCompressed(codec Codec, level int) (CompressedBuffer, error) {
+	c := &compressed{codec: codec, level: level}
+	if err := c.reset(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *compressed) reset() error {
+	c.data.Reset()
+	c.r = nil
+	if c.closeR != nil {
+		c.closeR()
+		c.closeR = nil
+	}
+	if w, ok := c.w.(interface{ Reset(io.Writer) }); ok {
+		// reuse the encoder and its state instead of allocating a new one
+		w.Reset(&c.data)
+		return nil
+	}
+	var err error
+	switch c.codec {
+	case Gzip:
+		level := c.level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		c.w, err = gzip.NewWriterLevel(&c.data, level)
+	case Zstd:
+		level := zstd.SpeedDefault
+		if c.level != 0 {
+			level = zstd.EncoderLevelFromZstd(c.level)
+		}
+		c.w, err = zstd.NewWriter(&c.data, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+	case Snappy:
+		c.w = snappy.NewBufferedWriter(&c.data)
+	default:
+		err = fmt.Errorf("unsupported codec %s", c.codec)
+	}
+	return err
+}
+
+// Write implements io.Writer interface
+func (c *compressed) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, errors.New("buffer is closed")
+	}
+	if c.r != nil {
+		return 0, errors.New("buffer is being read, reset it to write again")
+	}
+	return c.w.Write(p)
+}
+
+// Read implements io.Reader interface
+func (c *compressed) Read(p []byte) (int, error) {
+	if c.closed {
+		return 0, errors.New("buffer is closed")
+	}
+	if c.r == nil {
+		// flush what the codec still holds and end the stream
+		if err := c.w.Close(); err != nil {
+			return 0, err
+		}
+		src := bytes.NewReader(c.data.Bytes())
+		switch c.codec {
+		case Gzip:
+			zr, err := gzip.NewReader(src)
+			if err != nil {
+				return 0, err
+			}
+			c.r = zr
+		case Zstd:
+			zr, err := zstd.NewReader(src, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return 0, err
+			}
+			c.r, c.closeR = zr, zr.Close
+		case Snappy:
+			c.r = snappy.NewReader(src)
+		}
+	}
+	return c.r.Read(p)
+}
+
+// Close implements io.Closer interface
+func (c *compressed) Close() error {
+	if c.closed {
+		return nil // already closed, not an error
+	}
+	c.closed = true
+	if c.closeR != nil {
+		c.closeR()
+		c.closeR = nil
+	}
+	var err error
+	if c.r == nil {
+		err = c.w.Close()
+	}
+	c.data = bytes.Buffer{}
+	return err
+}
+
+// Reset discards the data and makes the buffer writable again
+func (c *compressed) Reset() {
+	if !c.closed {
+		// only fails for unsupported codecs, which NewCompressed rejects
+		_ = c.reset()
+	}
+}
+
+func (c *compressed) Len() int     { return c.data.Len() }
+func (c *compressed) Closed() bool { return c.closed }
@@ -0,0 +1,72 @@
+package buffer
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	payload := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 1000))
+	for _, codec := range []Codec{Gzip, Zstd, Snappy} {
+		t.Run(codec.String(), func(t *testing.T) {
+			buf, err := NewCompressed(codec, 0)
+			if err != nil {
+				t.Fatalf("NewCompressed() error = %v", err)
+			}
+			defer buf.Close()
+
+			// write in chunks to exercise streaming
+			for chunk := range slices.Chunk(payload, 4096) {
+				if n, err := buf.Write(chunk); err != nil || n != len(chunk) {
+					t.Fatalf("Write() = %d, %v", n, err)
+				}
+			}
+			got, err := io.ReadAll(buf)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("round trip mismatch: got %d bytes, want %d", len(got), len(payload))
+			}
+			if buf.Len() >= len(payload) {
+				t.Errorf("expected compressed size below %d, got %d", len(payload), buf.Len())
+			}
+			if _, err := buf.Write([]byte("more")); err == nil {
+				t.Error("expected Write to fail while reading")
+			}
+
+			buf.Reset()
+			if _, err := buf.Write([]byte("again")); err != nil {
+				t.Fatalf("Write() after Reset error = %v", err)
+			}
+			if got, err := io.ReadAll(buf); err != nil || string(got) != "again" {
+				t.Fatalf("ReadAll() after Reset = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestCompressedLevelAndClose(t *testing.T) {
+	if _, err := NewCompressed(Codec(42), 0); err == nil {
+		t.Error("expected an error for an unsupported codec")
+	}
+	buf, err := NewCompressed(Zstd, 19)
+	if err != nil {
+		t.Fatalf("NewCompressed() error = %v", err)
+	}
+	if err := buf.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !buf.Closed() {
+		t.Error("expected buffer to be closed")
+	}
+	if _, err := buf.Write([]byte("x")); err == nil {
+		t.Error("expected Write to fail after Close")
+	}
+	if _, err := buf.Read(make([]byte, 1)); err == nil {
+		t.Error("expected Read to fail after Close")
+	}
+}
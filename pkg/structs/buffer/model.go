@@ -4,11 +4,14 @@ import "io"
 
 var (
 	_ io.ReadWriter = (PooledBuffer)(nil)
+	_ io.ReadWriter = (RingBuffer)(nil)
+	_ io.ReadWriter = (CompressedBuffer)(nil)
 )
 
 type PoolG[T any] interface {
 	Get(required int) []T
 	Put(buffer []T)
+	NewBuffer(required int) PooledBufferG[T]
 	Stats() (gets, puts, hits, creates int64, hitRate float64)
 }
 
@@ -29,3 +32,23 @@ type PooledBufferG[T any] interface {
 }
 
 type PooledBuffer = PooledBufferG[byte]
+
+type CompressedBuffer interface {
+	io.ReadWriteCloser
+	Reset()
+	// Len returns the size of the compressed stream so far, excluding what
+	// the codec still buffers before the first Read.
+	Len() int
+	Closed() bool
+}
+
+type RingBufferG[T any] interface {
+	Write(p []T) (int, error)
+	Read(p []T) (int, error)
+	Reset()
+	Len() int
+	Cap() int
+	Data() []T
+}
+
+type RingBuffer = RingBufferG[byte]
@@ -93,6 +93,12 @@ func (p *pool[T]) Put(buffer []T) {
 	}
 }
 
+// NewBuffer creates a pooled buffer backed by a slice from this pool. Closing
+// the buffer returns its slice to the pool for the next Get or NewBuffer.
+func (p *pool[T]) NewBuffer(required int) PooledBufferG[T] {
+	return newPooledBuffer[T](required, p)
+}
+
 // findBestSize finds the smallest predefined size that can accommodate the required capacity
 // Returns 0 if no suitable size is found
 func (p *pool[T]) findBestSize(required int) int {
@@ -423,3 +423,36 @@ func TestPooledBufferGenericTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestPoolNewBuffer(t *testing.T) {
+	pool := NewPool[byte]()
+	buffer := pool.NewBuffer(100)
+	if buffer.Cap() != 1024 {
+		t.Fatalf("Expected capacity 1024, got %d", buffer.Cap())
+	}
+	buffer.Write([]byte("hello"))
+
+	// sync.Pool may drop a put (it does so randomly under the race detector),
+	// so allow a few cycles for a closed slice to come back
+	var reused bool
+	for i := 0; i < 10 && !reused; i++ {
+		data := buffer.(*pooled[byte]).data
+		backing := &data[:1][0]
+		if err := buffer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		buffer = pool.NewBuffer(100)
+		data = buffer.(*pooled[byte]).data
+		if buffer.Len() != 0 {
+			t.Fatalf("Reused buffer should be empty, got %d", buffer.Len())
+		}
+		reused = &data[:1][0] == backing
+	}
+	if !reused {
+		t.Error("Slice should be reused after Close")
+	}
+	_, puts, _, _, _ := pool.Stats()
+	if puts == 0 {
+		t.Error("Close should put the slice back to the pool")
+	}
+}
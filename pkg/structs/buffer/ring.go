@@ -0,0 +1,138 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrRingFull is returned by Write on a full ring buffer created with ErrorIfFull.
+var ErrRingFull = errors.New("ring buffer is full")
+
+type RingOption[T any] func(*ring[T])
+
+// ErrorIfFull makes Write fail with ErrRingFull instead of overwriting the oldest elements.
+func ErrorIfFull[T any]() RingOption[T] {
+	return func(r *ring[T]) {
+		r.errorIfFull = true
+	}
+}
+
+type ring[T any] struct {
+	sync.Mutex
+	data        []T
+	head        int // position of the oldest element
+	size        int // number of buffered elements
+	errorIfFull bool
+}
+
+// NewRingBuffer creates a fixed-capacity ring buffer. By default a write to a
+// full buffer overwrites the oldest elements.
+func NewRingBuffer[T any](capacity int, opts ...RingOption[T]) RingBufferG[T] {
+	return newRingBuffer(capacity, opts...)
+}
+
+func newRingBuffer[T any](capacity int, opts ...RingOption[T]) *ring[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	r := &ring[T]{
+		data: make([]T, capacity),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Write appends p to the buffer. When full, it either overwrites the oldest
+// elements or writes as many elements as fit and returns ErrRingFull.
+func (r *ring[T]) Write(p []T) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	n := len(p)
+	capacity := len(r.data)
+	if r.errorIfFull {
+		if free := capacity - r.size; n > free {
+			n = free
+		}
+	} else if n > capacity {
+		// only the last capacity elements would survive anyway
+		r.head = 0
+		r.size = copy(r.data, p[n-capacity:])
+		return n, nil
+	}
+	for i := 0; i < n; i++ {
+		r.data[(r.head+r.size)%capacity] = p[i]
+		if r.size < capacity {
+			r.size++
+		} else {
+			r.head = (r.head + 1) % capacity
+		}
+	}
+	if n < len(p) {
+		return n, ErrRingFull
+	}
+	return n, nil
+}
+
+// Read consumes the oldest elements into p. It returns io.EOF when the buffer is empty.
+func (r *ring[T]) Read(p []T) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.size == 0 {
+		return 0, io.EOF
+	}
+	n := r.copyTo(p)
+	var zero T
+	for i := 0; i < n; i++ {
+		// release references held by consumed elements
+		r.data[(r.head+i)%len(r.data)] = zero
+	}
+	r.head = (r.head + n) % len(r.data)
+	r.size -= n
+	return n, nil
+}
+
+// copyTo copies up to len(p) of the oldest elements into p without consuming them.
+func (r *ring[T]) copyTo(p []T) int {
+	n := min(len(p), r.size)
+	first := min(n, len(r.data)-r.head)
+	copy(p, r.data[r.head:r.head+first])
+	copy(p[first:n], r.data[:n-first])
+	return n
+}
+
+// Reset drops all buffered elements.
+func (r *ring[T]) Reset() {
+	r.Lock()
+	defer r.Unlock()
+	clear(r.data)
+	r.head = 0
+	r.size = 0
+}
+
+func (r *ring[T]) Len() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.size
+}
+
+func (r *ring[T]) Cap() int {
+	return len(r.data)
+}
+
+// Data returns a copy of the buffered elements from oldest to newest without consuming them.
+func (r *ring[T]) Data() []T {
+	r.Lock()
+	defer r.Unlock()
+	if r.size == 0 {
+		return nil
+	}
+	result := make([]T, r.size)
+	r.copyTo(result)
+	return result
+}
@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestRingBufferWrapAround(t *testing.T) {
+	rb := NewRingBuffer[int](4)
+	if rb.Cap() != 4 {
+		t.Fatalf("expected cap 4, got %d", rb.Cap())
+	}
+
+	if n, err := rb.Write([]int{1, 2, 3}); err != nil || n != 3 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	p := make([]int, 2)
+	if n, err := rb.Read(p); err != nil || n != 2 || !slices.Equal(p, []int{1, 2}) {
+		t.Fatalf("Read() = %d, %v, %v", n, err, p)
+	}
+	// head is now at 2, this write wraps around the end of the backing slice
+	if n, err := rb.Write([]int{4, 5, 6}); err != nil || n != 3 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if got := rb.Data(); !slices.Equal(got, []int{3, 4, 5, 6}) {
+		t.Fatalf("Data() = %v, want [3 4 5 6]", got)
+	}
+	if rb.Len() != 4 {
+		t.Fatalf("expected len 4, got %d", rb.Len())
+	}
+
+	p = make([]int, 10)
+	n, err := rb.Read(p)
+	if err != nil || !slices.Equal(p[:n], []int{3, 4, 5, 6}) {
+		t.Fatalf("Read() = %v, %v", p[:n], err)
+	}
+	if _, err := rb.Read(p); err != io.EOF {
+		t.Fatalf("expected io.EOF on empty buffer, got %v", err)
+	}
+}
+
+func TestRingBufferOverwrite(t *testing.T) {
+	rb := NewRingBuffer[string](3)
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := rb.Write([]string{line}); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+	if got := rb.Data(); !slices.Equal(got, []string{"c", "d", "e"}) {
+		t.Fatalf("Data() = %v, want the last 3 lines", got)
+	}
+
+	// a single write larger than the capacity keeps its tail
+	n, err := rb.Write([]string{"f", "g", "h", "i"})
+	if err != nil || n != 4 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if got := rb.Data(); !slices.Equal(got, []string{"g", "h", "i"}) {
+		t.Fatalf("Data() = %v, want [g h i]", got)
+	}
+
+	rb.Reset()
+	if rb.Len() != 0 || rb.Data() != nil {
+		t.Fatal("expected empty buffer after Reset")
+	}
+}
+
+func TestRingBufferErrorIfFull(t *testing.T) {
+	rb := NewRingBuffer(3, ErrorIfFull[byte]())
+	n, err := rb.Write([]byte("ab"))
+	if err != nil || n != 2 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	n, err = rb.Write([]byte("cde"))
+	if !errors.Is(err, ErrRingFull) || n != 1 {
+		t.Fatalf("expected partial write of 1 with ErrRingFull, got %d, %v", n, err)
+	}
+	if got := string(rb.Data()); got != "abc" {
+		t.Fatalf("Data() = %q, want %q", got, "abc")
+	}
+
+	// reading frees room for new writes
+	p := make([]byte, 2)
+	if _, err := rb.Read(p); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := rb.Write([]byte("de")); err != nil || n != 2 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if got := string(rb.Data()); got != "cde" {
+		t.Fatalf("Data() = %q, want %q", got, "cde")
+	}
+}
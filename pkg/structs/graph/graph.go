@@ -1,6 +1,8 @@
 package graph
 
 import (
+	"sync"
+
 	"github.com/xhanio/errors"
 	"github.com/xhanio/framingo/pkg/structs/staque"
 	"github.com/xhanio/framingo/pkg/types/common"
@@ -8,18 +10,23 @@ import (
 )
 
 type graph[T common.Named] struct {
-	added   maputil.Set[string]
+	mu      sync.RWMutex
+	added   map[string]T
+	aliases map[string]string // alias to canonical name
 	nodes   []T
-	edges   map[string][]T
+	edges   map[string][]T // dependency to dependents
+	deps    map[string][]T // dependent to dependencies
 	visited maputil.Set[string]
 	exists  maputil.Set[string]
 }
 
 func newGraph[T common.Named]() *graph[T] {
 	return &graph[T]{
-		added:   make(maputil.Set[string]),
+		added:   make(map[string]T),
+		aliases: make(map[string]string),
 		nodes:   make([]T, 0),
 		edges:   make(map[string][]T),
+		deps:    make(map[string][]T),
 		visited: make(maputil.Set[string]),
 		exists:  make(maputil.Set[string]),
 	}
@@ -30,18 +37,50 @@ func New[T common.Named]() Graph[T] {
 }
 
 func (g *graph[T]) Add(node T, dependencies ...T) {
-	g.add(node)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	node = g.add(node)
 	for _, dep := range dependencies {
-		g.add(dep)
+		dep = g.resolve(dep)
 		g.edges[dep.Name()] = append(g.edges[dep.Name()], node)
+		g.deps[node.Name()] = append(g.deps[node.Name()], dep)
+	}
+}
+
+func (g *graph[T]) AddAliased(node T, aliases ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	node = g.add(node)
+	for _, alias := range aliases {
+		if _, ok := g.get(alias); ok {
+			// taken by a node or an earlier alias, which keeps it
+			continue
+		}
+		g.aliases[alias] = node.Name()
 	}
 }
 
-func (g *graph[T]) add(node T) {
-	if !g.added.Has(node.Name()) {
-		g.added.Add(node.Name())
-		g.nodes = append(g.nodes, node)
+// add adds node unless a node of the same name is already in the graph, and
+// returns the node the graph holds under that name. A node takes its name
+// over an alias of another node, which is dropped.
+func (g *graph[T]) add(node T) T {
+	name := node.Name()
+	if existing, ok := g.added[name]; ok {
+		return existing
 	}
+	delete(g.aliases, name)
+	g.added[name] = node
+	g.nodes = append(g.nodes, node)
+	return node
+}
+
+// resolve returns the node a dependency refers to by name or alias, adding
+// dep if neither is in the graph.
+func (g *graph[T]) resolve(dep T) T {
+	if existing, ok := g.get(dep.Name()); ok {
+		return existing
+	}
+	return g.add(dep)
 }
 
 func (g *graph[T]) dfs(v T, stack staque.Simple[T]) bool {
@@ -64,6 +103,8 @@ func (g *graph[T]) dfs(v T, stack staque.Simple[T]) bool {
 }
 
 func (g *graph[T]) TopoSort() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.visited = make(maputil.Set[string], len(g.nodes))
 	g.exists = make(maputil.Set[string], len(g.nodes))
 
@@ -85,9 +126,126 @@ func (g *graph[T]) TopoSort() error {
 }
 
 func (g *graph[T]) Nodes() []T {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.nodes
 }
 
+func (g *graph[T]) Get(name string) (T, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.get(name)
+}
+
+// get resolves name like Get. Callers must hold the lock.
+func (g *graph[T]) get(name string) (T, bool) {
+	if canonical, ok := g.aliases[name]; ok {
+		name = canonical
+	}
+	node, ok := g.added[name]
+	return node, ok
+}
+
+func (g *graph[T]) Has(name string) bool {
+	_, ok := g.Get(name)
+	return ok
+}
+
 func (g *graph[T]) Count() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return len(g.nodes)
 }
+
+// Clone returns an independent copy of the graph's nodes and edges. The node
+// values themselves are copied as-is, so pointer nodes are shared.
+func (g *graph[T]) Clone() Graph[T] {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	c := newGraph[T]()
+	for name, node := range g.added {
+		c.added[name] = node
+	}
+	for alias, name := range g.aliases {
+		c.aliases[alias] = name
+	}
+	c.nodes = append(c.nodes, g.nodes...)
+	for name, deps := range g.edges {
+		c.edges[name] = append([]T(nil), deps...)
+	}
+	for name, deps := range g.deps {
+		c.deps[name] = append([]T(nil), deps...)
+	}
+	return c
+}
+
+func (g *graph[T]) Edges() []Edge[T] {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var result []Edge[T]
+	for _, node := range g.nodes {
+		seen := make(maputil.Set[string])
+		for _, dep := range g.deps[node.Name()] {
+			if seen.Has(dep.Name()) {
+				continue
+			}
+			seen.Add(dep.Name())
+			result = append(result, Edge[T]{From: node, To: dep})
+		}
+	}
+	return result
+}
+
+type step[T common.Named] struct {
+	node  T
+	depth int
+}
+
+func (g *graph[T]) Walk(start T, order Order, visit func(node T, depth int) bool) {
+	start, ok := g.Get(start.Name())
+	if !ok {
+		return
+	}
+	visited := make(maputil.Set[string])
+	frontier := staque.NewSimple[step[T]](0)
+	frontier.Push(step[T]{node: start})
+	for !frontier.IsEmpty() {
+		var s step[T]
+		if order == DFS {
+			s = frontier.MustPop()
+		} else {
+			s = frontier.MustShift()
+		}
+		name := s.node.Name()
+		if visited.Has(name) {
+			continue
+		}
+		visited.Add(name)
+		if !visit(s.node, s.depth) {
+			continue
+		}
+		// visit may add to the graph, so the lock is only held to read deps
+		g.mu.RLock()
+		deps := g.deps[name]
+		g.mu.RUnlock()
+		for i := range deps {
+			if order == DFS {
+				// push in reverse so the first dependency is popped first
+				frontier.Push(step[T]{node: deps[len(deps)-1-i], depth: s.depth + 1})
+			} else {
+				frontier.Push(step[T]{node: deps[i], depth: s.depth + 1})
+			}
+		}
+	}
+}
+
+func (g *graph[T]) TransitiveDeps(node T) []T {
+	var result []T
+	g.Walk(node, BFS, func(n T, depth int) bool {
+		if depth > 0 {
+			result = append(result, n)
+		}
+		return true
+	})
+	return result
+}
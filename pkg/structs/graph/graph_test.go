@@ -1,6 +1,9 @@
 package graph
 
 import (
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/xhanio/errors"
@@ -311,6 +314,31 @@ func TestGraph_Count(t *testing.T) {
 	}
 }
 
+func TestGraph_Get(t *testing.T) {
+	g := New[testNode]()
+	a, b := newTestNode("A"), newTestNode("B")
+	g.Add(a, b)
+
+	for _, want := range []testNode{a, b} {
+		got, ok := g.Get(want.Name())
+		if !ok || got != want {
+			t.Errorf("Get(%q) = %v, %v, want %v, true", want.Name(), got, ok, want)
+		}
+		if !g.Has(want.Name()) {
+			t.Errorf("Has(%q) = false, want true", want.Name())
+		}
+	}
+	if got, ok := g.Get("missing"); ok || got != (testNode{}) {
+		t.Errorf("Get(missing) = %v, %v, want zero value, false", got, ok)
+	}
+	if g.Has("missing") {
+		t.Error("Has(missing) = true, want false")
+	}
+	if _, ok := g.Clone().Get("B"); !ok {
+		t.Error("clone should keep nodes retrievable by name")
+	}
+}
+
 func TestGraph_Nodes(t *testing.T) {
 	g := New[testNode]()
 
@@ -380,3 +408,201 @@ func TestGraph_TopoSortPreservesOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestGraph_Clone(t *testing.T) {
+	g := New[testNode]()
+	g.Add(newTestNode("B"), newTestNode("A")) // B depends on A
+	clone := g.Clone()
+
+	// mutate the original after cloning
+	g.Add(newTestNode("C"), newTestNode("B"))
+	g.Add(newTestNode("A"), newTestNode("D"))
+	if err := g.TopoSort(); err != nil {
+		t.Fatalf("TopoSort() on original failed: %v", err)
+	}
+	if g.Count() != 4 {
+		t.Errorf("original Count() = %d, want 4", g.Count())
+	}
+
+	if clone.Count() != 2 {
+		t.Fatalf("clone Count() = %d, want 2", clone.Count())
+	}
+	if err := clone.TopoSort(); err != nil {
+		t.Fatalf("TopoSort() on clone failed: %v", err)
+	}
+	var order []string
+	for _, node := range clone.Nodes() {
+		order = append(order, node.Name())
+	}
+	if len(order) != 2 || order[0] != "A" || order[1] != "B" {
+		t.Errorf("clone order = %v, want [A B]", order)
+	}
+
+	// mutate the clone and confirm the original is unaffected
+	clone.Add(newTestNode("E"), newTestNode("A"))
+	if g.Count() != 4 {
+		t.Errorf("original Count() after clone mutation = %d, want 4", g.Count())
+	}
+}
+
+func TestGraph_CloneConcurrentAdd(t *testing.T) {
+	g := New[testNode]()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			g.Add(newTestNode(fmt.Sprintf("n%d", i)), newTestNode("root"))
+		}
+	}()
+	for range 100 {
+		g.Clone()
+	}
+	wg.Wait()
+	if c := g.Clone().Count(); c != 101 {
+		t.Errorf("clone Count() = %d, want 101", c)
+	}
+}
+
+// newDiamond builds d -> {b, c} -> a, where d depends on b and c which both depend on a
+func newDiamond() Graph[testNode] {
+	g := New[testNode]()
+	a, b, c, d := newTestNode("a"), newTestNode("b"), newTestNode("c"), newTestNode("d")
+	g.Add(b, a)
+	g.Add(c, a)
+	g.Add(d, b, c)
+	return g
+}
+
+func TestGraph_Walk(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    string
+		order    Order
+		prune    map[string]bool
+		expected []string
+	}{
+		{name: "bfs", start: "d", order: BFS, expected: []string{"d:0", "b:1", "c:1", "a:2"}},
+		{name: "dfs", start: "d", order: DFS, expected: []string{"d:0", "b:1", "a:2", "c:1"}},
+		{name: "bfs prune one branch", start: "d", order: BFS, prune: map[string]bool{"b": true}, expected: []string{"d:0", "b:1", "c:1", "a:2"}},
+		{name: "dfs prune both branches", start: "d", order: DFS, prune: map[string]bool{"b": true, "c": true}, expected: []string{"d:0", "b:1", "c:1"}},
+		{name: "leaf", start: "a", order: BFS, expected: []string{"a:0"}},
+		{name: "unknown start", start: "x", order: BFS, expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var visited []string
+			newDiamond().Walk(newTestNode(tt.start), tt.order, func(node testNode, depth int) bool {
+				visited = append(visited, fmt.Sprintf("%s:%d", node.Name(), depth))
+				return !tt.prune[node.Name()]
+			})
+			if !slices.Equal(visited, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, visited)
+			}
+		})
+	}
+}
+
+func TestGraph_TransitiveDeps(t *testing.T) {
+	g := newDiamond()
+	names := func(nodes []testNode) []string {
+		var result []string
+		for _, n := range nodes {
+			result = append(result, n.Name())
+		}
+		return result
+	}
+	if deps := names(g.TransitiveDeps(newTestNode("d"))); !slices.Equal(deps, []string{"b", "c", "a"}) {
+		t.Errorf("expected transitive deps of d to be [b c a], got %v", deps)
+	}
+	if deps := names(g.TransitiveDeps(newTestNode("b"))); !slices.Equal(deps, []string{"a"}) {
+		t.Errorf("expected transitive deps of b to be [a], got %v", deps)
+	}
+	if deps := g.TransitiveDeps(newTestNode("a")); len(deps) != 0 {
+		t.Errorf("expected no transitive deps of a, got %v", names(deps))
+	}
+}
+
+func TestGraph_Edges(t *testing.T) {
+	g := New[testNode]()
+	a, b, c := newTestNode("A"), newTestNode("B"), newTestNode("C")
+	g.Add(a, b, c)
+	g.Add(b, c)
+	g.Add(a, b) // duplicate dependency
+
+	want := []Edge[testNode]{{From: a, To: b}, {From: a, To: c}, {From: b, To: c}}
+	if got := g.Edges(); !slices.Equal(got, want) {
+		t.Errorf("Edges() = %v, want %v", got, want)
+	}
+	if err := g.TopoSort(); err != nil {
+		t.Fatalf("TopoSort() error = %v", err)
+	}
+	// sorted so dependencies come first
+	want = []Edge[testNode]{{From: b, To: c}, {From: a, To: b}, {From: a, To: c}}
+	if got := g.Edges(); !slices.Equal(got, want) {
+		t.Errorf("Edges() after TopoSort = %v, want %v", got, want)
+	}
+}
+
+func TestGraph_AddAliased(t *testing.T) {
+	g := New[testNode]()
+	db := newTestNode("pkg/services/db")
+	api := newTestNode("pkg/services/api")
+	g.AddAliased(db, "db", "database")
+	// the dependency referenced by its short name resolves to db
+	g.Add(api, newTestNode("db"))
+
+	for _, name := range []string{"pkg/services/db", "db", "database"} {
+		if got, ok := g.Get(name); !ok || got != db {
+			t.Errorf("Get(%q) = %v, %v, want %v, true", name, got, ok, db)
+		}
+	}
+	if g.Count() != 2 {
+		t.Errorf("expected 2 nodes, got %d: %v", g.Count(), g.Nodes())
+	}
+	want := []Edge[testNode]{{From: api, To: db}}
+	if got := g.Edges(); !slices.Equal(got, want) {
+		t.Errorf("Edges() = %v, want %v", got, want)
+	}
+	if deps := g.TransitiveDeps(newTestNode("pkg/services/api")); !slices.Equal(deps, []testNode{db}) {
+		t.Errorf("TransitiveDeps() = %v, want [%v]", deps, db)
+	}
+
+	// aliases never shadow names already taken
+	cache := newTestNode("cache")
+	g.AddAliased(cache, "db", "pkg/services/api", "kv")
+	if got, _ := g.Get("db"); got != db {
+		t.Errorf("Get(db) = %v, want %v", got, db)
+	}
+	if got, _ := g.Get("pkg/services/api"); got != api {
+		t.Errorf("Get(pkg/services/api) = %v, want %v", got, api)
+	}
+	if got, _ := g.Get("kv"); got != cache {
+		t.Errorf("Get(kv) = %v, want %v", got, cache)
+	}
+	if got, ok := g.Clone().Get("database"); !ok || got != db {
+		t.Error("clone should keep nodes retrievable by alias")
+	}
+}
+
+func TestGraph_AddAliasedBeforeNode(t *testing.T) {
+	g := New[testNode]()
+	cache := newTestNode("pkg/cache")
+	db := newTestNode("db")
+	api := newTestNode("api")
+	g.AddAliased(cache, "db")
+	// a node added under the name of an alias takes it over
+	g.Add(db)
+	g.Add(api, newTestNode("db"))
+
+	if got, ok := g.Get("db"); !ok || got != db {
+		t.Errorf("Get(db) = %v, %v, want %v, true", got, ok, db)
+	}
+	if want := []testNode{cache, db, api}; !slices.Equal(g.Nodes(), want) {
+		t.Errorf("Nodes() = %v, want %v", g.Nodes(), want)
+	}
+	want := []Edge[testNode]{{From: api, To: db}}
+	if got := g.Edges(); !slices.Equal(got, want) {
+		t.Errorf("Edges() = %v, want %v", got, want)
+	}
+}
@@ -2,9 +2,48 @@ package graph
 
 import "github.com/xhanio/framingo/pkg/types/common"
 
+// Order is the traversal order of Walk.
+type Order int
+
+const (
+	BFS Order = iota // breadth-first
+	DFS              // depth-first
+)
+
+// Edge is a dependency of From on To.
+type Edge[T common.Named] struct {
+	From T
+	To   T
+}
+
+// Graph is a dependency graph of named nodes. It is safe for concurrent use.
 type Graph[T common.Named] interface {
+	// Add adds node and its dependencies. Nodes are identified by name: a
+	// node whose name is already in the graph refers to the node added under
+	// it first, and a dependency refers to the node it names or aliases.
 	Add(node T, dependencies ...T)
+	// AddAliased adds node like Add and makes it resolvable by aliases too,
+	// e.g. a short name for a node registered under its full path. An alias
+	// that is already taken by the name or an alias of a node, including
+	// node's own name, is ignored. A node added later under the name of an
+	// alias takes the name and the alias is dropped, so aliases never shadow
+	// node names; dependencies resolved through the alias before stay with
+	// the aliased node.
+	AddAliased(node T, aliases ...string)
 	TopoSort() error
 	Nodes() []T
+	// Get returns the node with the given name or alias.
+	Get(name string) (T, bool)
+	Has(name string) bool
 	Count() int
+	Clone() Graph[T]
+	// Walk visits start and its transitive dependencies once each in the given
+	// order, along with their distance from start. Returning false from visit
+	// skips the dependencies of that node.
+	Walk(start T, order Order, visit func(node T, depth int) bool)
+	// TransitiveDeps returns every node that node depends on, directly or not.
+	TransitiveDeps(node T) []T
+	// Edges returns every dependency once, ordered by the dependent as in
+	// Nodes and then by the order the dependencies were added.
+	Edges() []Edge[T]
 }
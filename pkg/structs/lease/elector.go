@@ -0,0 +1,201 @@
+package lease
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/utils/log"
+)
+
+type elector struct {
+	id    string
+	key   string
+	ttl   time.Duration
+	store Store
+
+	log           log.Logger
+	renewInterval time.Duration
+	onElected     []func()
+	onResigned    []func()
+
+	mu     sync.Mutex // serializes campaign steps and guards term
+	term   Lease      // local lease tracking the current term, nil when not leader
+	leader atomic.Bool
+	lost   chan Lease // terms that expired locally
+
+	runMu  sync.Mutex // guards cancel
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewElector returns an Elector campaigning for key in store. A leader holds
+// key for ttl and renews it every ttl/3 by default. Leadership is also tracked
+// by a local lease that expires with the last successful renewal, so a leader
+// cut off from the store steps down no later than the key expires there.
+func NewElector(store Store, key string, ttl time.Duration, opts ...ElectorOption) Elector {
+	e := &elector{
+		id:            uuid.NewString(),
+		key:           key,
+		ttl:           ttl,
+		store:         store,
+		log:           log.Default,
+		renewInterval: ttl / 3,
+		lost:          make(chan Lease, 1),
+	}
+	e.apply(opts...)
+	return e
+}
+
+func (e *elector) ID() string {
+	return e.id
+}
+
+func (e *elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+func (e *elector) Acquire(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.term != nil && e.term.Expired() {
+		e.log.Warnf("%s could not renew %s before it expired", e.id, e.key)
+		if err := e.release(ctx); err != nil {
+			return false, err
+		}
+	}
+	if e.term != nil {
+		return e.renew(ctx)
+	}
+	start := time.Now()
+	ok, err := e.store.CompareAndSwap(ctx, e.key, "", e.id, e.ttl)
+	if err != nil || !ok {
+		return false, err
+	}
+	// The term counts from before the swap, so it never outlives the key. Its
+	// hook runs on the lease's goroutine under the lease's lock, so it only
+	// hands the term over to run.
+	var term Lease
+	term = Restore(LeaseState{ID: e.id, Duration: e.ttl, ExpiresAt: start.Add(e.ttl)}, OnExpired(func() {
+		select {
+		case e.lost <- term:
+		default:
+		}
+	}))
+	e.term = term
+	go term.Start()
+	e.leader.Store(true)
+	e.log.Infof("%s elected as leader of %s", e.id, e.key)
+	for _, fn := range e.onElected {
+		fn()
+	}
+	return true, nil
+}
+
+// renew extends the current term. Callers must hold the lock.
+func (e *elector) renew(ctx context.Context) (bool, error) {
+	start := time.Now()
+	ok, err := e.store.CompareAndSwap(ctx, e.key, e.id, e.id, e.ttl)
+	if err != nil {
+		// keep the term: it expires on its own if the store stays unreachable
+		return true, err
+	}
+	if !ok {
+		e.log.Warnf("%s lost leadership of %s", e.id, e.key)
+		return false, e.release(ctx)
+	}
+	e.term.Renew(start.Add(e.ttl))
+	return true, nil
+}
+
+func (e *elector) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.term == nil {
+		return nil
+	}
+	return e.release(ctx)
+}
+
+// release ends the current term and deletes the key if this elector still
+// holds it, so a term lost locally leaves no stale key behind. Callers must
+// hold the lock.
+func (e *elector) release(ctx context.Context) error {
+	e.stepDown()
+	if _, err := e.store.CompareAndDelete(ctx, e.key, e.id); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+// stepDown ends the current term. Callers must hold the lock.
+func (e *elector) stepDown() {
+	e.term.Cancel()
+	e.term = nil
+	e.leader.Store(false)
+	e.log.Infof("%s resigned as leader of %s", e.id, e.key)
+	for _, fn := range e.onResigned {
+		fn()
+	}
+}
+
+func (e *elector) Start(ctx context.Context) error {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+	if e.cancel != nil {
+		return errors.Conflict.Newf("elector %s is already started", e.id)
+	}
+	ctx, e.cancel = context.WithCancel(ctx)
+	e.wg.Add(1)
+	go e.run(ctx)
+	return nil
+}
+
+func (e *elector) run(ctx context.Context) {
+	defer e.wg.Done()
+	ticker := time.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := e.Acquire(ctx); err != nil && ctx.Err() == nil {
+			e.log.Warnf("%s failed to campaign for %s: %v", e.id, e.key, err)
+		}
+		select {
+		case <-ctx.Done():
+			resignCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.ttl)
+			if err := e.Resign(resignCtx); err != nil {
+				e.log.Warnf("%s failed to release %s: %v", e.id, e.key, err)
+			}
+			cancel()
+			return
+		case term := <-e.lost:
+			e.mu.Lock()
+			if e.term == term {
+				e.log.Warnf("%s could not renew %s before it expired", e.id, e.key)
+				if err := e.release(ctx); err != nil {
+					e.log.Warnf("%s failed to release %s: %v", e.id, e.key, err)
+				}
+			}
+			e.mu.Unlock()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *elector) Stop(wait bool) error {
+	e.runMu.Lock()
+	cancel := e.cancel
+	e.cancel = nil
+	e.runMu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	if wait {
+		e.wg.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package lease
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+)
+
+func TestElector(t *testing.T) {
+	store := NewMemoryStore()
+	var elected, resigned atomic.Int32
+	opts := []ElectorOption{
+		WithRenewInterval(50 * time.Millisecond),
+		OnElected(func() { elected.Add(1) }),
+		OnResigned(func() { resigned.Add(1) }),
+	}
+	e1 := NewElector(store, "leader", 500*time.Millisecond, append(opts, WithHolderID("e1"))...)
+	e2 := NewElector(store, "leader", 500*time.Millisecond, append(opts, WithHolderID("e2"))...)
+
+	require.NoError(t, e1.Start(context.Background()))
+	require.NoError(t, e2.Start(context.Background()))
+	defer e2.Stop(true)
+
+	assert.Eventually(t, func() bool { return e1.IsLeader() || e2.IsLeader() }, time.Second, 10*time.Millisecond)
+	// renewals keep the same leader well past the ttl
+	time.Sleep(time.Second)
+	assert.NotEqual(t, e1.IsLeader(), e2.IsLeader(), "exactly one elector should lead")
+	assert.Equal(t, int32(1), elected.Load())
+
+	leader, follower := e1, e2
+	if e2.IsLeader() {
+		leader, follower = e2, e1
+	}
+	require.NoError(t, leader.Stop(true))
+	assert.False(t, leader.IsLeader())
+	assert.Equal(t, int32(1), resigned.Load())
+	// resigning releases the key, so the follower does not wait out the ttl
+	assert.Eventually(t, follower.IsLeader, 200*time.Millisecond, 10*time.Millisecond)
+}
+
+type unreachableStore struct {
+	Store
+	down atomic.Bool
+}
+
+func (s *unreachableStore) CompareAndSwap(ctx context.Context, key string, old string, value string, ttl time.Duration) (bool, error) {
+	if s.down.Load() {
+		return false, errors.Unavailable.Newf("store is down")
+	}
+	return s.Store.CompareAndSwap(ctx, key, old, value, ttl)
+}
+
+func TestElectorTermExpires(t *testing.T) {
+	store := &unreachableStore{Store: NewMemoryStore()}
+	resigned := make(chan struct{}, 1)
+	e := NewElector(store, "leader", 300*time.Millisecond,
+		WithRenewInterval(50*time.Millisecond),
+		OnResigned(func() { resigned <- struct{}{} }),
+	)
+
+	ok, err := e.Acquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, e.Start(context.Background()))
+	defer e.Stop(true)
+
+	start := time.Now()
+	store.down.Store(true)
+	select {
+	case <-resigned:
+		assert.False(t, e.IsLeader())
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("leader cut off from the store should step down once its term expires")
+	}
+}
+
+// skewedStore keeps keys for ten times the requested ttl, like a store whose
+// clock runs behind the elector's.
+type skewedStore struct {
+	unreachableStore
+}
+
+func (s *skewedStore) CompareAndSwap(ctx context.Context, key string, old string, value string, ttl time.Duration) (bool, error) {
+	return s.unreachableStore.CompareAndSwap(ctx, key, old, value, 10*ttl)
+}
+
+func TestElectorReleasesLostTerm(t *testing.T) {
+	store := &skewedStore{unreachableStore{Store: NewMemoryStore()}}
+	resigned := make(chan struct{}, 1)
+	e := NewElector(store, "leader", 300*time.Millisecond,
+		WithRenewInterval(50*time.Millisecond),
+		OnResigned(func() { resigned <- struct{}{} }),
+	)
+	require.NoError(t, e.Start(context.Background()))
+	defer e.Stop(true)
+	assert.Eventually(t, e.IsLeader, time.Second, 10*time.Millisecond)
+
+	store.down.Store(true)
+	select {
+	case <-resigned:
+	case <-time.After(time.Second):
+		t.Fatal("leader cut off from the store should step down once its term expires")
+	}
+	// the key outlives the term in the store, so stepping down must delete it
+	ok, err := store.Store.CompareAndSwap(context.Background(), "leader", "", "other", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok, "lost term left a stale key behind")
+}
+
+func TestElectorStartStop(t *testing.T) {
+	e := NewElector(NewMemoryStore(), "leader", 300*time.Millisecond)
+	require.NoError(t, e.Start(context.Background()))
+	assert.True(t, errors.Is(e.Start(context.Background()), errors.Conflict))
+	require.NoError(t, e.Stop(true))
+	require.NoError(t, e.Stop(true))
+}
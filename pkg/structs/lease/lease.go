@@ -1,6 +1,8 @@
 package lease
 
 import (
+	"math/rand/v2"
+	"slices"
 	"sync"
 	"time"
 
@@ -15,6 +17,12 @@ const (
 	ActionTypeRenew
 )
 
+// Reasons passed to OnDenied hooks.
+const (
+	DeniedExpired  = "expired"
+	DeniedCanceled = "canceled"
+)
+
 type action struct {
 	Type      uint
 	Duration  time.Duration
@@ -27,22 +35,28 @@ type lease struct {
 	log log.Logger
 
 	duration time.Duration
+	jitter   time.Duration // max random offset applied to the expiry
 	once     bool
 	wall     bool
 
 	sync.RWMutex
-	expired   bool
-	expiresAt time.Time
-	ticker    *time.Ticker
-	actionCh  chan action
-	cancelCh  chan struct{}
-	done      chan struct{} // signal that the loop has exited
+	expired       bool
+	canceled      bool // expired by Cancel rather than by running out
+	expiresAt     time.Time
+	restored      time.Time // persisted expiry to resume from on the next start
+	pending       []action  // actions issued before the loop started
+	cancelPending bool      // Cancel issued before the loop started
+	ticker        *time.Ticker
+	actionCh      chan action
+	cancelCh      chan struct{}
+	done          chan struct{} // signal that the loop has exited
 
 	onCancel  []func()
 	onExpire  []func()
 	onRefresh []func()
 	onExtend  []func()
 	onRenew   []func()
+	onDenied  []func(op, reason string)
 }
 
 func New(id string, duration time.Duration, opts ...LeaseOption) Lease {
@@ -65,13 +79,50 @@ func New(id string, duration time.Duration, opts ...LeaseOption) Lease {
 	return l
 }
 
+// Restore recreates a lease from a snapshot. Once started, the lease counts down
+// toward the persisted expiry instead of a fresh duration, and expires on the
+// first tick if that expiry has already passed.
+func Restore(state LeaseState, opts ...LeaseOption) Lease {
+	l := New(state.ID, state.Duration, opts...).(*lease)
+	l.once = l.once || state.Once
+	l.wall = l.wall || state.Wall
+	l.expiresAt = state.ExpiresAt
+	l.restored = state.ExpiresAt
+	return l
+}
+
 func (l *lease) initialize() {
 	l.expired = false
-	l.expiresAt = time.Now().Add(l.duration)
+	l.canceled = false
+	if !l.restored.IsZero() {
+		l.expiresAt = l.restored
+		l.restored = time.Time{}
+	} else {
+		l.expiresAt = time.Now().Add(l.jittered(l.duration))
+	}
 	l.actionCh = make(chan action, 1)
 	l.cancelCh = make(chan struct{}, 1)
 	l.done = make(chan struct{})
 	l.ticker = time.NewTicker(100 * time.Millisecond)
+	// replay what was issued while the loop was not running yet
+	for _, a := range l.pending {
+		l.handle(a)
+	}
+	l.pending = nil
+	if l.cancelPending {
+		l.cancelPending = false
+		l.cancelCh <- struct{}{}
+	}
+}
+
+// jittered returns d offset by a random amount within [-l.jitter, l.jitter],
+// never going below zero.
+func (l *lease) jittered(d time.Duration) time.Duration {
+	if l.jitter <= 0 {
+		return d
+	}
+	d += time.Duration(rand.Int64N(2*int64(l.jitter)+1)) - l.jitter
+	return max(d, 0)
 }
 
 func (l *lease) finalize() {
@@ -86,16 +137,12 @@ func (l *lease) ID() string {
 }
 
 func (l *lease) Start() {
-	l.RLock()
+	l.Lock()
 	if l.ticker != nil || (l.once && l.expired) {
-		l.RUnlock()
+		l.Unlock()
 		return
 	}
-	l.RUnlock()
-
 	// fmt.Printf("starting %s\n", l.id)
-
-	l.Lock()
 	l.initialize()
 	l.Unlock()
 
@@ -111,6 +158,7 @@ func (l *lease) Start() {
 		case <-l.cancelCh:
 			l.Lock()
 			l.finalize()
+			l.canceled = true
 			for i := range l.onCancel {
 				l.onCancel[i]()
 			}
@@ -118,26 +166,7 @@ func (l *lease) Start() {
 			return
 		case a := <-l.actionCh:
 			l.Lock()
-			switch a.Type {
-			case ActionTypeRefresh:
-				l.expiresAt = time.Now().Add(a.Duration)
-				// l.log.Debugf("%s refreshed to %s", l.id, l.expiresAt.Local().Format("15:04:05.00"))
-				for i := range l.onRefresh {
-					l.onRefresh[i]()
-				}
-			case ActionTypeExtend:
-				l.expiresAt = time.Now().Add(time.Until(l.expiresAt) + a.Duration)
-				// l.log.Debugf("%s extended to %s", l.id, l.expiresAt.Local().Format("15:04:05.00"))
-				for i := range l.onExtend {
-					l.onExtend[i]()
-				}
-			case ActionTypeRenew:
-				l.expiresAt = a.ExpiresAt
-				// l.log.Debugf("%s renewed to %s", l.id, l.expiresAt.Local().Format("15:04:05.00"))
-				for i := range l.onRenew {
-					l.onRenew[i]()
-				}
-			}
+			l.handle(a)
 			l.Unlock()
 		case <-l.ticker.C:
 			l.Lock()
@@ -160,78 +189,114 @@ func (l *lease) Start() {
 	}
 }
 
-func (l *lease) Refresh(duraton time.Duration) bool {
-	l.RLock()
-	if l.expired {
-		l.RUnlock()
-		return false
+// handle applies a to the expiry and fires its hooks. Callers must hold the
+// lock.
+func (l *lease) handle(a action) {
+	switch a.Type {
+	case ActionTypeRefresh:
+		l.expiresAt = time.Now().Add(l.jittered(a.Duration))
+		// l.log.Debugf("%s refreshed to %s", l.id, l.expiresAt.Local().Format("15:04:05.00"))
+		for i := range l.onRefresh {
+			l.onRefresh[i]()
+		}
+	case ActionTypeExtend:
+		l.expiresAt = time.Now().Add(time.Until(l.expiresAt) + a.Duration)
+		// l.log.Debugf("%s extended to %s", l.id, l.expiresAt.Local().Format("15:04:05.00"))
+		for i := range l.onExtend {
+			l.onExtend[i]()
+		}
+	case ActionTypeRenew:
+		l.expiresAt = a.ExpiresAt
+		// l.log.Debugf("%s renewed to %s", l.id, l.expiresAt.Local().Format("15:04:05.00"))
+		for i := range l.onRenew {
+			l.onRenew[i]()
+		}
 	}
-	l.RUnlock()
+}
 
-	select {
-	case l.actionCh <- action{
+func (l *lease) Refresh(duraton time.Duration) bool {
+	return l.act("refresh", action{
 		Type:     ActionTypeRefresh,
 		Duration: duraton,
-	}:
-		return true
-	case <-l.done:
-		// l.log.Debugf("%s refresh failed: lease closed", l.id)
-		return false
-	}
+	})
 }
 
 func (l *lease) Extend(duraton time.Duration) bool {
-	l.RLock()
+	return l.act("extend", action{
+		Type:     ActionTypeExtend,
+		Duration: duraton,
+	})
+}
+
+func (l *lease) Renew(expiresAt time.Time) bool {
+	return l.act("renew", action{
+		Type:      ActionTypeRenew,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// act hands a to the lease loop, or reports op as denied if the lease has
+// ended. Before the loop starts, a is recorded and replayed on Start.
+func (l *lease) act(op string, a action) bool {
+	l.Lock()
 	if l.expired {
-		l.RUnlock()
-		return false
+		l.Unlock()
+		return l.deny(op)
 	}
-	l.RUnlock()
+	if l.ticker == nil {
+		l.pending = append(l.pending, a)
+		l.Unlock()
+		return true
+	}
+	actionCh, done := l.actionCh, l.done
+	l.Unlock()
 
 	select {
-	case l.actionCh <- action{
-		Type:     ActionTypeExtend,
-		Duration: duraton,
-	}:
+	case actionCh <- a:
 		return true
-	case <-l.done:
-		// l.log.Debugf("%s extend failed: lease closed", l.id)
-		return false
+	case <-done:
+		return l.deny(op)
 	}
 }
 
-func (l *lease) Renew(expiresAt time.Time) bool {
+// deny fires the OnDenied hooks for op with the reason the lease ended, and
+// returns false for the denied operation.
+func (l *lease) deny(op string) bool {
 	l.RLock()
-	if l.expired {
-		l.RUnlock()
-		return false
+	reason := DeniedExpired
+	if l.canceled {
+		reason = DeniedCanceled
 	}
+	hooks := slices.Clone(l.onDenied)
 	l.RUnlock()
-
-	select {
-	case l.actionCh <- action{
-		Type:      ActionTypeRenew,
-		ExpiresAt: expiresAt,
-	}:
-		return true
-	case <-l.done:
-		// l.log.Debugf("%s renew failed: lease closed", l.id)
-		return false
+	for _, fn := range hooks {
+		fn(op, reason)
 	}
+	return false
 }
 
+// Cancel expires the lease. Before the loop starts, the cancel is recorded and
+// the lease ends as soon as it is started.
 func (l *lease) Cancel() {
-	l.RLock()
+	l.Lock()
 	if l.expired {
-		l.RUnlock()
+		l.Unlock()
 		return
 	}
-	l.RUnlock()
+	if l.ticker == nil {
+		l.cancelPending = true
+		l.Unlock()
+		return
+	}
+	cancelCh, done := l.cancelCh, l.done
+	l.Unlock()
 
 	select {
-	case l.cancelCh <- struct{}{}:
-	case <-l.done:
+	case cancelCh <- struct{}{}:
+	case <-done:
 		// l.log.Debugf("%s cancel failed: lease closed", l.id)
+	default:
+		// a cancel is already queued for the loop
 	}
 }
 
@@ -247,6 +312,28 @@ func (l *lease) ExpiresAt() time.Time {
 	return l.expiresAt
 }
 
+// Remaining returns the time left until the lease expires, or 0 once expired.
+func (l *lease) Remaining() time.Duration {
+	l.RLock()
+	defer l.RUnlock()
+	if l.expired {
+		return 0
+	}
+	return max(time.Until(l.expiresAt), 0)
+}
+
+func (l *lease) Snapshot() LeaseState {
+	l.RLock()
+	defer l.RUnlock()
+	return LeaseState{
+		ID:        l.id,
+		Duration:  l.duration,
+		ExpiresAt: l.expiresAt,
+		Once:      l.once,
+		Wall:      l.wall,
+	}
+}
+
 func (l *lease) OnExpired(fn func()) {
 	l.Lock()
 	defer l.Unlock()
@@ -276,3 +363,9 @@ func (l *lease) OnRenew(fn func()) {
 	defer l.Unlock()
 	l.onRenew = append(l.onRenew, fn)
 }
+
+func (l *lease) OnDenied(fn func(op, reason string)) {
+	l.Lock()
+	defer l.Unlock()
+	l.onDenied = append(l.onDenied, fn)
+}
@@ -1,6 +1,7 @@
 package lease
 
 import (
+	"encoding/json"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
@@ -282,6 +284,42 @@ func TestLeaseHooks(t *testing.T) {
 	})
 }
 
+func TestLeaseOnDenied(t *testing.T) {
+	type denial struct{ op, reason string }
+	record := func(denials chan<- denial) func(op, reason string) {
+		return func(op, reason string) { denials <- denial{op, reason} }
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		denials := make(chan denial, 3)
+		lease := New("test", 100*time.Millisecond, OnDenied(record(denials)))
+		go lease.Start()
+		require.Eventually(t, lease.Expired, time.Second, 10*time.Millisecond)
+
+		assert.False(t, lease.Refresh(time.Second))
+		assert.False(t, lease.Extend(time.Second))
+		assert.False(t, lease.Renew(time.Now().Add(time.Second)))
+		assert.Equal(t, denial{"refresh", DeniedExpired}, <-denials)
+		assert.Equal(t, denial{"extend", DeniedExpired}, <-denials)
+		assert.Equal(t, denial{"renew", DeniedExpired}, <-denials)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		denials := make(chan denial, 1)
+		lease := New("test", time.Minute)
+		lease.OnDenied(record(denials))
+		go lease.Start()
+		time.Sleep(50 * time.Millisecond) // Let it initialize
+		assert.True(t, lease.Refresh(time.Minute))
+		lease.Cancel()
+		require.Eventually(t, lease.Expired, time.Second, 10*time.Millisecond)
+
+		assert.False(t, lease.Refresh(time.Minute))
+		assert.Equal(t, denial{"refresh", DeniedCanceled}, <-denials)
+		assert.Empty(t, denials, "accepted operations are not reported")
+	})
+}
+
 func TestLeaseOptions(t *testing.T) {
 	t.Run("Once option prevents restart", func(t *testing.T) {
 		var expiredCount atomic.Int32
@@ -375,6 +413,45 @@ func TestLeaseThreadSafety(t *testing.T) {
 }
 
 func TestLeaseEdgeCases(t *testing.T) {
+	t.Run("cancel before start", func(t *testing.T) {
+		canceled := make(chan struct{})
+		lease := New("test", 1*time.Second, OnCancel(func() { close(canceled) }))
+
+		// a cancel racing the goroutine starting the lease must not block
+		lease.Cancel()
+		go lease.Start()
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("cancel before start was lost")
+		}
+		assert.True(t, lease.Expired())
+	})
+
+	t.Run("repeated cancels and actions before start", func(t *testing.T) {
+		var refreshed atomic.Int32
+		canceled := make(chan struct{})
+		lease := New("test", 1*time.Second,
+			OnRefresh(func() { refreshed.Add(1) }),
+			OnCancel(func() { close(canceled) }),
+		)
+
+		// none of these may block while the loop is not running
+		for range 3 {
+			assert.True(t, lease.Refresh(1*time.Second))
+		}
+		lease.Cancel()
+		lease.Cancel()
+		go lease.Start()
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("cancel before start was lost")
+		}
+		assert.Equal(t, int32(3), refreshed.Load())
+		assert.True(t, lease.Expired())
+	})
+
 	t.Run("operations on cancelled lease", func(t *testing.T) {
 		lease := New("test", 1*time.Second)
 
@@ -463,6 +540,78 @@ func TestLeaseExpiresAtAccuracy(t *testing.T) {
 	})
 }
 
+func TestLeaseSnapshotRestore(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		original := New("test", 1*time.Second, Once())
+
+		go original.Start()
+		time.Sleep(50 * time.Millisecond) // Let it initialize
+
+		b, err := json.Marshal(original.Snapshot())
+		assert.NoError(t, err)
+		original.Cancel()
+
+		var state LeaseState
+		assert.NoError(t, json.Unmarshal(b, &state))
+		assert.Equal(t, "test", state.ID)
+		assert.Equal(t, 1*time.Second, state.Duration)
+		assert.True(t, state.Once)
+
+		var expired atomic.Bool
+		restored := Restore(state, OnExpired(func() { expired.Store(true) }))
+		assert.Equal(t, "test", restored.ID())
+		assert.True(t, restored.ExpiresAt().Equal(state.ExpiresAt))
+
+		go restored.Start()
+		time.Sleep(50 * time.Millisecond) // Let it initialize
+
+		// Restored lease keeps counting down toward the persisted expiry
+		assert.True(t, restored.ExpiresAt().Equal(state.ExpiresAt))
+		assert.Equal(t, state, restored.Snapshot())
+		assert.False(t, restored.Expired())
+
+		time.Sleep(time.Until(state.ExpiresAt) + 200*time.Millisecond)
+		assert.True(t, expired.Load())
+		assert.True(t, restored.Expired())
+	})
+
+	t.Run("past expiry fires promptly", func(t *testing.T) {
+		var expired atomic.Bool
+		restored := Restore(LeaseState{
+			ID:        "test",
+			Duration:  1 * time.Minute,
+			ExpiresAt: time.Now().Add(-1 * time.Second),
+		}, OnExpired(func() { expired.Store(true) }))
+
+		assert.False(t, restored.Expired())
+
+		go restored.Start()
+		time.Sleep(200 * time.Millisecond)
+
+		assert.True(t, expired.Load())
+		assert.True(t, restored.Expired())
+	})
+
+	t.Run("restart uses fresh duration", func(t *testing.T) {
+		restored := Restore(LeaseState{
+			ID:        "test",
+			Duration:  1 * time.Second,
+			ExpiresAt: time.Now().Add(-1 * time.Second),
+		})
+
+		go restored.Start()
+		time.Sleep(200 * time.Millisecond)
+		assert.True(t, restored.Expired())
+
+		go restored.Start()
+		time.Sleep(50 * time.Millisecond) // Let it initialize
+
+		assert.False(t, restored.Expired())
+		assert.True(t, restored.ExpiresAt().After(time.Now()))
+		restored.Cancel()
+	})
+}
+
 func BenchmarkLeaseOperations(b *testing.B) {
 	b.Run("New", func(b *testing.B) {
 		b.ResetTimer()
@@ -506,3 +655,60 @@ func BenchmarkLeaseOperations(b *testing.B) {
 		lease.Cancel()
 	})
 }
+
+func TestLeaseExpiryJitter(t *testing.T) {
+	t.Run("spreads expiries across the range", func(t *testing.T) {
+		const (
+			samples  = 2000
+			duration = 10 * time.Second
+			jitter   = 2 * time.Second
+		)
+		l := New("test", duration, WithExpiryJitter(jitter)).(*lease)
+		var sum time.Duration
+		buckets := make([]int, 4) // quarters of [duration-jitter, duration+jitter]
+		for range samples {
+			d := l.jittered(duration)
+			assert.GreaterOrEqual(t, d, duration-jitter)
+			assert.LessOrEqual(t, d, duration+jitter)
+			sum += d
+			b := int((d - (duration - jitter)) * 4 / (2*jitter + 1))
+			buckets[b]++
+		}
+		// roughly uniform: every quarter gets a fair share, and the mean stays centered
+		for i, n := range buckets {
+			assert.Greater(t, n, samples/8, "quarter %d only got %d samples", i, n)
+		}
+		mean := sum / samples
+		assert.InDelta(t, float64(duration), float64(mean), float64(jitter/5))
+	})
+
+	t.Run("expiry and remaining reflect the jitter", func(t *testing.T) {
+		const (
+			duration = 10 * time.Second
+			jitter   = 5 * time.Second
+		)
+		distinct := make(map[time.Duration]struct{})
+		for i := range 10 {
+			l := New("", duration, WithExpiryJitter(jitter))
+			before := time.Now()
+			go l.Start()
+			assert.Eventually(t, func() bool { return !l.ExpiresAt().IsZero() }, time.Second, 5*time.Millisecond)
+			offset := l.ExpiresAt().Sub(before)
+			assert.GreaterOrEqual(t, offset, duration-jitter, "lease %d", i)
+			assert.LessOrEqual(t, offset, duration+jitter+time.Second, "lease %d", i)
+			assert.InDelta(t, float64(time.Until(l.ExpiresAt())), float64(l.Remaining()), float64(100*time.Millisecond))
+			distinct[offset.Round(10*time.Millisecond)] = struct{}{}
+			l.Cancel()
+			assert.Eventually(t, l.Expired, time.Second, 5*time.Millisecond)
+			assert.Equal(t, time.Duration(0), l.Remaining())
+		}
+		assert.Greater(t, len(distinct), 1, "jittered leases should not share an expiry")
+	})
+
+	t.Run("never negative", func(t *testing.T) {
+		l := New("test", 100*time.Millisecond, WithExpiryJitter(time.Second)).(*lease)
+		for range 100 {
+			assert.GreaterOrEqual(t, l.jittered(l.duration), time.Duration(0))
+		}
+	})
+}
@@ -1,6 +1,11 @@
 package lease
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/xhanio/framingo/pkg/types/common"
+)
 
 type Lease interface {
 	ID() string
@@ -11,13 +16,43 @@ type Lease interface {
 	Cancel()
 	Expired() bool
 	ExpiresAt() time.Time
+	Remaining() time.Duration
+	Snapshot() LeaseState
 	Hooks
 }
 
+type LeaseState struct {
+	ID        string        `json:"id"`
+	Duration  time.Duration `json:"duration"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Once      bool          `json:"once"`
+	Wall      bool          `json:"wall"`
+}
+
 type Hooks interface {
 	OnRefresh(fn func())
 	OnExtend(fn func())
 	OnRenew(fn func())
 	OnExpired(fn func())
 	OnCancel(fn func())
+	// OnDenied registers fn to be called when Refresh, Extend or Renew (op
+	// "refresh", "extend" or "renew") is rejected because the lease ended, with
+	// reason DeniedExpired or DeniedCanceled.
+	OnDenied(fn func(op, reason string))
+}
+
+// Elector campaigns for leadership by holding a lease on a key in a shared
+// Store.
+type Elector interface {
+	// ID returns the holder ID written to the store while leading.
+	ID() string
+	// IsLeader reports whether this elector currently holds the lease.
+	IsLeader() bool
+	// Acquire makes a single attempt to take the lease, or renews it when
+	// already leading, and reports whether this elector leads afterwards.
+	Acquire(ctx context.Context) (bool, error)
+	// Resign gives up the lease so another elector can take it right away.
+	Resign(ctx context.Context) error
+	// Start campaigns in the background until Stop, which resigns.
+	common.Daemon
 }
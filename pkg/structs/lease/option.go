@@ -1,6 +1,10 @@
 package lease
 
-import "github.com/xhanio/framingo/pkg/utils/log"
+import (
+	"time"
+
+	"github.com/xhanio/framingo/pkg/utils/log"
+)
 
 type LeaseOption func(*lease)
 
@@ -22,6 +26,16 @@ func UseWallTime() LeaseOption {
 	}
 }
 
+// WithExpiryJitter offsets the expiry set on start and on Refresh by a random
+// amount within [-max, max], so leases sharing a duration don't expire together.
+func WithExpiryJitter(max time.Duration) LeaseOption {
+	return func(l *lease) {
+		if max > 0 {
+			l.jitter = max
+		}
+	}
+}
+
 func WithLogger(logger log.Logger) LeaseOption {
 	return func(l *lease) {
 		l.log = logger
@@ -57,3 +71,56 @@ func OnRenew(fn func()) LeaseOption {
 		l.onRenew = append(l.onRenew, fn)
 	}
 }
+
+// OnDenied is the option form of Hooks.OnDenied.
+func OnDenied(fn func(op, reason string)) LeaseOption {
+	return func(l *lease) {
+		l.onDenied = append(l.onDenied, fn)
+	}
+}
+
+type ElectorOption func(*elector)
+
+func (e *elector) apply(opts ...ElectorOption) {
+	for _, opt := range opts {
+		opt(e)
+	}
+}
+
+// WithHolderID sets the ID written to the store while leading. It defaults to
+// a random UUID.
+func WithHolderID(id string) ElectorOption {
+	return func(e *elector) {
+		if id != "" {
+			e.id = id
+		}
+	}
+}
+
+// WithRenewInterval sets how often the elector campaigns or renews its lease.
+// It must be well below the lease ttl.
+func WithRenewInterval(d time.Duration) ElectorOption {
+	return func(e *elector) {
+		if d > 0 {
+			e.renewInterval = d
+		}
+	}
+}
+
+func WithElectorLogger(logger log.Logger) ElectorOption {
+	return func(e *elector) {
+		e.log = logger
+	}
+}
+
+func OnElected(fn func()) ElectorOption {
+	return func(e *elector) {
+		e.onElected = append(e.onElected, fn)
+	}
+}
+
+func OnResigned(fn func()) ElectorOption {
+	return func(e *elector) {
+		e.onResigned = append(e.onResigned, fn)
+	}
+}
@@ -0,0 +1,61 @@
+// Package redisstore implements lease.Store on Redis, so electors running in
+// different processes can share a lease. It lives in its own package to keep
+// the go-redis dependency out of binaries that only use local leases.
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/structs/lease"
+)
+
+// casScript sets KEYS[1] to ARGV[2] with a ttl of ARGV[3] milliseconds if it
+// currently holds ARGV[1], where an empty ARGV[1] matches a missing key.
+var casScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if (cur == false and ARGV[1] == '') or cur == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// cadScript deletes KEYS[1] if it currently holds ARGV[1].
+var cadScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type store struct {
+	client *redis.Client
+}
+
+// New returns a lease.Store backed by client.
+func New(client *redis.Client) (lease.Store, error) {
+	if client == nil {
+		return nil, errors.Newf("redis client cannot be nil")
+	}
+	return &store{client: client}, nil
+}
+
+func (s *store) CompareAndSwap(ctx context.Context, key string, old string, value string, ttl time.Duration) (bool, error) {
+	n, err := casScript.Run(ctx, s.client, []string{key}, old, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to swap lease key %s", key)
+	}
+	return n == 1, nil
+}
+
+func (s *store) CompareAndDelete(ctx context.Context, key string, old string) (bool, error) {
+	n, err := cadScript.Run(ctx, s.client, []string{key}, old).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to delete lease key %s", key)
+	}
+	return n == 1, nil
+}
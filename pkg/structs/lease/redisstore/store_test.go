@@ -0,0 +1,51 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   15, // use DB 15 for testing
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("skipping redis tests: %v", err)
+	}
+
+	_, err := New(nil)
+	assert.Error(t, err)
+
+	s, err := New(client)
+	require.NoError(t, err)
+	key := "lease:test"
+	client.Del(ctx, key)
+	defer client.Del(context.Background(), key)
+
+	ok, err := s.CompareAndSwap(ctx, key, "", "a", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = s.CompareAndSwap(ctx, key, "", "b", time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok, "only one holder can take a held key")
+	ok, err = s.CompareAndSwap(ctx, key, "a", "a", time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok, "the holder can renew")
+
+	ok, err = s.CompareAndDelete(ctx, key, "b")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = s.CompareAndDelete(ctx, key, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,64 @@
+package lease
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store is a shared key-value store with compare-and-swap semantics, used by
+// Elector to hold a lease across processes.
+type Store interface {
+	// CompareAndSwap sets key to value with the given ttl if its current value
+	// is old, where an empty old matches a missing or expired key. It reports
+	// whether the swap happened.
+	CompareAndSwap(ctx context.Context, key string, old string, value string, ttl time.Duration) (bool, error)
+	// CompareAndDelete deletes key if its current value is old, reporting
+	// whether it did.
+	CompareAndDelete(ctx context.Context, key string, old string) (bool, error)
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore returns a Store local to the process, for tests and single
+// instance deployments.
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// current returns the live value of key. Callers must hold the lock.
+func (s *memoryStore) current(key string) string {
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return ""
+	}
+	return e.value
+}
+
+func (s *memoryStore) CompareAndSwap(_ context.Context, key string, old string, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current(key) != old {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) CompareAndDelete(_ context.Context, key string, old string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current(key) != old {
+		return false, nil
+	}
+	delete(s.entries, key)
+	return true, nil
+}
@@ -115,6 +115,30 @@ func (q *buffered[T]) Read(p []T) (int, error) {
 	return n, err
 }
 
+// Flush implements DoubleBufferQueueG. Written data is swapped in when the read
+// buffer is drained, or appended after the unread data otherwise.
+func (q *buffered[T]) Flush() error {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.cancel == nil {
+		return errors.New("buffered is closed")
+	}
+	if q.writeBuffer.Len() == 0 {
+		return nil
+	}
+	if q.readBuffer.Available() == 0 {
+		q.doSwap()
+		return nil
+	}
+	if _, err := q.readBuffer.Write(q.writeBuffer.Data()); err != nil {
+		return err
+	}
+	q.writeBuffer.Reset()
+	q.written.Broadcast()
+	return nil
+}
+
 // swapBuffers swaps read/write buffers (concurrency safe)
 func (q *buffered[T]) swapBuffers() {
 	q.Lock()
@@ -465,6 +465,70 @@ func TestBufferedWriteTriggersSwap(t *testing.T) {
 	}
 }
 
+func TestBufferedFlush(t *testing.T) {
+	ctx := context.Background()
+	queue := NewDoubleBufferQueue[byte](ctx, 10, 1*time.Second) // long interval
+	defer queue.Close()
+
+	// flushing an empty queue is a no-op
+	if err := queue.Flush(); err != nil {
+		t.Fatalf("Flush on empty queue failed: %v", err)
+	}
+
+	if _, err := queue.Write([]byte("first")); err != nil {
+		t.Fatalf("First write failed: %v", err)
+	}
+	// partially drain the read buffer so the next write stays buffered
+	readBuf := make([]byte, 2)
+	if n, _ := queue.Read(readBuf); n != 2 {
+		t.Fatalf("Expected to read 2 bytes, read %d", n)
+	}
+	if _, err := queue.Write([]byte("second")); err != nil {
+		t.Fatalf("Second write failed: %v", err)
+	}
+
+	if err := queue.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	readBuf = make([]byte, 20)
+	n, err := queue.Read(readBuf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := string(readBuf[:n]); got != "rstsecond" {
+		t.Errorf("Expected %q after flush, got %q", "rstsecond", got)
+	}
+}
+
+func TestBufferedFlushConcurrentWrite(t *testing.T) {
+	ctx := context.Background()
+	queue := NewDoubleBufferQueue[byte](ctx, 10, 1*time.Second)
+	defer queue.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			queue.Write([]byte("x"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			queue.Flush()
+		}
+	}()
+	wg.Wait()
+	queue.Flush()
+
+	readBuf := make([]byte, 200)
+	n, _ := queue.Read(readBuf)
+	if n != 100 {
+		t.Errorf("Expected to read 100 bytes, read %d", n)
+	}
+}
+
 func TestBufferedLargeDataHandling(t *testing.T) {
 	ctx := context.Background()
 	queue := NewDoubleBufferQueue[byte](ctx, 10, 100*time.Millisecond) // small initial size
@@ -9,6 +9,9 @@ var (
 type DoubleBufferQueueG[T any] interface {
 	Write(p []T) (int, error)
 	Read(p []T) (int, error)
+	// Flush makes all written data readable immediately instead of waiting
+	// for the next swap.
+	Flush() error
 	io.Closer
 }
 
@@ -0,0 +1,113 @@
+package staque
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xhanio/errors"
+)
+
+type fileBackend[T any] struct {
+	sync.Mutex
+	dir string
+}
+
+// NewFileBackend returns a Persistable keeping every item as a json file in
+// dir, named after its key. Items are encoded with encoding/json, so only
+// their exported fields survive a restart.
+func NewFileBackend[T any](dir string) Persistable[T] {
+	return &fileBackend[T]{dir: dir}
+}
+
+func (b *fileBackend[T]) path(key string) string {
+	return filepath.Join(b.dir, base64.RawURLEncoding.EncodeToString([]byte(key))+".json")
+}
+
+// Save replaces the file atomically so a crash never leaves it half written.
+func (b *fileBackend[T]) Save(key string, item T) error {
+	b.Lock()
+	defer b.Unlock()
+	data, err := json.Marshal(item)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode item %s", key)
+	}
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return errors.Wrap(err)
+	}
+	path := b.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.Wrap(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *fileBackend[T]) Delete(key string) error {
+	b.Lock()
+	defer b.Unlock()
+	if err := os.Remove(b.path(key)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (b *fileBackend[T]) files() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(b.dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+func (b *fileBackend[T]) Load() ([]T, error) {
+	b.Lock()
+	defer b.Unlock()
+	files, err := b.files()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err)
+		}
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode queued item %s", file)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (b *fileBackend[T]) Clear() error {
+	b.Lock()
+	defer b.Unlock()
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err)
+		}
+	}
+	return nil
+}
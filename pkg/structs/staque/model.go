@@ -17,6 +17,12 @@ func DefaultLessFunc[T PriorityItem](a, b T) bool {
 	return a.Key() < b.Key()
 }
 
+// priorityLess orders items by priority only, leaving ties to the push order,
+// see WithFIFOTieBreak.
+func priorityLess[T PriorityItem](a, b T) bool {
+	return a.GetPriority() < b.GetPriority()
+}
+
 type Stack[T any] interface {
 	Length() int
 	IsEmpty() bool
@@ -41,6 +47,11 @@ type Priority[T PriorityItem] interface {
 	Update(item T) error
 	Remove(item T) (T, bool)
 	Items() []T
+	// Signal returns a channel that receives after items are pushed, so a
+	// consumer can select on it alongside other channels instead of polling.
+	// Pushes between two receives are coalesced into one signal, so drain the
+	// queue on every wakeup.
+	Signal() <-chan struct{}
 }
 
 type Simple[T any] interface {
@@ -49,3 +60,24 @@ type Simple[T any] interface {
 	PopN(n int) ([]T, error)
 	ShiftN(n int) ([]T, error)
 }
+
+// Persistable is a storage backend keeping queued items by key, e.g. on disk
+// or in Redis, so a Persistent queue can recover them after a restart.
+type Persistable[T any] interface {
+	// Save adds or replaces the item stored under key.
+	Save(key string, item T) error
+	// Delete removes the item stored under key, if any.
+	Delete(key string) error
+	Load() ([]T, error)
+	Clear() error
+}
+
+// Persistent is a Priority queue writing its items through to a Persistable
+// backend. Items are stored while queued and deleted once popped, shifted or
+// removed; items with an empty key are never stored.
+type Persistent[T PriorityItem] interface {
+	Priority[T]
+	// Recover queues the items of the backend without storing them again and
+	// returns how many were loaded.
+	Recover() (int, error)
+}
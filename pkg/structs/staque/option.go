@@ -22,6 +22,18 @@ func WithLessFunc[T PriorityItem](lf btree.LessFunc[T]) Option[T] {
 	}
 }
 
+// WithFIFOTieBreak numbers items as they are pushed and breaks ties of the less
+// func by that order instead of by key, so equal-priority items are popped in
+// the order they were pushed, however their keys sort. Combined with the
+// default less func, only priorities are compared before the push order. Pop
+// returns tied items first in first out; Shift, taking from the other end,
+// returns them last in first out.
+func WithFIFOTieBreak[T PriorityItem]() Option[T] {
+	return func(p *priority[T]) {
+		p.fifo = true
+	}
+}
+
 func WithLogger[T PriorityItem](logger log.Logger) Option[T] {
 	return func(p *priority[T]) {
 		p.log = logger
@@ -0,0 +1,108 @@
+package staque
+
+import (
+	"github.com/xhanio/errors"
+)
+
+type persistent[T PriorityItem] struct {
+	*priority[T]
+	backend Persistable[T]
+}
+
+// NewPersistent initializes an empty priority queue backed by backend. It
+// starts empty like NewPriority, call Recover to load the stored items.
+// Failures of the backend on Push, Pop and the like are logged rather than
+// returned, the in-memory queue stays authoritative.
+func NewPersistent[T PriorityItem](backend Persistable[T], opts ...Option[T]) Persistent[T] {
+	return &persistent[T]{
+		priority: NewPriority(opts...).(*priority[T]),
+		backend:  backend,
+	}
+}
+
+func (p *persistent[T]) Recover() (int, error) {
+	items, err := p.backend.Load()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to load queued items")
+	}
+	p.priority.Push(items...)
+	return len(items), nil
+}
+
+func (p *persistent[T]) save(item T) {
+	if key := item.Key(); key != "" {
+		if err := p.backend.Save(key, item); err != nil {
+			p.log.Warnf("failed to persist queued item %s: %s", key, err)
+		}
+	}
+}
+
+func (p *persistent[T]) delete(item T) {
+	if key := item.Key(); key != "" {
+		if err := p.backend.Delete(key); err != nil {
+			p.log.Warnf("failed to delete queued item %s: %s", key, err)
+		}
+	}
+}
+
+func (p *persistent[T]) Push(items ...T) {
+	for _, item := range items {
+		p.save(item)
+	}
+	p.priority.Push(items...)
+}
+
+func (p *persistent[T]) Update(item T) error {
+	if err := p.priority.Update(item); err != nil {
+		return err
+	}
+	p.save(item)
+	return nil
+}
+
+func (p *persistent[T]) Remove(item T) (T, bool) {
+	removed, ok := p.priority.Remove(item)
+	if ok {
+		p.delete(removed)
+	}
+	return removed, ok
+}
+
+func (p *persistent[T]) Pop() (T, error) {
+	item, err := p.priority.Pop()
+	if err == nil {
+		p.delete(item)
+	}
+	return item, err
+}
+
+func (p *persistent[T]) MustPop() T {
+	element, err := p.Pop()
+	if err != nil {
+		return *new(T)
+	}
+	return element
+}
+
+func (p *persistent[T]) Shift() (T, error) {
+	item, err := p.priority.Shift()
+	if err == nil {
+		p.delete(item)
+	}
+	return item, err
+}
+
+func (p *persistent[T]) MustShift() T {
+	element, err := p.Shift()
+	if err != nil {
+		return *new(T)
+	}
+	return element
+}
+
+func (p *persistent[T]) Reset() {
+	p.priority.Reset()
+	if err := p.backend.Clear(); err != nil {
+		p.log.Warnf("failed to clear queued items: %s", err)
+	}
+}
@@ -0,0 +1,85 @@
+package staque
+
+import (
+	"testing"
+)
+
+type testPersistedItem struct {
+	ID       string `json:"id"`
+	Priority int    `json:"priority"`
+}
+
+func (t *testPersistedItem) Key() string {
+	return t.ID
+}
+
+func (t *testPersistedItem) GetPriority() int {
+	return t.Priority
+}
+
+func (t *testPersistedItem) SetPriority(priority int) {
+	t.Priority = priority
+}
+
+func TestPersistentRecover(t *testing.T) {
+	dir := t.TempDir()
+	pq := NewPersistent(NewFileBackend[*testPersistedItem](dir))
+	pq.Push(
+		&testPersistedItem{ID: "a", Priority: 1},
+		&testPersistedItem{ID: "b/c", Priority: 3},
+		&testPersistedItem{ID: "d", Priority: 2},
+		&testPersistedItem{ID: "", Priority: 9}, // never stored
+	)
+	if item, _ := pq.Pop(); item.ID != "" {
+		t.Fatalf("expected the unkeyed item first, got %s", item.ID)
+	}
+	if item, _ := pq.Pop(); item.ID != "b/c" {
+		t.Fatalf("expected b/c, got %s", item.ID)
+	}
+	if err := pq.Update(&testPersistedItem{ID: "a", Priority: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new queue on the same directory simulates a restart
+	restarted := NewPersistent(NewFileBackend[*testPersistedItem](dir))
+	if !restarted.IsEmpty() {
+		t.Fatal("expected the queue to start empty before Recover")
+	}
+	n, err := restarted.Recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || restarted.Length() != 2 {
+		t.Fatalf("expected 2 recovered items, got %d", n)
+	}
+	item, err := restarted.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != "a" || item.Priority != 5 {
+		t.Fatalf("expected the updated item a, got %+v", item)
+	}
+
+	restarted.Reset()
+	n, err = NewPersistent(NewFileBackend[*testPersistedItem](dir)).Recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("expected no items after Reset, got %d", n)
+	}
+}
+
+func TestFileBackendMissingDir(t *testing.T) {
+	b := NewFileBackend[*testPersistedItem](t.TempDir() + "/missing")
+	items, err := b.Load()
+	if err != nil || len(items) != 0 {
+		t.Fatalf("expected no items, got %v %v", items, err)
+	}
+	if err := b.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Clear(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -14,25 +14,56 @@ type priority[T PriorityItem] struct {
 
 	sync.RWMutex
 	items    map[string]T
-	lf       btree.LessFunc[T]
+	lf       btree.LessFunc[T] // nil until applied for DefaultLessFunc
+	fifo     bool
+	seq      map[string]uint64 // push order of the items by key, see WithFIFOTieBreak
+	next     uint64
 	tree     *btree.BTreeG[T]
 	empty    *sync.Cond
 	blocking bool
+	signal   chan struct{}
 }
 
 // New initializes an empty priority queue.
 func NewPriority[T PriorityItem](opts ...Option[T]) Priority[T] {
 	p := &priority[T]{
-		log:   log.Default,
-		items: make(map[string]T),
-		lf:    DefaultLessFunc[T],
+		log:    log.Default,
+		items:  make(map[string]T),
+		signal: make(chan struct{}, 1), // buffered so pushing never waits for a consumer
 	}
 	p.apply(opts...)
 	p.empty = sync.NewCond(&p.RWMutex)
-	p.tree = btree.NewG(2, p.lf)
+	p.tree = btree.NewG(2, p.lessFunc())
 	return p
 }
 
+// lessFunc returns the ordering of the tree. With WithFIFOTieBreak, ties of
+// the less func are broken by push order, the earliest pushed item ranking
+// highest so Pop returns it first. The tree only calls it under the lock,
+// which guards seq.
+func (p *priority[T]) lessFunc() btree.LessFunc[T] {
+	if !p.fifo {
+		if p.lf == nil {
+			return DefaultLessFunc[T]
+		}
+		return p.lf
+	}
+	lf := p.lf
+	if lf == nil {
+		lf = priorityLess[T]
+	}
+	p.seq = make(map[string]uint64)
+	return func(a, b T) bool {
+		if lf(a, b) {
+			return true
+		}
+		if lf(b, a) {
+			return false
+		}
+		return p.seq[a.Key()] > p.seq[b.Key()]
+	}
+}
+
 func (p *priority[T]) IsEmpty() bool {
 	p.RLock()
 	defer p.RUnlock()
@@ -54,14 +85,26 @@ func (p *priority[T]) Push(items ...T) {
 	for _, item := range items {
 		if _, ok := p.items[item.Key()]; !ok {
 			p.items[item.Key()] = item
+			if p.fifo {
+				p.next++
+				p.seq[item.Key()] = p.next
+			}
 			p.tree.ReplaceOrInsert(item)
 		}
 	}
 	if len(p.items) > 0 {
 		p.empty.Signal()
+		select {
+		case p.signal <- struct{}{}:
+		default:
+		}
 	}
 }
 
+func (p *priority[T]) Signal() <-chan struct{} {
+	return p.signal
+}
+
 func (p *priority[T]) Update(item T) error {
 	p.Lock()
 	defer p.Unlock()
@@ -83,7 +126,7 @@ func (p *priority[T]) Remove(item T) (T, bool) {
 	}
 	deleted, found := p.tree.Delete(i)
 	if found {
-		delete(p.items, item.Key())
+		p.forget(item.Key())
 	}
 	if ok != found {
 		panic(errors.Newf("inconsistent queue length: items %d tree %d", len(p.items), p.tree.Len()))
@@ -91,6 +134,14 @@ func (p *priority[T]) Remove(item T) (T, bool) {
 	return deleted, found
 }
 
+// forget drops the item with key once it left the tree.
+func (p *priority[T]) forget(key string) {
+	delete(p.items, key)
+	if p.fifo {
+		delete(p.seq, key)
+	}
+}
+
 func (p *priority[T]) Pop() (T, error) {
 	p.Lock()
 	defer p.Unlock()
@@ -101,7 +152,7 @@ func (p *priority[T]) Pop() (T, error) {
 		}
 		return *new(T), errors.Newf("failed to pop element: the queue is empty")
 	}
-	delete(p.items, item.Key())
+	p.forget(item.Key())
 	return item, nil
 }
 
@@ -123,7 +174,7 @@ func (p *priority[T]) Shift() (T, error) {
 		}
 		return *new(T), errors.Newf("failed to pop element: the queue is empty")
 	}
-	delete(p.items, item.Key())
+	p.forget(item.Key())
 	return item, nil
 }
 
@@ -151,4 +202,7 @@ func (p *priority[T]) Reset() {
 	defer p.Unlock()
 	p.tree.Clear(false)
 	p.items = make(map[string]T)
+	if p.fifo {
+		p.seq = make(map[string]uint64)
+	}
 }
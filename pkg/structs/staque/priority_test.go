@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 )
 
 type testPriorityItem struct {
@@ -280,3 +281,90 @@ func TestWithLessFuncOption(t *testing.T) {
 		t.Errorf("Expected priority 10, got %d", popped.GetPriority())
 	}
 }
+
+func TestFIFOTieBreak(t *testing.T) {
+	pq := NewPriority(WithFIFOTieBreak[*testPriorityItem]())
+
+	// keys sort against the push order
+	keys := []string{"d", "b", "e", "a", "c"}
+	for _, key := range keys {
+		pq.Push(&testPriorityItem{key: key, priority: 1})
+	}
+	pq.Push(&testPriorityItem{key: "urgent", priority: 5})
+	pq.Push(&testPriorityItem{key: "z", priority: 1})
+
+	// a removed item gives up its place
+	if _, ok := pq.Remove(&testPriorityItem{key: "e"}); !ok {
+		t.Fatal("failed to remove e")
+	}
+	pq.Push(&testPriorityItem{key: "e", priority: 1})
+
+	expected := []string{"urgent", "d", "b", "a", "c", "z", "e"}
+	for i, key := range expected {
+		item, err := pq.Pop()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if item.Key() != key {
+			t.Errorf("pop %d: expected %s, got %s", i, key, item.Key())
+		}
+	}
+	if !pq.IsEmpty() {
+		t.Error("queue should be empty")
+	}
+
+	// without the option equal priorities pop by key
+	pq = NewPriority[*testPriorityItem]()
+	for _, key := range keys {
+		pq.Push(&testPriorityItem{key: key, priority: 1})
+	}
+	if item := pq.MustPop(); item.Key() != "e" {
+		t.Errorf("expected e, got %s", item.Key())
+	}
+}
+
+func TestPrioritySignal(t *testing.T) {
+	pq := NewPriority[*testPriorityItem]()
+	select {
+	case <-pq.Signal():
+		t.Fatal("empty queue should not be signalled")
+	default:
+	}
+
+	// pushing never blocks, even when nobody listens
+	for i := 0; i < 10; i++ {
+		pq.Push(&testPriorityItem{key: fmt.Sprintf("item%d", i)})
+	}
+	<-pq.Signal()
+	for !pq.IsEmpty() {
+		pq.MustShift()
+	}
+
+	// a push wakes a worker selecting on the signal and a cancel channel
+	cancel := make(chan struct{})
+	got := make(chan string)
+	go func() {
+		for {
+			select {
+			case <-pq.Signal():
+				for !pq.IsEmpty() {
+					got <- pq.MustShift().Key()
+				}
+			case <-cancel:
+				close(got)
+				return
+			}
+		}
+	}()
+	pq.Push(&testPriorityItem{key: "wake"})
+	select {
+	case key := <-got:
+		if key != "wake" {
+			t.Errorf("Expected item wake, got %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("push did not wake the worker")
+	}
+	close(cancel)
+	<-got
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/reflectutil"
+)
+
+// BindAndValidate binds the request of c into dst and checks dst against its
+// validate tags (see reflectutil.Validate). Malformed requests and constraint
+// violations are reported as BadRequest errors, the latter carrying a detail
+// per offending field, named after its json tag, which WrapError renders into
+// the error body.
+func BindAndValidate(c echo.Context, dst any) error {
+	if err := c.Bind(dst); err != nil {
+		var he *echo.HTTPError
+		if stderrors.As(err, &he) {
+			return errors.BadRequest.Newf("invalid request: %s", fmt.Sprint(he.Message))
+		}
+		return errors.BadRequest.Wrapf(err, "invalid request")
+	}
+	// malformed validate tags are a server side bug and keep their category
+	return reflectutil.ValidateTagged(dst, "json")
+}
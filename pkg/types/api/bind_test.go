@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createUser struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,regex=^[^@]+@[^@]+$"`
+	Age   int    `json:"age" validate:"max=150"`
+}
+
+func TestBindAndValidate(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		body := WrapError(err, c)
+		_ = c.JSON(body.Status, body)
+	}
+	e.POST("/users", func(c echo.Context) error {
+		var body createUser
+		if err := BindAndValidate(c, &body); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, body)
+	})
+	send := func(payload string) (*httptest.ResponseRecorder, *ErrorBody) {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var body ErrorBody
+		if rec.Code != http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		}
+		return rec, &body
+	}
+
+	rec, _ := send(`{"name":"alice","email":"alice@example.com","age":30}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// missing required field
+	rec, body := send(`{"email":"alice@example.com","age":30}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "BadRequest", body.Kind)
+	assert.Equal(t, "name is required", body.Details["name"])
+	assert.Len(t, body.Details, 1)
+
+	// every violation is reported
+	rec, body = send(`{"email":"alice","age":200}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body.Details, "name")
+	assert.Contains(t, body.Details, "email")
+	assert.Contains(t, body.Details, "age")
+
+	// malformed body
+	rec, body = send(`{"name":`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "BadRequest", body.Kind)
+}
@@ -17,6 +17,7 @@ const (
 	ContextKeyTrace        = common.ContextKeyTrace
 	ContextKeyDB           = common.ContextKeyDB
 	ContextKeyLogger       = common.ContextKeyLogger
+	ContextKeyClientIP     = common.ContextKeyClientIP
 
 	CookiesKeySession = "JSESSIONID"
 
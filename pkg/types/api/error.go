@@ -2,13 +2,18 @@ package api
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 )
 
 const ErrorSourceUnknown = "Unknown"
@@ -52,12 +57,43 @@ func (e *ErrorBody) Format(f fmt.State, c rune) {
 	}
 }
 
+// MarshalXML renders the error as an <error> element. Details are emitted as
+// <detail key="..."> children sorted by key since encoding/xml cannot marshal maps.
+func (e *ErrorBody) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type detail struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	body := struct {
+		Source  string   `xml:"source,omitempty"`
+		Status  int      `xml:"status,omitempty"`
+		Code    string   `xml:"code,omitempty"`
+		Kind    string   `xml:"kind,omitempty"`
+		Message string   `xml:"message,omitempty"`
+		Details []detail `xml:"details>detail,omitempty"`
+	}{
+		Source:  e.Source,
+		Status:  e.Status,
+		Code:    e.Code,
+		Kind:    e.Kind,
+		Message: e.Message,
+	}
+	keys := make([]string, 0, len(e.Details))
+	for k := range e.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		body.Details = append(body.Details, detail{Key: k, Value: e.Details[k]})
+	}
+	start.Name = xml.Name{Local: "error"}
+	return enc.EncodeElement(body, start)
+}
+
 func WrapError(err error, c echo.Context) *ErrorBody {
 	switch err {
-	case context.Canceled:
-		err = errors.Cancaled.Wrap(err)
-	case context.DeadlineExceeded:
-		err = errors.DeadlineExceeded.Wrap(err)
+	case context.Canceled, context.DeadlineExceeded:
+		err = errutil.Wrap(err)
 	}
 	switch e := err.(type) {
 	case *ErrorBody:
@@ -79,13 +115,17 @@ func WrapError(err error, c echo.Context) *ErrorBody {
 		}
 	case errors.Error:
 		status := e.Category().StatusCode()
-		code, details := e.Code()
+		code, details := errutil.CodeOf(e)
+		msg := errutil.MessageOf(e) // only expose the latest level error message
+		if localized, ok := errutil.Localize(e, acceptLanguages(c)...); ok {
+			msg = localized
+		}
 		return &ErrorBody{
 			Origin:  e,
 			Status:  status,
 			Code:    code,
 			Kind:    e.Category().Error(),
-			Message: e.Message(), // only expose the latest level error message
+			Message: msg,
 			Details: details,
 		}
 	case errors.Category:
@@ -118,6 +158,53 @@ func StatusCode(err error) int {
 	case errors.Category:
 		return e.StatusCode()
 	default:
-		return http.StatusInternalServerError
+		// classifies context errors, anything else is Internal
+		return errutil.CategoryOf(errutil.Wrap(err)).StatusCode()
+	}
+}
+
+// acceptLanguages returns the language tags of the Accept-Language header of
+// the request, most preferred first. A regional tag such as "de-AT" is
+// followed by its base language "de" unless that is listed explicitly.
+func acceptLanguages(c echo.Context) []string {
+	if c == nil {
+		return nil
+	}
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(c.Request().Header.Get("Accept-Language"), ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, tag{lang: lang, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	listed := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		listed[strings.ToLower(t.lang)] = true
+	}
+	var langs []string
+	for _, t := range tags {
+		langs = append(langs, t.lang)
+		if base, _, ok := strings.Cut(t.lang, "-"); ok && !listed[strings.ToLower(base)] {
+			langs = append(langs, base)
+			listed[strings.ToLower(base)] = true
+		}
 	}
+	return langs
 }
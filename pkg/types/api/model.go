@@ -19,6 +19,14 @@ type Middleware interface {
 	Func(echo.HandlerFunc) echo.HandlerFunc
 }
 
+// OrderedMiddleware is a Middleware with an explicit position in a handler's
+// chain. Lower orders run first (outermost); middlewares without an order
+// count as 0, and equal orders keep their router.yaml declaration order.
+type OrderedMiddleware interface {
+	Middleware
+	Order() int
+}
+
 type Router interface {
 	common.Service
 	Config() []byte
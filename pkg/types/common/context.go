@@ -8,9 +8,11 @@ const (
 	ContextKeyCredential = "_credential"
 	ContextKeySession    = "_session"
 	ContextKeyNamespace  = "_namespace"
+	ContextKeyTenant     = "_tenant"
 	ContextKeyDB         = "_db"
 	ContextKeyTX         = "_tx"
 	ContextKeyLogger     = "_logger"
 	ContextKeyTrace      = "_trace"
 	ContextKeyConfig     = "_config"
+	ContextKeyClientIP   = "_client_ip"
 )
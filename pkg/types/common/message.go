@@ -8,6 +8,13 @@ type Message interface {
 	Kind() string
 }
 
+// Versioned is implemented by messages whose schema evolves. The pubsub
+// drivers record the version with every message, so subscribers can skip or
+// migrate versions they do not understand. Messages without it are version 0.
+type Versioned interface {
+	Version() int
+}
+
 type MessageSender interface {
 	Service
 	SendMessage(ctx context.Context, from Named, msg Message)
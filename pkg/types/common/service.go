@@ -10,6 +10,14 @@ type Service interface {
 	Dependencies() []Service
 }
 
+// OptionallyDependent is implemented by services that should start after
+// others only when those are available. Optional dependencies order startup
+// like Dependencies() does, but nil entries are skipped and they never block
+// the initialization of the service.
+type OptionallyDependent interface {
+	OptionalDependencies() []Service
+}
+
 type Daemon interface {
 	Start(ctx context.Context) error
 	Stop(wait bool) error
@@ -6,4 +6,16 @@ type PubsubMessage struct {
 	Topic   string `json:"topic"`
 	Kind    string `json:"kind"`
 	Payload any    `json:"payload"`
+	Version int    `json:"version,omitempty"` // schema version of the payload, see common.Versioned
+}
+
+// PubsubTopicMetrics counts the traffic of a published topic since the driver
+// started. Deliveries are counted per local subscriber, so a message fanned out
+// to three subscribers adds three. Errors count deliveries dropped because a
+// subscriber was not draining and failed cross-instance hops.
+type PubsubTopicMetrics struct {
+	Topic      string `json:"topic"`
+	Publishes  uint64 `json:"publishes"`
+	Deliveries uint64 `json:"deliveries"`
+	Errors     uint64 `json:"errors"`
 }
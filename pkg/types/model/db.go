@@ -20,4 +20,26 @@ type Database interface {
 	Reload() error
 	// Transaction executes fn within a database transaction.
 	Transaction(ctx context.Context, fn func(tctx context.Context) error, opts ...*sql.TxOptions) error
+	// Upsert inserts value, updating updateColumns on rows that conflict on conflictColumns.
+	Upsert(ctx context.Context, value any, conflictColumns []string, updateColumns []string) error
+	// Seed idempotently inserts fixtures, restoring soft-deleted ones.
+	Seed(ctx context.Context, fixtures ...any) error
+	// Reset deletes all rows, then seeds fixtures.
+	Reset(fixtures ...any) error
+	// RegisterScope registers a named gorm scope for WithScopes.
+	RegisterScope(name string, scope func(*gorm.DB) *gorm.DB) error
+	// WithScopes returns a session with the named scopes applied.
+	WithScopes(ctx context.Context, names ...string) (*gorm.DB, error)
+	// Tables lists the tables of the connected database.
+	Tables() ([]string, error)
+	// Columns describes the columns of table in declaration order.
+	Columns(table string) ([]ColumnInfo, error)
+}
+
+// ColumnInfo is the dialect-independent description of a table column.
+type ColumnInfo struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"` // lowercased type as declared by the database
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primary_key"`
 }
@@ -2,6 +2,7 @@ package certutil
 
 import (
 	"crypto"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
@@ -9,6 +10,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/xhanio/errors"
 )
@@ -23,6 +25,8 @@ type bundle struct {
 	key  crypto.PrivateKey
 
 	tc tls.Certificate
+
+	ocspValidity time.Duration // see WithOCSPResponseValidity, passed on to the cas it signs
 }
 
 func newCABundle(cn string) (*bundle, error) {
@@ -143,6 +147,11 @@ func newBundleFromBytes(certBytes, caBytes, keyBytes []byte, password string) (*
 
 func (b *bundle) init() error {
 	if b.cert != nil {
+		// emit the pool in handshake order when it forms a proper chain, a pool
+		// holding unrelated trust anchors is kept as is
+		if chain, err := b.Chain(); err == nil {
+			b.pool = chain[1:]
+		}
 		b.certPEM = append(b.certPEM, pem.EncodeToMemory(&pem.Block{
 			Type:  "CERTIFICATE",
 			Bytes: b.cert.Raw,
@@ -210,6 +219,15 @@ func (b *bundle) CertPEM() []byte {
 	return b.certPEM
 }
 
+// Chain orders the leaf and the pool into an issuer chain, leaf first and root
+// last. It fails if any cert in the pool cannot be placed on the chain.
+func (b *bundle) Chain() ([]*x509.Certificate, error) {
+	if b.cert == nil {
+		return nil, errors.Newf("failed to build chain: cert is empty")
+	}
+	return buildChain(b.cert, b.pool)
+}
+
 func (b *bundle) CertTLS() tls.Certificate {
 	return b.tc
 }
@@ -242,8 +260,9 @@ func (b *bundle) SignCA(req *CARequest) (CABundle, error) {
 		return nil, errors.Wrap(err)
 	}
 	result := &bundle{
-		cert: cert,
-		key:  key,
+		cert:         cert,
+		key:          key,
+		ocspValidity: b.ocspValidity,
 	}
 	if req.KeepChain {
 		result.pool = append(result.pool, b.cert)
@@ -310,6 +329,49 @@ func (b *bundle) SignClient(req *ClientRequest) (CertBundle, error) {
 	return result, nil
 }
 
+// RotateCA replaces the ca key with newKey, or a generated one if nil. It
+// returns the new self-signed ca and the new ca cross-signed by the current
+// one, which carries the current ca in its chain. Serving the cross-signed
+// cert as an intermediate lets peers that only trust the current ca verify
+// certs issued by the new one until they trust it directly.
+func (b *bundle) RotateCA(newKey *rsa.PrivateKey) (CABundle, CABundle, error) {
+	if b.cert == nil {
+		return nil, nil, errors.Newf("unable to rotate ca: cert is empty")
+	}
+	if !b.cert.IsCA {
+		return nil, nil, errors.Newf("unable to rotate ca: bundle is not a ca")
+	}
+	if newKey == nil {
+		key, err := generateKey()
+		if err != nil {
+			return nil, nil, errors.Wrap(err)
+		}
+		newKey = key
+	}
+	cert, cross, err := rotateCA(newKey, b)
+	if err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	ca := &bundle{
+		cert:         cert,
+		key:          newKey,
+		ocspValidity: b.ocspValidity,
+	}
+	if err := ca.initTLS(); err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	crossCA := &bundle{
+		cert:         cross,
+		key:          newKey,
+		pool:         append([]*x509.Certificate{b.cert}, b.pool...),
+		ocspValidity: b.ocspValidity,
+	}
+	if err := crossCA.initTLS(); err != nil {
+		return nil, nil, errors.Wrap(err)
+	}
+	return ca, crossCA, nil
+}
+
 func (b *bundle) Dump(certFile, keyFile string) error {
 	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
 		return errors.Wrap(err)
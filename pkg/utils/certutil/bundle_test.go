@@ -1,15 +1,24 @@
 package certutil
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/xhanio/errors"
 )
 
 func TestBundle(t *testing.T) {
@@ -102,6 +111,327 @@ func TestBundle(t *testing.T) {
 	}
 }
 
+func TestChain(t *testing.T) {
+	root, err := New(WithCommonName("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inter1, err := root.SignCA(&CARequest{CommonName: "inter1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inter2, err := inter1.SignCA(&CARequest{CommonName: "inter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := inter2.SignServer(&ServerRequest{CommonName: "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// concatenated pem with the intermediates out of order
+	var concat []byte
+	for _, b := range []CertBundle{server, root, inter1, inter2} {
+		concat = append(concat, b.CertPEM()...)
+	}
+	b, err := newBundleFromBytes(concat, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := b.Chain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []CertBundle{server, inter2, inter1, root}
+	if len(chain) != len(expected) {
+		t.Fatalf("expected chain of %d certs, got %d", len(expected), len(chain))
+	}
+	var ordered []byte
+	for i, e := range expected {
+		if !chain[i].Equal(e.Cert()) {
+			t.Fatalf("expected %s at position %d, got %s", e.Cert().Subject.CommonName, i, chain[i].Subject.CommonName)
+		}
+		ordered = append(ordered, e.CertPEM()...)
+	}
+	if !bytes.Equal(b.CertPEM(), ordered) {
+		t.Fatal("cert pem is not emitted in chain order")
+	}
+
+	// missing inter1 breaks the chain between inter2 and root
+	var broken []byte
+	for _, b := range []CertBundle{server, root, inter2} {
+		broken = append(broken, b.CertPEM()...)
+	}
+	b, err = newBundleFromBytes(broken, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Chain(); err == nil {
+		t.Fatal("expected broken chain error")
+	}
+}
+
+func TestRotateCA(t *testing.T) {
+	old, err := New(WithCommonName("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldServer, err := old.SignServer(&ServerRequest{CommonName: "old.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, cross, err := old.RotateCA(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.Cert().Subject.CommonName != "root" || cross.Cert().Subject.CommonName != "root" {
+		t.Fatal("rotated ca should keep the subject")
+	}
+	newServer, err := ca.SignServer(&ServerRequest{CommonName: "new.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(leaf CertBundle, roots []CertBundle, intermediates ...CertBundle) error {
+		opts := x509.VerifyOptions{
+			Roots:         x509.NewCertPool(),
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, r := range roots {
+			opts.Roots.AddCert(r.Cert())
+		}
+		for _, i := range intermediates {
+			opts.Intermediates.AddCert(i.Cert())
+		}
+		_, err := leaf.Cert().Verify(opts)
+		return err
+	}
+	// peers that only trust the old ca verify new certs through the cross-signed cert
+	if err := verify(newServer, []CertBundle{old}, cross); err != nil {
+		t.Fatalf("new cert should validate against the old ca via the cross-signed cert: %v", err)
+	}
+	if err := verify(newServer, []CertBundle{old}); err == nil {
+		t.Fatal("new cert should not validate against the old ca without the cross-signed cert")
+	}
+	// peers that already trust the new ca
+	if err := verify(newServer, []CertBundle{ca}); err != nil {
+		t.Fatalf("new cert should validate against the new ca: %v", err)
+	}
+	// during the overlap window both generations validate against both cas
+	for _, leaf := range []CertBundle{oldServer, newServer} {
+		if err := verify(leaf, []CertBundle{old, ca}, cross); err != nil {
+			t.Fatalf("%s should validate during the overlap: %v", leaf.Cert().Subject.CommonName, err)
+		}
+	}
+	// the cross-signed bundle carries the old ca in its chain
+	if chain, err := cross.Chain(); err != nil || len(chain) != 2 || !chain[1].Equal(old.Cert()) {
+		t.Fatalf("cross-signed chain should end at the old ca: %v", err)
+	}
+
+	if _, _, err := oldServer.(CABundle).RotateCA(nil); err == nil {
+		t.Fatal("expected error rotating a non-ca bundle")
+	}
+	certOnly, err := newBundleFromBytes(old.CertPEM(), nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := certOnly.RotateCA(nil); err == nil {
+		t.Fatal("expected error rotating a ca without key")
+	}
+}
+
+func TestSignRequestValidation(t *testing.T) {
+	ca, err := New(WithCommonName("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ca.SignServer(&ServerRequest{}); !errors.Is(err, errors.BadRequest) {
+		t.Fatalf("expected bad request for server without identity, got %v", err)
+	}
+	if _, err := ca.SignClient(&ClientRequest{}); !errors.Is(err, errors.BadRequest) {
+		t.Fatalf("expected bad request for client without common name, got %v", err)
+	}
+
+	tests := []struct {
+		req      *ServerRequest
+		expected []string
+	}{
+		{&ServerRequest{CommonName: "example.com"}, []string{"example.com"}},
+		{&ServerRequest{CommonName: "localhost", DNSNames: []string{"api.local"}}, []string{"api.local", "localhost"}},
+		{&ServerRequest{CommonName: "Example.com", DNSNames: []string{"example.com"}}, []string{"example.com"}},
+		{&ServerRequest{CommonName: "*.example.com"}, []string{"*.example.com"}},
+		{&ServerRequest{CommonName: "127.0.0.1", IPs: []net.IP{net.ParseIP("127.0.0.1")}}, nil},
+		{&ServerRequest{CommonName: "my server", DNSNames: []string{"api.local"}}, []string{"api.local"}},
+	}
+	for _, tt := range tests {
+		b, err := ca.SignServer(tt.req)
+		if err != nil {
+			t.Fatalf("failed to sign %q: %v", tt.req.CommonName, err)
+		}
+		if got := b.Cert().DNSNames; !slices.Equal(got, tt.expected) {
+			t.Errorf("common name %q: expected dns names %v, got %v", tt.req.CommonName, tt.expected, got)
+		}
+	}
+}
+
+func TestPathLen(t *testing.T) {
+	root, err := New(WithCommonName("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, zero, unset := 1, 0, -1
+	inter1, err := root.SignCA(&CARequest{CommonName: "inter1", MaxPathLen: &one, KeepChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := inter1.Cert(); c.MaxPathLen != 1 || c.MaxPathLenZero {
+		t.Fatalf("expected path length 1, got %d", c.MaxPathLen)
+	}
+	// deeper than inter1 allows
+	if _, err := inter1.SignCA(&CARequest{CommonName: "inter2", MaxPathLen: &one}); !errors.Is(err, errors.BadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	// an unset path length takes what inter1 allows
+	inter2, err := inter1.SignCA(&CARequest{CommonName: "inter2", KeepChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := inter2.Cert(); c.MaxPathLen != 0 || !c.MaxPathLenZero {
+		t.Fatalf("expected path length 0, got %d", c.MaxPathLen)
+	}
+	// path length 0 forbids further cas, leaf certs are still fine
+	if _, err := inter2.SignCA(&CARequest{CommonName: "inter3", MaxPathLen: &unset}); !errors.Is(err, errors.Forbidden) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	server, err := inter2.SignServer(&ServerRequest{CommonName: "server", KeepChain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert())
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(inter1.Cert())
+	intermediates.AddCert(inter2.Cert())
+	if _, err := server.Cert().Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a ca without constraint signs cas without constraint unless asked for
+	unlimited, err := root.SignCA(&CARequest{CommonName: "unlimited"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := unlimited.Cert(); c.MaxPathLen != -1 {
+		t.Fatalf("expected no path length constraint, got %d", c.MaxPathLen)
+	}
+	leafOnly, err := root.SignCA(&CARequest{CommonName: "leaf-only", MaxPathLen: &zero})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := leafOnly.Cert(); c.MaxPathLen != 0 || !c.MaxPathLenZero {
+		t.Fatalf("expected path length 0, got %d", c.MaxPathLen)
+	}
+
+	// rotating a ca keeps its path length constraint
+	rotated, cross, err := inter1.RotateCA(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*x509.Certificate{rotated.Cert(), cross.Cert()} {
+		if c.MaxPathLen != 1 || c.MaxPathLenZero {
+			t.Fatalf("expected rotated path length 1, got %d", c.MaxPathLen)
+		}
+	}
+}
+
+func TestOCSP(t *testing.T) {
+	root, err := New(WithCommonName("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := root.SignServer(&ServerRequest{CommonName: "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(t *testing.T, cert, issuer *x509.Certificate) *ocsp.Request {
+		t.Helper()
+		der, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := ocsp.ParseRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	req := request(t, server.Cert(), root.Cert())
+
+	der, err := root.SignOCSPResponse(req, ocsp.Good, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ocsp.ParseResponseForCert(der, server.Cert(), root.Cert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != ocsp.Good || resp.SerialNumber.Cmp(server.Cert().SerialNumber) != 0 {
+		t.Fatalf("unexpected response: status %d serial %s", resp.Status, resp.SerialNumber)
+	}
+	if d := resp.NextUpdate.Sub(resp.ThisUpdate); d != DefaultOCSPResponseValidity {
+		t.Fatalf("expected the default validity, got %s", d)
+	}
+
+	hourly, err := New(WithCommonName("hourly"), WithOCSPResponseValidity(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hourlyServer, err := hourly.SignServer(&ServerRequest{CommonName: "server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = hourly.SignOCSPResponse(request(t, hourlyServer.Cert(), hourly.Cert()), ocsp.Good, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ocsp.ParseResponseForCert(der, hourlyServer.Cert(), hourly.Cert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := resp.NextUpdate.Sub(resp.ThisUpdate); d != time.Hour {
+		t.Fatalf("expected a validity of 1h, got %s", d)
+	}
+
+	revokedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	der, err = root.SignOCSPResponse(req, ocsp.Revoked, revokedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ocsp.ParseResponseForCert(der, server.Cert(), root.Cert())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != ocsp.Revoked || !resp.RevokedAt.Equal(revokedAt) {
+		t.Fatalf("unexpected response: status %d revoked at %s", resp.Status, resp.RevokedAt)
+	}
+
+	// requests for certs of another ca are rejected
+	other, err := New(WithCommonName("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.SignOCSPResponse(req, ocsp.Good, time.Time{}); !errors.Is(err, errors.BadRequest) {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	if _, err := root.SignOCSPResponse(req, 42, time.Time{}); !errors.Is(err, errors.InvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+	// only a ca signs responses
+	if _, err := server.(*bundle).SignOCSPResponse(req, ocsp.Good, time.Time{}); !errors.Is(err, errors.InvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
+
 func TestPKCS8(t *testing.T) {
 	certBytes, err := os.ReadFile("/home/xhan/Downloads/dns.crt")
 	if err != nil {
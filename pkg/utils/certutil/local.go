@@ -0,0 +1,66 @@
+package certutil
+
+import (
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/xhanio/errors"
+)
+
+// LocalSANs gathers the subject alternative names of the local node to be fed
+// into a ServerRequest: the hostname and its fully qualified name as dns names,
+// and the addresses of the interfaces that are up as ips. Link-local addresses
+// are skipped, and loopback ones ("localhost", 127.0.0.1, ::1) are only
+// included when includeLoopback is set.
+func LocalSANs(includeLoopback bool) (dnsNames []string, ips []net.IP, err error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get hostname")
+	}
+	addName := func(name string) {
+		name = strings.TrimSuffix(name, ".")
+		if isHostname(name) && !slices.ContainsFunc(dnsNames, func(n string) bool {
+			return strings.EqualFold(n, name)
+		}) {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+	addName(hostname)
+	// the fqdn is best effort since the node may not resolve its own name
+	if cname, err := net.LookupCNAME(hostname); err == nil {
+		addName(cname)
+	}
+	if includeLoopback {
+		addName("localhost")
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to list network interfaces")
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get addresses of interface %s", iface.Name)
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipnet.IP
+			if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || (ip.IsLoopback() && !includeLoopback) {
+				continue
+			}
+			if !slices.ContainsFunc(ips, ip.Equal) {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return dnsNames, ips, nil
+}
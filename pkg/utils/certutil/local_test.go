@@ -0,0 +1,53 @@
+package certutil
+
+import (
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLocalSANs(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasHostname := func(dnsNames []string) bool {
+		return slices.ContainsFunc(dnsNames, func(name string) bool {
+			return strings.EqualFold(name, hostname)
+		})
+	}
+
+	dnsNames, ips, err := LocalSANs(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasHostname(dnsNames) {
+		t.Fatalf("expected hostname %s in %v", hostname, dnsNames)
+	}
+	if slices.Contains(dnsNames, "localhost") || slices.ContainsFunc(ips, net.IP.IsLoopback) {
+		t.Fatalf("expected no loopback names, got %v %v", dnsNames, ips)
+	}
+
+	dnsNames, ips, err = LocalSANs(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasHostname(dnsNames) || !slices.Contains(dnsNames, "localhost") {
+		t.Fatalf("expected hostname and localhost in %v", dnsNames)
+	}
+
+	// the gathered names are accepted by a server request
+	ca, err := New(WithCommonName("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.SignServer(&ServerRequest{CommonName: hostname, DNSNames: dnsNames, IPs: ips})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Cert().IPAddresses) != len(ips) {
+		t.Fatalf("expected %d ips in the cert, got %d", len(ips), len(server.Cert().IPAddresses))
+	}
+}
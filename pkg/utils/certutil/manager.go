@@ -1,6 +1,8 @@
 package certutil
 
 import (
+	"time"
+
 	"github.com/xhanio/errors"
 )
 
@@ -18,6 +20,8 @@ type manager struct {
 	cn        string
 	password  string
 
+	ocspValidity time.Duration
+
 	CABundle
 }
 
@@ -39,6 +43,7 @@ func New(opts ...Option) (Manager, error) {
 	if err != nil {
 		return nil, errors.Wrap(err)
 	}
+	b.ocspValidity = m.ocspValidity
 	if b.Key() != nil {
 		err = b.initTLS()
 		if err != nil {
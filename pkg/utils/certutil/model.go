@@ -2,11 +2,14 @@ package certutil
 
 import (
 	"crypto"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"net"
 	"time"
 
+	"golang.org/x/crypto/ocsp"
+
 	"github.com/xhanio/framingo/pkg/types/common"
 )
 
@@ -32,6 +35,11 @@ type CARequest struct {
 	DNSNames   []string
 	IPs        []net.IP
 	KeepChain  bool
+	// MaxPathLen is how many levels of intermediate CAs the signed CA may
+	// have below it, 0 letting it sign leaf certs only. Unset, or negative,
+	// it takes the most the issuing CAs allow, unlimited if they set no
+	// constraint.
+	MaxPathLen *int
 }
 
 type Manager interface {
@@ -46,6 +54,7 @@ type CertBundle interface {
 	Cert() *x509.Certificate
 	CertDER() []byte
 	CertPEM() []byte
+	Chain() ([]*x509.Certificate, error)
 	CertTLS() tls.Certificate
 	Key() crypto.PrivateKey
 	KeyDER() []byte
@@ -59,4 +68,10 @@ type CABundle interface {
 	SignClient(req *ClientRequest) (CertBundle, error)
 	SignServer(req *ServerRequest) (CertBundle, error)
 	SignCA(req *CARequest) (CABundle, error)
+	// RotateCA returns a new ca for newKey, or a generated key if nil, and the
+	// new ca cross-signed by the current one to bridge the transition.
+	RotateCA(newKey *rsa.PrivateKey) (CABundle, CABundle, error)
+	// SignOCSPResponse answers an OCSP request for a cert issued by the ca,
+	// see ocsp.Good, ocsp.Revoked and ocsp.Unknown for status.
+	SignOCSPResponse(req *ocsp.Request, status int, revokedAt time.Time) ([]byte, error)
 }
@@ -0,0 +1,93 @@
+package certutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/xhanio/errors"
+)
+
+// DefaultOCSPResponseValidity is how long OCSP responses are valid for, i.e.
+// how far their NextUpdate lies after their ThisUpdate, unless the ca is
+// created WithOCSPResponseValidity.
+const DefaultOCSPResponseValidity = 24 * time.Hour
+
+// SignOCSPResponse answers req, an OCSP request for a cert issued by the ca,
+// with status, one of ocsp.Good, ocsp.Revoked or ocsp.Unknown. The response
+// is signed by the ca key itself rather than a delegated responder. revokedAt
+// is when a revoked cert was revoked, now if zero, and is ignored otherwise.
+func (b *bundle) SignOCSPResponse(req *ocsp.Request, status int, revokedAt time.Time) ([]byte, error) {
+	if b.cert == nil || !b.cert.IsCA {
+		return nil, errors.InvalidArgument.Newf("unable to sign ocsp response: bundle is not a ca")
+	}
+	signer, ok := b.key.(crypto.Signer)
+	if !ok {
+		return nil, errors.InvalidArgument.Newf("unable to sign ocsp response: no private key found")
+	}
+	if req == nil || req.SerialNumber == nil {
+		return nil, errors.BadRequest.Newf("unable to sign ocsp response: request has no serial number")
+	}
+	switch status {
+	case ocsp.Good, ocsp.Revoked, ocsp.Unknown:
+	default:
+		return nil, errors.InvalidArgument.Newf("unable to sign ocsp response: invalid status %d", status)
+	}
+	validity := b.ocspValidity
+	if validity <= 0 {
+		validity = DefaultOCSPResponseValidity
+	}
+	issued, err := issuedBy(req, b.cert)
+	if err != nil {
+		return nil, err
+	}
+	if !issued {
+		return nil, errors.BadRequest.Newf("unable to sign ocsp response: serial %s is not issued by ca %s", req.SerialNumber, b.cert.Subject.CommonName)
+	}
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(validity),
+		IssuerHash:   req.HashAlgorithm,
+	}
+	if status == ocsp.Revoked {
+		if revokedAt.IsZero() {
+			revokedAt = now
+		}
+		template.RevokedAt = revokedAt
+		template.RevocationReason = ocsp.Unspecified
+	}
+	resp, err := ocsp.CreateResponse(b.cert, b.cert, template, signer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to sign ocsp response")
+	}
+	return resp, nil
+}
+
+// issuedBy reports whether the issuer name and key hashes of req identify ca.
+func issuedBy(req *ocsp.Request, ca *x509.Certificate) (bool, error) {
+	if !req.HashAlgorithm.Available() {
+		return false, errors.BadRequest.Newf("unable to sign ocsp response: unsupported hash algorithm %s", req.HashAlgorithm)
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(ca.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false, errors.Wrapf(err, "failed to parse ca public key")
+	}
+	h := req.HashAlgorithm.New()
+	h.Write(ca.RawSubject)
+	nameHash := h.Sum(nil)
+	h.Reset()
+	h.Write(spki.PublicKey.RightAlign())
+	keyHash := h.Sum(nil)
+	return bytes.Equal(nameHash, req.IssuerNameHash) && bytes.Equal(keyHash, req.IssuerKeyHash), nil
+}
@@ -1,5 +1,7 @@
 package certutil
 
+import "time"
+
 type Option func(m *manager)
 
 func (m *manager) apply(opts ...Option) {
@@ -35,3 +37,15 @@ func WithPassword(password string) Option {
 		m.password = password
 	}
 }
+
+// WithOCSPResponseValidity sets how long the OCSP responses signed by the ca,
+// and by the cas it signs or rotates to, are valid for, i.e. how far their
+// NextUpdate lies after their ThisUpdate. DefaultOCSPResponseValidity by
+// default.
+func WithOCSPResponseValidity(d time.Duration) Option {
+	return func(m *manager) {
+		if d > 0 {
+			m.ocspValidity = d
+		}
+	}
+}
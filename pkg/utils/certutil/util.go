@@ -1,6 +1,7 @@
 package certutil
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
@@ -8,6 +9,10 @@ import (
 	"crypto/x509/pkix"
 	"encoding/json"
 	"math/big"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/xhanio/errors"
@@ -44,7 +49,103 @@ func generateCA(cn string, key *rsa.PrivateKey) (*x509.Certificate, error) {
 	return cert, nil
 }
 
+// rotateCA creates a self-signed ca with the subject of ca and key, and the
+// same ca cross-signed by ca. Both share the subject key id, so certs issued
+// with key chain through either of them.
+func rotateCA(key *rsa.PrivateKey, ca *bundle) (*x509.Certificate, *x509.Certificate, error) {
+	if ca.Key() == nil {
+		return nil, nil, errors.Newf("failed to rotate ca: no private key found")
+	}
+	sn, err := rand.Int(rand.Reader, big.NewInt(1).Lsh(big.NewInt(1), 159))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to generate certificate serial number")
+	}
+	template := &x509.Certificate{
+		SerialNumber:          sn,
+		Subject:               ca.cert.Subject,
+		NotBefore:             time.Now().Add(-10 * time.Second),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            ca.cert.MaxPathLen,
+		MaxPathLenZero:        ca.cert.MaxPathLenZero,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to create certificate")
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse certificate")
+	}
+
+	// the cross-signed cert can't outlive the ca signing it
+	template.SubjectKeyId = cert.SubjectKeyId
+	template.NotAfter = timeutil.Earliest(true, ca.cert.NotAfter, template.NotAfter)
+	template.SerialNumber, err = rand.Int(rand.Reader, big.NewInt(1).Lsh(big.NewInt(1), 159))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to generate certificate serial number")
+	}
+	crossDER, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.Key())
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to create cross-signed certificate")
+	}
+	cross, err := x509.ParseCertificate(crossDER)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse cross-signed certificate")
+	}
+	return cert, cross, nil
+}
+
+// pathLenLimit returns how many levels of intermediate CAs a CA signed by ca
+// may have below it, as allowed by the path length constraints of ca and of
+// the CAs above it in its pool, or false if none of them is constrained. A
+// limit of -1 means ca may only sign leaf certs, less than that means ca
+// itself is deeper than its issuers allow.
+func pathLenLimit(ca *bundle) (int, bool) {
+	chain, err := buildChain(ca.cert, ca.pool)
+	if err != nil {
+		chain = []*x509.Certificate{ca.cert}
+	}
+	var limit int
+	var constrained bool
+	for i, c := range chain {
+		if c.MaxPathLen < 0 || (c.MaxPathLen == 0 && !c.MaxPathLenZero) {
+			continue
+		}
+		// the new ca would be the (i+1)th intermediate below c
+		if l := c.MaxPathLen - i - 1; !constrained || l < limit {
+			limit, constrained = l, true
+		}
+	}
+	return limit, constrained
+}
+
+// checkPathLen verifies the path length constraints above ca permit it to
+// issue certs.
+func checkPathLen(ca *bundle) error {
+	if limit, constrained := pathLenLimit(ca); constrained && limit < -1 {
+		return errors.Forbidden.Newf("ca %s exceeds the path length allowed by its issuers", ca.cert.Subject.CommonName)
+	}
+	return nil
+}
+
 func signCA(req *CARequest, key *rsa.PrivateKey, ca *bundle) (*x509.Certificate, error) {
+	maxPathLen := -1
+	if req.MaxPathLen != nil && *req.MaxPathLen >= 0 {
+		maxPathLen = *req.MaxPathLen
+	}
+	limit, constrained := pathLenLimit(ca)
+	switch {
+	case !constrained:
+	case limit < 0:
+		return nil, errors.Forbidden.Newf("ca %s is not allowed to sign cas by its path length constraint", ca.cert.Subject.CommonName)
+	case maxPathLen < 0:
+		maxPathLen = limit
+	case maxPathLen > limit:
+		return nil, errors.BadRequest.Newf("max path length %d exceeds %d allowed by ca %s", maxPathLen, limit, ca.cert.Subject.CommonName)
+	}
 	subject := ca.cert.Subject
 	subject.CommonName = req.CommonName // overwrite common name
 
@@ -83,6 +184,8 @@ func signCA(req *CARequest, key *rsa.PrivateKey, ca *bundle) (*x509.Certificate,
 		Subject:               pcr.Subject,
 		NotBefore:             ca.cert.NotBefore,
 		NotAfter:              ca.cert.NotAfter,
+		MaxPathLen:            maxPathLen,
+		MaxPathLenZero:        maxPathLen == 0,
 		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		DNSNames:              pcr.DNSNames,
 		IPAddresses:           pcr.IPAddresses,
@@ -98,7 +201,40 @@ func signCA(req *CARequest, key *rsa.PrivateKey, ca *bundle) (*x509.Certificate,
 	return cert, nil
 }
 
+var hostnameRegex = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// isHostname reports whether name can be used as a dns subject alternative name.
+func isHostname(name string) bool {
+	if len(name) > 253 || net.ParseIP(name) != nil {
+		return false
+	}
+	return hostnameRegex.MatchString(name)
+}
+
+// serverDNSNames validates that the request identifies the server and returns
+// its dns names, with the common name appended when it looks like a hostname
+// since clients only match against the subject alternative names.
+func serverDNSNames(req *ServerRequest) ([]string, error) {
+	if req.CommonName == "" && len(req.DNSNames) == 0 && len(req.IPs) == 0 {
+		return nil, errors.BadRequest.Newf("server request requires a common name, dns name or ip")
+	}
+	dnsNames := slices.Clone(req.DNSNames)
+	if isHostname(req.CommonName) && !slices.ContainsFunc(dnsNames, func(name string) bool {
+		return strings.EqualFold(name, req.CommonName)
+	}) {
+		dnsNames = append(dnsNames, req.CommonName)
+	}
+	return dnsNames, nil
+}
+
 func signServer(req *ServerRequest, key *rsa.PrivateKey, ca *bundle) (*x509.Certificate, error) {
+	dnsNames, err := serverDNSNames(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPathLen(ca); err != nil {
+		return nil, err
+	}
 	subject := ca.cert.Subject
 	subject.CommonName = req.CommonName // overwrite common name
 	// fmt.Println(ca.cert.SignatureAlgorithm)
@@ -106,7 +242,7 @@ func signServer(req *ServerRequest, key *rsa.PrivateKey, ca *bundle) (*x509.Cert
 		PublicKey:          key.PublicKey,
 		SignatureAlgorithm: x509.SHA256WithRSA,
 		Subject:            subject,
-		DNSNames:           req.DNSNames,
+		DNSNames:           dnsNames,
 	}
 	if len(req.IPs) > 0 {
 		cr.IPAddresses = req.IPs
@@ -151,6 +287,12 @@ func signServer(req *ServerRequest, key *rsa.PrivateKey, ca *bundle) (*x509.Cert
 }
 
 func signClient(req *ClientRequest, key *rsa.PrivateKey, ca *bundle) (*x509.Certificate, error) {
+	if req.CommonName == "" {
+		return nil, errors.BadRequest.Newf("client request requires a common name")
+	}
+	if err := checkPathLen(ca); err != nil {
+		return nil, err
+	}
 	name := ca.cert.Subject
 	name.CommonName = req.CommonName // overwrite common name
 
@@ -198,6 +340,50 @@ func signClient(req *ClientRequest, key *rsa.PrivateKey, ca *bundle) (*x509.Cert
 	return cert, nil
 }
 
+func buildChain(leaf *x509.Certificate, pool []*x509.Certificate) ([]*x509.Certificate, error) {
+	chain := []*x509.Certificate{leaf}
+	remaining := make([]*x509.Certificate, 0, len(pool))
+	for _, cert := range pool {
+		if !cert.Equal(leaf) {
+			remaining = append(remaining, cert)
+		}
+	}
+	curr := leaf
+	for len(remaining) > 0 && !isSelfSigned(curr) {
+		idx := findIssuer(curr, remaining)
+		if idx < 0 {
+			break
+		}
+		curr = remaining[idx]
+		chain = append(chain, curr)
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+	if len(remaining) > 0 {
+		return nil, errors.Newf("broken certificate chain: issuer of %s not found, %d cert(s) left unchained", curr.Subject.CommonName, len(remaining))
+	}
+	return chain, nil
+}
+
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) int {
+	for i, candidate := range candidates {
+		if len(cert.AuthorityKeyId) > 0 && len(candidate.SubjectKeyId) > 0 {
+			if !bytes.Equal(cert.AuthorityKeyId, candidate.SubjectKeyId) {
+				continue
+			}
+		} else if !bytes.Equal(cert.RawIssuer, candidate.RawSubject) {
+			continue
+		}
+		if cert.CheckSignatureFrom(candidate) == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
 func Encode(b CertBundle) ([]byte, error) {
 	if b == nil {
 		return nil, errors.Newf("The bundle is empty, nothing to encode")
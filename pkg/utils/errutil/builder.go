@@ -0,0 +1,70 @@
+package errutil
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/xhanio/errors"
+)
+
+// Builder is a fluent alternative to passing errors.Option values to
+// errors.New and errors.Wrap:
+//
+//	errutil.Build().Message("user %s not found", id).Code("U404", nil).Category(errors.NotFound).Err()
+type Builder struct {
+	opts   []errors.Option
+	cause  error
+	fields labels.Set
+
+	messageID   string
+	messageArgs []any
+}
+
+func Build() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) Message(format string, args ...any) *Builder {
+	b.opts = append(b.opts, errors.WithMessage(format, args...))
+	return b
+}
+
+func (b *Builder) Code(code string, details labels.Set) *Builder {
+	b.opts = append(b.opts, errors.WithCode(code, details))
+	return b
+}
+
+func (b *Builder) Category(category errors.Category) *Builder {
+	b.opts = append(b.opts, errors.WithCategory(category))
+	return b
+}
+
+// Fields merges fields into the error details, see WithFields.
+func (b *Builder) Fields(fields labels.Set) *Builder {
+	b.fields = labels.Merge(b.fields, fields)
+	return b
+}
+
+// MessageID sets the message catalog ID of the error, see WithMessageID.
+func (b *Builder) MessageID(id string, args ...any) *Builder {
+	b.messageID = id
+	b.messageArgs = args
+	return b
+}
+
+// Cause makes Err wrap err, as errors.Wrap does.
+func (b *Builder) Cause(err error) *Builder {
+	b.cause = err
+	return b
+}
+
+// Err builds the error, capturing the stack at this call. It is equivalent to
+// errors.New with the collected options, or errors.Wrap when a cause is set.
+func (b *Builder) Err() error {
+	var err error
+	if b.cause != nil {
+		err = errors.Wrap(b.cause, b.opts...)
+	} else {
+		err = errors.New(b.opts...)
+	}
+	return WithMessageID(WithFields(err, b.fields), b.messageID, b.messageArgs...)
+}
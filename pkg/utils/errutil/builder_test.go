@@ -0,0 +1,57 @@
+package errutil
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/xhanio/errors"
+)
+
+func TestBuilder(t *testing.T) {
+	details := labels.Set{"field": "name"}
+	tests := []struct {
+		name     string
+		built    error
+		expected error
+	}{
+		{
+			name:     "message",
+			built:    Build().Message("invalid %s", "name").Err(),
+			expected: errors.New(errors.WithMessage("invalid %s", "name")),
+		},
+		{
+			name:  "message, code and category",
+			built: Build().Message("invalid name").Code("X1", details).Category(errors.BadRequest).Err(),
+			expected: errors.New(
+				errors.WithMessage("invalid name"),
+				errors.WithCode("X1", details),
+				errors.WithCategory(errors.BadRequest),
+			),
+		},
+		{
+			name:     "cause",
+			built:    Build().Message("read failed").Category(errors.Unavailable).Cause(io.EOF).Err(),
+			expected: errors.Wrap(io.EOF, errors.WithMessage("read failed"), errors.WithCategory(errors.Unavailable)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			built, ok := tt.built.(errors.Error)
+			assert.True(t, ok)
+			expected := tt.expected.(errors.Error)
+			assert.Equal(t, expected.Error(), built.Error())
+			assert.Equal(t, expected.Category(), built.Category())
+			assert.Equal(t, expected.Cause(), built.Cause())
+			code, d := built.Code()
+			expectedCode, expectedDetails := expected.Code()
+			assert.Equal(t, expectedCode, code)
+			assert.Equal(t, expectedDetails, d)
+			// the stack is captured where Err is called
+			assert.Contains(t, fmt.Sprintf("%v", built), "errutil.TestBuilder")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package errutil
+
+import (
+	"fmt"
+
+	"github.com/xhanio/errors"
+)
+
+// CombineDedup combines errs like errors.Combine, but collapses errors with
+// the same message into their first occurrence with a count suffix, e.g.
+// "connection refused (x1523)". Distinct messages are kept in the order they
+// first appear, and combined errors among errs are flattened first, so a batch
+// that fails uniformly reports one line instead of thousands.
+func CombineDedup(errs ...error) error {
+	var flat []error
+	for _, err := range errs {
+		flat = appendFlat(flat, err)
+	}
+	counts := make(map[string]int, len(flat))
+	var distinct []error
+	for _, err := range flat {
+		msg := err.Error()
+		if counts[msg] == 0 {
+			distinct = append(distinct, err)
+		}
+		counts[msg]++
+	}
+	for i, err := range distinct {
+		if n := counts[err.Error()]; n > 1 {
+			distinct[i] = &repeated{err: err, count: n}
+		}
+	}
+	return errors.Combine(distinct...)
+}
+
+func appendFlat(flat []error, err error) []error {
+	if err == nil {
+		return flat
+	}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range multi.Unwrap() {
+			flat = appendFlat(flat, e)
+		}
+		return flat
+	}
+	return append(flat, err)
+}
+
+// repeated is an error that occurred count times. It unwraps to the first
+// occurrence, so categories and codes are still found through it.
+type repeated struct {
+	err   error
+	count int
+}
+
+func (e *repeated) Error() string {
+	return fmt.Sprintf("%s (x%d)", e.err.Error(), e.count)
+}
+
+func (e *repeated) Unwrap() error {
+	return e.err
+}
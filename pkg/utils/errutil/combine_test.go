@@ -0,0 +1,39 @@
+package errutil
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/errors"
+)
+
+func TestCombineDedup(t *testing.T) {
+	assert.NoError(t, CombineDedup())
+	assert.NoError(t, CombineDedup(nil, nil))
+
+	single := errors.Newf("timeout")
+	assert.Equal(t, single, CombineDedup(nil, single))
+
+	var errs []error
+	for range 1523 {
+		errs = append(errs, errors.Unavailable.Newf("connection refused"))
+	}
+	errs = append(errs, errors.InvalidArgument.Newf("bad row 7"), nil)
+	// nested combined errors are flattened before counting
+	errs = append(errs, errors.Combine(errors.Newf("bad row 9"), errors.Unavailable.Newf("connection refused")))
+
+	err := CombineDedup(errs...)
+	require.Error(t, err)
+	assert.Equal(t, "connection refused (x1524); bad row 7; bad row 9", err.Error())
+
+	multi, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok)
+	parts := multi.Unwrap()
+	require.Len(t, parts, 3)
+	assert.Equal(t, errors.Unavailable, CategoryOf(parts[0]))
+	assert.Equal(t, errors.InvalidArgument, CategoryOf(parts[1]))
+	assert.True(t, stderrors.Is(parts[0], errs[0]), "the collapsed entry should unwrap to the first occurrence")
+}
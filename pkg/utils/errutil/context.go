@@ -0,0 +1,63 @@
+package errutil
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/xhanio/errors"
+)
+
+var (
+	// Timeout classifies context.DeadlineExceeded. It answers 504 rather
+	// than the 408 of errors.DeadlineExceeded, since the deadline is one of
+	// the server waiting on something else, not of the client.
+	Timeout = errors.NewCategory("Timeout", http.StatusGatewayTimeout)
+	// Canceled classifies context.Canceled, using the non-standard 499 status.
+	Canceled = errors.Cancaled
+)
+
+// FromContext returns ctx.Err() classified as Timeout or Canceled, or nil if
+// ctx is still active.
+func FromContext(ctx context.Context) error {
+	return Wrap(ctx.Err())
+}
+
+// Wrap is errors.Wrap that classifies uncategorized context errors found in
+// the chain of err as Timeout or Canceled. Errors with an explicit category
+// keep it.
+func Wrap(err error, opts ...errors.Option) error {
+	if err == nil {
+		return nil
+	}
+	if CategoryOf(err) == errors.Internal {
+		if c := contextCategory(err); c != nil {
+			return c.Wrap(err, opts...)
+		}
+	}
+	return errors.Wrap(err, opts...)
+}
+
+func contextCategory(err error) errors.Category {
+	switch {
+	case HasCause(err, context.DeadlineExceeded):
+		return Timeout
+	case HasCause(err, context.Canceled):
+		return Canceled
+	}
+	return nil
+}
+
+// HasCause reports whether cause is in the chain of err, looking through both
+// standard unwrapping and the cause chain of errors.Error, which does not
+// implement Unwrap.
+func HasCause(err, cause error) bool {
+	if stderrors.Is(err, cause) {
+		return true
+	}
+	var e errors.Error
+	if stderrors.As(err, &e) {
+		return e.Has(cause)
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package errutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xhanio/errors"
+)
+
+func TestWrapContextErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected errors.Category
+		status   int
+	}{
+		{
+			name:     "deadline exceeded",
+			err:      context.DeadlineExceeded,
+			expected: Timeout,
+			status:   http.StatusGatewayTimeout,
+		},
+		{
+			name:     "canceled",
+			err:      context.Canceled,
+			expected: Canceled,
+			status:   499,
+		},
+		{
+			name:     "fmt wrapped deadline exceeded",
+			err:      fmt.Errorf("query: %w", context.DeadlineExceeded),
+			expected: Timeout,
+			status:   http.StatusGatewayTimeout,
+		},
+		{
+			name:     "errors wrapped canceled",
+			err:      errors.Wrapf(context.Canceled, "job stopped"),
+			expected: Canceled,
+			status:   499,
+		},
+		{
+			name:     "explicit category is kept",
+			err:      errors.Unavailable.Wrap(context.DeadlineExceeded),
+			expected: errors.Unavailable,
+			status:   http.StatusServiceUnavailable,
+		},
+		{
+			name:     "non context error",
+			err:      fmt.Errorf("boom"),
+			expected: errors.Internal,
+			status:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CategoryOf(Wrap(tt.err))
+			assert.Equal(t, tt.expected, c)
+			assert.Equal(t, tt.status, c.StatusCode())
+		})
+	}
+	assert.Nil(t, Wrap(nil))
+}
+
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, FromContext(ctx))
+	cancel()
+	assert.Equal(t, Canceled, CategoryOf(FromContext(ctx)))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	assert.Equal(t, Timeout, CategoryOf(FromContext(ctx)))
+}
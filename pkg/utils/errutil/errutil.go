@@ -0,0 +1,131 @@
+package errutil
+
+import (
+	stderrors "errors"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/xhanio/errors"
+)
+
+// CategoryOf returns the category of err. Errors without a category are treated
+// as Internal, and a nil error has no category.
+func CategoryOf(err error) errors.Category {
+	if err == nil {
+		return nil
+	}
+	var e errors.Error
+	if stderrors.As(err, &e) {
+		return e.Category()
+	}
+	var c errors.Category
+	if stderrors.As(err, &c) {
+		return c
+	}
+	return errors.Internal
+}
+
+// CodeOf returns the customized error code and details carried by err. Details
+// set at every level of the chain, including the fields of WithFields, are
+// merged, outer levels taking precedence.
+func CodeOf(err error) (string, labels.Set) {
+	var code string
+	var details labels.Set
+	levels := levelsOf(err)
+	for i := len(levels) - 1; i >= 0; i-- {
+		switch level := levels[i].(type) {
+		case *fieldsError:
+			details = labels.Merge(details, level.fields)
+		case errors.Error:
+			c, d := level.Code()
+			if c != "" {
+				code = c
+			}
+			if len(d) > 0 {
+				details = labels.Merge(details, d)
+			}
+		}
+	}
+	return code, details
+}
+
+// levelsOf returns the levels of the chain of err, outermost first, continuing
+// below the fields of WithFields and the message ID of WithMessageID, where
+// errors.Error.Chain stops.
+func levelsOf(err error) []error {
+	var levels []error
+	for err != nil {
+		switch e := err.(type) {
+		case *fieldsError:
+			levels = append(levels, e)
+			err = e.err
+		case *messageError:
+			levels = append(levels, e)
+			err = e.err
+		case errors.Error:
+			chain := e.Chain()
+			levels = append(levels, chain...)
+			err = chain[len(chain)-1].(errors.Error).Cause()
+		default:
+			var next errors.Error
+			if !stderrors.As(err, &next) {
+				return levels
+			}
+			err = next
+		}
+	}
+	return levels
+}
+
+// MessageOf returns the latest level message of err like errors.Error.Message,
+// without the fields WithFields appends to it. It is the message of err itself
+// for other errors.
+func MessageOf(err error) string {
+	var e errors.Error
+	if !stderrors.As(err, &e) {
+		return err.Error()
+	}
+	msg := e.Message()
+	chain := e.Chain()
+	switch cause := chain[len(chain)-1].(errors.Error).Cause().(type) {
+	case *fieldsError:
+		if msg == cause.Error() {
+			return MessageOf(cause.err)
+		}
+	case *messageError:
+		if msg == cause.Error() {
+			return MessageOf(cause.err)
+		}
+	}
+	return msg
+}
+
+// WithFields wraps err with fields, so they survive further wrapping, show up
+// in CodeOf and API error bodies, and are appended to the text of err as
+// "[key=value,...]". The category and code of err are kept.
+func WithFields(err error, fields labels.Set) error {
+	if err == nil || len(fields) == 0 {
+		return err
+	}
+	opts := []errors.Option{errors.WithCategory(CategoryOf(err))}
+	if code, details := CodeOf(err); code != "" {
+		opts = append(opts, errors.WithCode(code, labels.Merge(details, fields)))
+	}
+	return errors.Wrap(&fieldsError{err: err, fields: fields}, opts...)
+}
+
+// fieldsError holds the fields of a WithFields call. It sits below an
+// errors.Error level carrying the category and code of err, since errors.Error
+// levels only find those through causes of their own type.
+type fieldsError struct {
+	err    error
+	fields labels.Set
+}
+
+func (e *fieldsError) Error() string {
+	return e.err.Error() + " [" + e.fields.String() + "]"
+}
+
+func (e *fieldsError) Unwrap() error {
+	return e.err
+}
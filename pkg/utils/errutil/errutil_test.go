@@ -0,0 +1,114 @@
+package errutil
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/xhanio/errors"
+)
+
+func TestCategoryOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected errors.Category
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: nil,
+		},
+		{
+			name:     "plain error",
+			err:      io.EOF,
+			expected: errors.Internal,
+		},
+		{
+			name:     "category",
+			err:      errors.NotFound,
+			expected: errors.NotFound,
+		},
+		{
+			name:     "categorized error",
+			err:      errors.BadRequest.Newf("invalid input"),
+			expected: errors.BadRequest,
+		},
+		{
+			name:     "wrapped categorized error",
+			err:      errors.Wrapf(errors.Conflict.Newf("duplicated"), "failed to create"),
+			expected: errors.Conflict,
+		},
+		{
+			name:     "std wrapped categorized error",
+			err:      fmt.Errorf("failed: %w", errors.Forbidden.Newf("denied")),
+			expected: errors.Forbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CategoryOf(tt.err))
+		})
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	details := labels.Set{"field": "name"}
+	err := errors.Wrapf(errors.BadRequest.New(errors.WithCode("E001", details)), "failed to validate")
+	code, d := CodeOf(err)
+	assert.Equal(t, "E001", code)
+	assert.Equal(t, details, d)
+
+	code, d = CodeOf(io.EOF)
+	assert.Empty(t, code)
+	assert.Nil(t, d)
+
+	code, d = CodeOf(nil)
+	assert.Empty(t, code)
+	assert.Nil(t, d)
+}
+
+func TestWithFields(t *testing.T) {
+	// fields without a code
+	err := WithFields(errors.NotFound.Newf("user not found"), labels.Set{"user": "u1"})
+	code, d := CodeOf(err)
+	assert.Empty(t, code)
+	assert.Equal(t, labels.Set{"user": "u1"}, d)
+	assert.True(t, errors.Is(err, errors.NotFound))
+	assert.Equal(t, "user not found [user=u1]", err.Error())
+	assert.Equal(t, "user not found", MessageOf(err))
+	assert.NotContains(t, fmt.Sprintf("%v", err), "{")
+	raw, _ := err.(errors.Error).Code()
+	assert.Empty(t, raw)
+
+	// fields merged across a wrap chain, outer levels winning
+	err = errors.Wrapf(err, "failed to load profile")
+	err = WithFields(err, labels.Set{"request": "r1", "user": "u2"})
+	err = errors.Wrapf(err, "failed to render page")
+	code, d = CodeOf(err)
+	assert.Empty(t, code)
+	assert.Equal(t, labels.Set{"request": "r1", "user": "u2"}, d)
+	assert.True(t, errors.Is(err, errors.NotFound))
+	assert.Equal(t, "failed to render page: failed to load profile: user not found [user=u1] [request=r1,user=u2]", err.Error())
+
+	// fields kept alongside a code, even when an outer code hides them
+	err = WithFields(errors.BadRequest.New(errors.WithCode("E001", labels.Set{"field": "name"})), labels.Set{"user": "u1"})
+	code, d = CodeOf(err)
+	assert.Equal(t, "E001", code)
+	assert.Equal(t, labels.Set{"field": "name", "user": "u1"}, d)
+	err = errors.Wrap(err, errors.WithCode("E002", nil))
+	code, d = CodeOf(err)
+	assert.Equal(t, "E002", code)
+	assert.Equal(t, labels.Set{"field": "name", "user": "u1"}, d)
+
+	assert.Nil(t, WithFields(nil, labels.Set{"user": "u1"}))
+	assert.Equal(t, io.EOF, WithFields(io.EOF, nil))
+
+	err = Build().Message("invalid name").Fields(labels.Set{"field": "name"}).Err()
+	code, d = CodeOf(err)
+	assert.Empty(t, code)
+	assert.Equal(t, labels.Set{"field": "name"}, d)
+}
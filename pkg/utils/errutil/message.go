@@ -0,0 +1,88 @@
+package errutil
+
+import (
+	"sync/atomic"
+
+	"github.com/xhanio/errors"
+)
+
+// Translator renders the catalog message id in lang, e.g. "de" or "pt-BR",
+// returning "" when it has no translation for them.
+type Translator func(id, lang string, args ...any) string
+
+var translator atomic.Pointer[Translator]
+
+// SetTranslator installs the translator Localize uses. A nil translator
+// disables localization, which is the default.
+func SetTranslator(t Translator) {
+	if t == nil {
+		translator.Store(nil)
+		return
+	}
+	translator.Store(&t)
+}
+
+// WithMessageID wraps err with a message catalog ID and its arguments, which
+// Localize renders in the language of the reader. They survive further
+// wrapping while the message, code, details and category stay as they are,
+// and the arguments reach the translator unchanged, so verbs like %d and %.2f
+// work on them.
+func WithMessageID(err error, id string, args ...any) error {
+	if err == nil || id == "" {
+		return err
+	}
+	opts := []errors.Option{errors.WithCategory(CategoryOf(err))}
+	if code, details := CodeOf(err); code != "" {
+		opts = append(opts, errors.WithCode(code, details))
+	}
+	return errors.Wrap(&messageError{err: err, id: id, args: args}, opts...)
+}
+
+// MessageIDOf returns the message catalog ID and arguments recorded by the
+// outermost WithMessageID in the chain of err, or "" if there is none.
+func MessageIDOf(err error) (string, []any) {
+	for _, level := range levelsOf(err) {
+		if me, ok := level.(*messageError); ok {
+			return me.id, me.args
+		}
+	}
+	return "", nil
+}
+
+// messageError holds the message ID of a WithMessageID call, below an
+// errors.Error level carrying the category and code of err like fieldsError.
+// It adds nothing to the text of err.
+type messageError struct {
+	err  error
+	id   string
+	args []any
+}
+
+func (e *messageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *messageError) Unwrap() error {
+	return e.err
+}
+
+// Localize renders the message ID of err with the installed translator in
+// the first of langs it has a translation for. It returns false when err
+// carries no message ID, no translator is installed or none of langs is
+// translated, in which case callers fall back to the message of err.
+func Localize(err error, langs ...string) (string, bool) {
+	t := translator.Load()
+	if t == nil {
+		return "", false
+	}
+	id, args := MessageIDOf(err)
+	if id == "" {
+		return "", false
+	}
+	for _, lang := range langs {
+		if msg := (*t)(id, lang, args...); msg != "" {
+			return msg, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,78 @@
+package errutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/xhanio/errors"
+)
+
+var testCatalog = map[string]map[string]string{
+	"en": {"user.not_found": "user %s not found", "quota.exceeded": "%d of %.2f GB used"},
+	"de": {"user.not_found": "Benutzer %s nicht gefunden"},
+}
+
+func testTranslator(id, lang string, args ...any) string {
+	format, ok := testCatalog[lang][id]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func TestWithMessageID(t *testing.T) {
+	err := WithMessageID(errors.NotFound.New(errors.WithMessage("no such user"), errors.WithCode("U404", labels.Set{"user": "u1"})), "user.not_found", "u1")
+	err = errors.Wrapf(err, "failed to load profile")
+
+	// code, category and message are left as they are
+	id, args := MessageIDOf(err)
+	assert.Equal(t, "user.not_found", id)
+	assert.Equal(t, []any{"u1"}, args)
+	code, d := CodeOf(err)
+	assert.Equal(t, "U404", code)
+	assert.Equal(t, labels.Set{"user": "u1"}, d)
+	assert.True(t, errors.Is(err, errors.NotFound))
+	assert.Equal(t, "failed to load profile: no such user", err.Error())
+	assert.NotContains(t, fmt.Sprintf("%v", err), "user.not_found")
+
+	// no translator installed
+	_, ok := Localize(err, "de")
+	assert.False(t, ok)
+
+	SetTranslator(testTranslator)
+	defer SetTranslator(nil)
+	msg, ok := Localize(err, "en")
+	assert.True(t, ok)
+	assert.Equal(t, "user u1 not found", msg)
+	msg, ok = Localize(err, "fr", "de", "en")
+	assert.True(t, ok)
+	assert.Equal(t, "Benutzer u1 nicht gefunden", msg)
+	_, ok = Localize(err, "fr")
+	assert.False(t, ok)
+	_, ok = Localize(errors.NotFound.Newf("no such user"), "en")
+	assert.False(t, ok)
+
+	// an outer message ID replaces the inner one with all its arguments
+	err = WithMessageID(WithMessageID(errors.Newf("failed"), "a", 1, 2), "b")
+	id, args = MessageIDOf(err)
+	assert.Equal(t, "b", id)
+	assert.Empty(t, args)
+
+	err = Build().Message("no such user").Category(errors.NotFound).MessageID("user.not_found", "u42").Err()
+	msg, ok = Localize(err, "de")
+	assert.True(t, ok)
+	assert.Equal(t, "Benutzer u42 nicht gefunden", msg)
+
+	// arguments reach the translator as they are
+	err = WithMessageID(WithFields(errors.Newf("quota exceeded"), labels.Set{"user": "u1"}), "quota.exceeded", 3, 2.5)
+	msg, ok = Localize(err, "en")
+	assert.True(t, ok)
+	assert.Equal(t, "3 of 2.50 GB used", msg)
+	_, d = CodeOf(err)
+	assert.Equal(t, labels.Set{"user": "u1"}, d)
+	assert.Equal(t, "quota exceeded [user=u1]", err.Error())
+	assert.Equal(t, "quota exceeded", MessageOf(err))
+}
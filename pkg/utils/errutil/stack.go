@@ -0,0 +1,114 @@
+package errutil
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/xhanio/errors"
+)
+
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// FormatStack renders the stack trace recorded by err as "file:line:func"
+// strings, innermost frame first. Frames of functions in any of skipPkgs, given
+// as package path prefixes such as "github.com/xhanio/framingo/pkg/utils/errutil",
+// are elided. It returns nil if err carries no stack trace.
+func FormatStack(err error, skipPkgs ...string) []string {
+	var st stackTracer
+	if !stderrors.As(err, &st) {
+		return nil
+	}
+	var lines []string
+	for _, f := range st.StackTrace() {
+		pc := uintptr(f) - 1
+		fn := runtime.FuncForPC(pc)
+		if fn == nil {
+			continue
+		}
+		name := fn.Name()
+		if skipFrame(name, skipPkgs) {
+			continue
+		}
+		file, line := fn.FileLine(pc)
+		lines = append(lines, fmt.Sprintf("%s:%d:%s", file, line, name))
+	}
+	return lines
+}
+
+// skipFrame reports whether the function, named as by runtime.Func.Name, lives
+// in one of pkgs. The prefix must end at a package boundary, so "a/b" does not
+// match "a/bc".
+func skipFrame(name string, pkgs []string) bool {
+	for _, pkg := range pkgs {
+		rest, ok := strings.CutPrefix(name, pkg)
+		if ok && (rest == "" || rest[0] == '.' || rest[0] == '/') {
+			return true
+		}
+	}
+	return false
+}
+
+// Recover turns r, a value returned by recover, into an error. Call it from the
+// deferred function that recovered: that still runs on the panicking stack, so
+// the stack trace captured here leads to the panic. An errors.Error keeps the
+// stack recorded where it was created, which for panic(errors.Newf(...)) is the
+// panicking function too. It returns nil for a nil r.
+func Recover(r any) error {
+	switch v := r.(type) {
+	case nil:
+		return nil
+	case error:
+		return errors.Wrap(v)
+	default:
+		return errors.Newf("recovered from panic: %v", r)
+	}
+}
+
+// WithStackFilter wraps err so that %+v prints its message followed by the
+// stack trace from FormatStack, one frame per line, with frames of skipPkgs
+// elided. %s and %v print the message only. The wrapper unwraps to err, so
+// categories and codes are still found through it.
+func WithStackFilter(err error, skipPkgs ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &stackFilter{err: err, skipPkgs: skipPkgs}
+}
+
+type stackFilter struct {
+	err      error
+	skipPkgs []string
+}
+
+func (e *stackFilter) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackFilter) Unwrap() error {
+	return e.err
+}
+
+func (e *stackFilter) Format(f fmt.State, c rune) {
+	switch c {
+	case 'v':
+		io.WriteString(f, e.err.Error())
+		if f.Flag('+') {
+			for _, line := range FormatStack(e.err, e.skipPkgs...) {
+				io.WriteString(f, "\n\t"+line)
+			}
+		}
+	case 's':
+		io.WriteString(f, e.err.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", e.err.Error())
+	default:
+		fmt.Fprintf(f, "!%%%c(%s)", c, e.err.Error())
+	}
+}
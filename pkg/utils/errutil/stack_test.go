@@ -0,0 +1,54 @@
+package errutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xhanio/errors"
+)
+
+const errutilPkg = "github.com/xhanio/framingo/pkg/utils/errutil"
+
+func newStackError() error {
+	return errors.NotFound.Newf("missing")
+}
+
+func TestFormatStack(t *testing.T) {
+	err := errors.Wrap(newStackError())
+
+	all := FormatStack(err)
+	require.NotEmpty(t, all)
+	assert.True(t, strings.HasSuffix(all[0], ":"+errutilPkg+".newStackError"), all[0])
+	assert.Regexp(t, `^.+\.go:\d+:.+$`, all[0])
+
+	filtered := FormatStack(err, errutilPkg)
+	require.NotEmpty(t, filtered)
+	assert.Less(t, len(filtered), len(all))
+	for _, line := range filtered {
+		assert.NotContains(t, line, errutilPkg+".", "internal frames are elided")
+	}
+	// prefixes only match whole package paths
+	assert.Equal(t, all, FormatStack(err, "github.com/xhanio/framingo/pkg/utils/err"))
+
+	assert.Nil(t, FormatStack(nil))
+	assert.Nil(t, FormatStack(fmt.Errorf("no stack")))
+}
+
+func TestWithStackFilter(t *testing.T) {
+	assert.Nil(t, WithStackFilter(nil))
+
+	err := WithStackFilter(newStackError(), errutilPkg)
+	assert.Equal(t, "missing", err.Error())
+	assert.Equal(t, "missing", fmt.Sprintf("%v", err))
+	assert.Equal(t, errors.NotFound, CategoryOf(err), "category is kept")
+
+	out := fmt.Sprintf("%+v", err)
+	lines := strings.Split(out, "\n\t")
+	assert.Equal(t, "missing", lines[0])
+	assert.Equal(t, FormatStack(err, errutilPkg), lines[1:])
+	assert.NotContains(t, out, errutilPkg+".")
+}
@@ -0,0 +1,75 @@
+package job
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xhanio/errors"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/xhanio/framingo/pkg/utils/errutil"
+)
+
+// RunBatch runs jobs with a total weight of at most concurrency active at once
+// and returns the error of each job by id. Jobs count by their Weight, 1 unless
+// set with WithWeight, so a few heavy jobs cannot take all the slots: a job
+// heavier than concurrency runs alone. Jobs are admitted in order, a heavy job
+// waiting for enough slots holds back the lighter jobs after it rather than
+// being starved by them. Once ctx is done no further jobs are launched,
+// running jobs are canceled and jobs never launched report the context error.
+// A concurrency below 1 runs all jobs at once.
+func RunBatch(ctx context.Context, jobs []Job, concurrency int) map[string]error {
+	var total int
+	for _, j := range jobs {
+		total += j.Weight()
+	}
+	if concurrency < 1 || concurrency > total {
+		concurrency = total
+	}
+	var mu sync.Mutex
+	results := make(map[string]error, len(jobs))
+	set := func(id string, err error) {
+		mu.Lock()
+		results[id] = err
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	sem := semaphore.NewWeighted(int64(concurrency))
+	for i, j := range jobs {
+		weight := int64(min(j.Weight(), concurrency))
+		if err := sem.Acquire(ctx, weight); err != nil {
+			for _, skipped := range jobs[i:] {
+				set(skipped.ID(), errutil.FromContext(ctx))
+			}
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.Release(weight)
+			set(j.ID(), runAndWait(ctx, j))
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func runAndWait(ctx context.Context, j Job) error {
+	if !j.Run(ctx, nil) {
+		return errors.Conflict.Newf("job %s is still pending", j.ID())
+	}
+	done := make(chan struct{})
+	go func() {
+		j.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// the job context derives from ctx, cancel marks it as canceled
+		j.Cancel()
+		<-done
+	}
+	return j.Err()
+}
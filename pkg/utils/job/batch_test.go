@@ -0,0 +1,128 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ferrors "github.com/xhanio/errors"
+)
+
+func TestRunBatch(t *testing.T) {
+	var active, peak atomic.Int32
+	var jobs []Job
+	for i := range 10 {
+		jobs = append(jobs, New(fmt.Sprintf("job-%d", i), Wrap(func(ctx context.Context) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			if i == 3 {
+				return ferrors.Newf("job %d failed", i)
+			}
+			return nil
+		})))
+	}
+
+	results := RunBatch(context.Background(), jobs, 3)
+	if len(results) != len(jobs) {
+		t.Fatalf("expected %d results, got %d", len(jobs), len(results))
+	}
+	if p := peak.Load(); p > 3 {
+		t.Fatalf("expected at most 3 active jobs, got %d", p)
+	}
+	for id, err := range results {
+		if id == "job-3" {
+			if err == nil {
+				t.Errorf("expected %s to fail", id)
+			}
+		} else if err != nil {
+			t.Errorf("expected %s to succeed, got %s", id, err)
+		}
+	}
+}
+
+func TestRunBatchCanceled(t *testing.T) {
+	var started atomic.Int32
+	var jobs []Job
+	for i := range 6 {
+		jobs = append(jobs, New(fmt.Sprintf("job-%d", i), Wrap(func(ctx context.Context) error {
+			started.Add(1)
+			<-ctx.Done()
+			return ctx.Err()
+		})))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	results := RunBatch(ctx, jobs, 2)
+
+	if n := started.Load(); n != 2 {
+		t.Fatalf("expected 2 jobs to start, got %d", n)
+	}
+	if len(results) != len(jobs) {
+		t.Fatalf("expected %d results, got %d", len(jobs), len(results))
+	}
+	for id, err := range results {
+		if !ferrors.Is(err, ferrors.Cancaled) {
+			t.Errorf("expected %s to be canceled, got %v", id, err)
+		}
+	}
+}
+
+func TestRunBatchWeighted(t *testing.T) {
+	var inFlight, peak, heavy, heavyPeak atomic.Int32
+	track := func(n *atomic.Int32, p *atomic.Int32, delta int32) {
+		v := n.Add(delta)
+		for {
+			old := p.Load()
+			if v <= old || p.CompareAndSwap(old, v) {
+				break
+			}
+		}
+	}
+	var jobs []Job
+	for i := range 8 {
+		weight := 1
+		if i%2 == 0 {
+			weight = 3
+		}
+		jobs = append(jobs, New(fmt.Sprintf("job-%d", i), Wrap(func(ctx context.Context) error {
+			track(&inFlight, &peak, int32(weight))
+			defer inFlight.Add(-int32(weight))
+			if weight == 3 {
+				track(&heavy, &heavyPeak, 1)
+				defer heavy.Add(-1)
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}), WithWeight(weight)))
+	}
+	// heavier than the whole batch, still runs on its own
+	jobs = append(jobs, New("job-huge", Wrap(func(ctx context.Context) error {
+		return nil
+	}), WithWeight(10)))
+
+	results := RunBatch(context.Background(), jobs, 4)
+	if len(results) != len(jobs) {
+		t.Fatalf("expected %d results, got %d", len(jobs), len(results))
+	}
+	for id, err := range results {
+		if err != nil {
+			t.Errorf("expected %s to succeed, got %s", id, err)
+		}
+	}
+	if p := peak.Load(); p > 4 {
+		t.Fatalf("expected a weight of at most 4 in flight, got %d", p)
+	}
+	if p := heavyPeak.Load(); p != 1 {
+		t.Fatalf("expected heavy jobs to run one at a time, got %d", p)
+	}
+}
@@ -0,0 +1,64 @@
+package job
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long executors remember an idempotency key by
+// default, see WithIdempotencyKey.
+const DefaultIdempotencyTTL = time.Hour
+
+// how often Mark sweeps the expired keys of a memory deduper
+const dedupePruneInterval = time.Minute
+
+// DefaultDeduper is the in-process Deduper executors consult unless given
+// another one. Deployments running a job on several instances need a shared
+// one, e.g. backed by redis.
+var DefaultDeduper = NewMemoryDeduper()
+
+var _ Deduper = (*memoryDeduper)(nil)
+
+type memoryDeduper struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	pruned  time.Time
+}
+
+// NewMemoryDeduper returns a Deduper remembering keys in memory. An expired
+// key is dropped when it is looked up, the rest by a sweep Mark runs at most
+// once a minute.
+func NewMemoryDeduper() Deduper {
+	return &memoryDeduper{
+		expires: make(map[string]time.Time),
+	}
+}
+
+func (d *memoryDeduper) SeenRecently(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expires, ok := d.expires[key]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(expires) {
+		delete(d.expires, key)
+		return false
+	}
+	return true
+}
+
+func (d *memoryDeduper) Mark(key string, ttl time.Duration) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if now.Sub(d.pruned) >= dedupePruneInterval {
+		for k, expires := range d.expires {
+			if !now.Before(expires) {
+				delete(d.expires, k)
+			}
+		}
+		d.pruned = now
+	}
+	d.expires[key] = now.Add(ttl)
+}
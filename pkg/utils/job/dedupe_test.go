@@ -0,0 +1,38 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryDeduperPrune(t *testing.T) {
+	d := NewMemoryDeduper().(*memoryDeduper)
+	d.Mark("a", time.Millisecond)
+	d.Mark("b", time.Millisecond)
+	d.Mark("c", time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	// a lookup drops an expired key
+	if d.SeenRecently("a") {
+		t.Fatal("expired key a reported as seen")
+	}
+	if _, ok := d.expires["a"]; ok {
+		t.Fatal("expired key a was not dropped on lookup")
+	}
+	// marking does not sweep again within the prune interval
+	d.Mark("d", time.Hour)
+	if _, ok := d.expires["b"]; !ok {
+		t.Fatal("expired key b was swept before the prune interval")
+	}
+	// but does once it passed
+	d.pruned = time.Now().Add(-dedupePruneInterval)
+	d.Mark("e", time.Hour)
+	if _, ok := d.expires["b"]; ok {
+		t.Fatal("expired key b was not swept")
+	}
+	for _, key := range []string{"c", "d", "e"} {
+		if !d.SeenRecently(key) {
+			t.Fatalf("key %s not seen", key)
+		}
+	}
+}
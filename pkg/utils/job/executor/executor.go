@@ -2,23 +2,41 @@ package executor
 
 import (
 	"context"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/avast/retry-go/v4"
 
 	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/job"
+	"github.com/xhanio/framingo/pkg/utils/timeutil"
 )
 
 var _ Executor = (*executor)(nil)
 
+// AlreadyDone is the category of the error Start returns instead of running a
+// job whose idempotency key already succeeded, see job.WithIdempotencyKey.
+var AlreadyDone = errors.NewCategory("AlreadyDone", http.StatusConflict)
+
 type executor struct {
 	j          job.Job
 	once       bool
+	dryRun     bool
 	timeout    *timeoutOptions
 	retry      *retryOptions
 	cooldown   *cooldownOptions
+	nextRun    *nextRunOptions
+	prefetch   *prefetchOptions
+	deduper    job.Deduper
+	dedupeTTL  time.Duration
 	onComplete func(job.Job)
+	metrics    func(*Stats, error)
+	clock      timeutil.Clock
+
+	mu      sync.Mutex
+	resumed chan struct{} // non-nil while paused, closed on Resume
 }
 
 func New(j job.Job, opts ...Option) Executor {
@@ -27,7 +45,10 @@ func New(j job.Job, opts ...Option) Executor {
 
 func newExecuter(j job.Job, opts ...Option) *executor {
 	e := &executor{
-		j: j,
+		j:         j,
+		clock:     timeutil.RealClock,
+		deduper:   job.DefaultDeduper,
+		dedupeTTL: job.DefaultIdempotencyTTL,
 	}
 	e.apply(opts...)
 	return e
@@ -45,6 +66,12 @@ func (e *executor) Reset() {
 		e.cooldown.endedAt = time.Time{}
 		e.cooldown.Unlock()
 	}
+	if e.nextRun != nil {
+		e.nextRun.Lock()
+		e.nextRun.delay = 0
+		e.nextRun.scheduled = false
+		e.nextRun.Unlock()
+	}
 }
 
 func (e *executor) run(ctx context.Context, params any) error {
@@ -68,20 +95,39 @@ func (e *executor) Start(ctx context.Context, params any) error {
 		if e.once {
 			return errors.Conflict.Newf("job can only start once")
 		}
-		if e.cooldown != nil && time.Now().Before(e.cooldown.endedAt) {
-			return errors.Conflict.Newf("job is still in cooldown, %s left", time.Until(e.cooldown.endedAt).Round(time.Second).String())
+		if left, cooling := e.isCooling(); cooling {
+			return errors.Conflict.Newf("job is still in cooldown, %s left", left.Round(time.Second).String())
 		}
 	}
+	key := e.j.IdempotencyKey()
+	if key != "" && !e.dryRun && e.deduper.SeenRecently(key) {
+		return AlreadyDone.Newf("job %s with idempotency key %s already ran", e.j.ID(), key)
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if e.dryRun {
+		ctx = job.DryRunContext(ctx)
+	}
+	e.stopPrefetch()
 
 	var err error
+	if e.metrics != nil {
+		defer func() { e.metrics(e.Stats(), err) }()
+	}
 	if e.retry != nil {
 		// with retries - works regardless of Once setting
 		// Once means "can only start once", retry means "retry within this execution"
+		var attempt int
+		var paused time.Duration
 		err = retry.Do(
 			func() error {
+				if attempt > 0 {
+					if err := e.waitResumed(ctx, &paused); err != nil {
+						return retry.Unrecoverable(err)
+					}
+				}
+				attempt++
 				return e.run(ctx, params)
 			},
 			retry.Attempts(uint(e.retry.Attempts)),
@@ -95,13 +141,31 @@ func (e *executor) Start(ctx context.Context, params any) error {
 		err = e.run(ctx, params)
 	}
 
+	if key != "" && err == nil && !e.dryRun {
+		// only successful runs count, failed ones may be submitted again
+		e.deduper.Mark(key, e.dedupeTTL)
+	}
+
 	// Set cooldown after job completes
 	if e.cooldown != nil {
 		e.cooldown.Lock()
-		e.cooldown.endedAt = time.Now().Add(e.cooldown.Duration)
+		e.cooldown.endedAt = e.clock.Now().Add(e.cooldown.Duration)
 		e.cooldown.Unlock()
 	}
 
+	// Let self-rescheduling jobs decide when to run again
+	if e.nextRun != nil {
+		delay, ok := e.nextRun.fn(e.Stats())
+		e.nextRun.Lock()
+		e.nextRun.delay = delay
+		e.nextRun.scheduled = ok
+		e.nextRun.Unlock()
+	}
+
+	if _, scheduled := e.NextRun(); e.prefetch != nil && !e.once && (e.nextRun == nil || scheduled) {
+		e.startPrefetch(ctx)
+	}
+
 	if e.onComplete != nil {
 		e.onComplete(e.j)
 	}
@@ -109,7 +173,26 @@ func (e *executor) Start(ctx context.Context, params any) error {
 	return err
 }
 
+func (e *executor) StartResult(ctx context.Context, params any) (any, error) {
+	if err := e.Start(ctx, params); err != nil {
+		return nil, err
+	}
+	return e.j.Result(), nil
+}
+
+// StartResultAs runs e like StartResult and returns the result as a T, see
+// job.DecodeResult.
+func StartResultAs[T any](ctx context.Context, e Executor, params any) (T, error) {
+	result, err := e.StartResult(ctx, params)
+	if err != nil {
+		var v T
+		return v, err
+	}
+	return job.DecodeResult[T](result)
+}
+
 func (e *executor) Stop(wait bool) error {
+	e.stopPrefetch()
 	canceling := e.j.Cancel()
 	if canceling && wait {
 		e.j.Wait()
@@ -117,23 +200,144 @@ func (e *executor) Stop(wait bool) error {
 	return nil
 }
 
+func (e *executor) Pause() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.resumed == nil {
+		e.resumed = make(chan struct{})
+	}
+}
+
+func (e *executor) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.resumed != nil {
+		close(e.resumed)
+		e.resumed = nil
+	}
+}
+
+func (e *executor) isPaused() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.resumed != nil
+}
+
+// waitResumed blocks while the executor is paused, giving up once ctx is done
+// or the time paused during this Start, tracked in paused, adds up to the
+// configured timeout.
+func (e *executor) waitResumed(ctx context.Context, paused *time.Duration) error {
+	e.mu.Lock()
+	resumed := e.resumed
+	e.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	start := time.Now()
+	defer func() { *paused += time.Since(start) }()
+	var expired <-chan time.Time
+	if e.timeout != nil {
+		timer := time.NewTimer(max(e.timeout.Duration-*paused, 0))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return errutil.FromContext(ctx)
+	case <-expired:
+		return errutil.Timeout.Newf("job %s stayed paused for more than %s", e.j.ID(), e.timeout.Duration)
+	}
+}
+
+// startPrefetch runs the prefetch step in the background until it returns,
+// the cooldown ends or stopPrefetch cancels it.
+func (e *executor) startPrefetch(ctx context.Context) {
+	p := e.prefetch
+	var pctx context.Context
+	var cancel context.CancelFunc
+	if e.cooldown != nil {
+		pctx, cancel = context.WithTimeout(ctx, e.cooldown.Duration)
+	} else {
+		pctx, cancel = context.WithCancel(ctx)
+	}
+	done := make(chan struct{})
+	p.Lock()
+	p.cancel, p.done = cancel, done
+	p.Unlock()
+	go func() {
+		defer close(done)
+		defer cancel()
+		err := p.fn(pctx)
+		p.Lock()
+		defer p.Unlock()
+		if p.done == done {
+			// not canceled by stopPrefetch
+			p.err = err
+		}
+	}()
+}
+
+// stopPrefetch cancels a running prefetch step and waits for it to return.
+func (e *executor) stopPrefetch() {
+	if e.prefetch == nil {
+		return
+	}
+	p := e.prefetch
+	p.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done = nil, nil
+	p.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (e *executor) prefetchErr() error {
+	if e.prefetch == nil {
+		return nil
+	}
+	e.prefetch.Lock()
+	defer e.prefetch.Unlock()
+	return e.prefetch.err
+}
+
 func (e *executor) isCooling() (time.Duration, bool) {
 	if e.cooldown == nil {
 		return 0, false
 	}
 	e.cooldown.RLock()
 	defer e.cooldown.RUnlock()
-	d := time.Until(e.cooldown.endedAt)
+	d := e.cooldown.endedAt.Sub(e.clock.Now())
 	return d, d > 0
 }
 
+func (e *executor) NextRun() (time.Duration, bool) {
+	if e.nextRun == nil {
+		return 0, false
+	}
+	e.nextRun.RLock()
+	defer e.nextRun.RUnlock()
+	return e.nextRun.delay, e.nextRun.scheduled
+}
+
 func (e *executor) Stats() *Stats {
 	cooldown, _ := e.isCooling()
 	stat := &Stats{
 		Cooldown: cooldown,
+		Paused:   e.isPaused(),
+		Job:      e.j.Stats(),
 	}
 	if e.retry != nil {
 		stat.Retries = e.retry.attempted
 	}
+	if delay, ok := e.NextRun(); ok {
+		stat.NextRun = delay
+	}
+	if err := e.prefetchErr(); err != nil {
+		stat.PrefetchError = err.Error()
+	}
 	return stat
 }
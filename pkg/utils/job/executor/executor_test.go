@@ -2,12 +2,16 @@ package executor
 
 import (
 	"context"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/job"
+	"github.com/xhanio/framingo/pkg/utils/timeutil"
 )
 
 func TestJobRetry(t *testing.T) {
@@ -207,7 +211,8 @@ func TestCooldown(t *testing.T) {
 		return nil
 	}))
 
-	je := New(j, WithCooldown(2*time.Second))
+	clock := timeutil.NewFakeClock(time.Now())
+	je := New(j, WithCooldown(2*time.Second), WithClock(clock))
 
 	// First execution
 	err := je.Start(context.Background(), nil)
@@ -220,11 +225,8 @@ func TestCooldown(t *testing.T) {
 	if stats == nil {
 		t.Fatal("Stats should not be nil")
 	}
-	if stats.Cooldown <= 0 {
-		t.Error("expected positive cooldown duration after job completion")
-	}
-	if stats.Cooldown > 2*time.Second {
-		t.Errorf("cooldown duration should be <= 2s, got %v", stats.Cooldown)
+	if stats.Cooldown != 2*time.Second {
+		t.Errorf("expected cooldown of 2s on a stopped clock, got %v", stats.Cooldown)
 	}
 
 	// Try to start again immediately (should fail due to cooldown)
@@ -233,8 +235,20 @@ func TestCooldown(t *testing.T) {
 		t.Fatalf("expected cooldown error, got: %v", err)
 	}
 
-	// Wait for cooldown to expire
-	time.Sleep(2100 * time.Millisecond)
+	// Still cooling just before the end
+	clock.Advance(1900 * time.Millisecond)
+	if cooldown := je.Stats().Cooldown; cooldown != 100*time.Millisecond {
+		t.Errorf("expected 100ms cooldown left, got %v", cooldown)
+	}
+	if err := je.Start(context.Background(), nil); err == nil {
+		t.Fatal("expected cooldown error before expiry")
+	}
+
+	// Expire the cooldown
+	clock.Advance(100 * time.Millisecond)
+	if cooldown := je.Stats().Cooldown; cooldown != 0 {
+		t.Errorf("expected no cooldown left, got %v", cooldown)
+	}
 
 	// Should be able to start again
 	err = je.Start(context.Background(), nil)
@@ -520,3 +534,427 @@ func TestCooldownWithRetry(t *testing.T) {
 		t.Fatalf("execution after cooldown failed: %v", err)
 	}
 }
+
+func TestNextRun(t *testing.T) {
+	const base = 1 * time.Second
+	fail := true
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		if fail {
+			return errors.Newf("poll failed")
+		}
+		return nil
+	}))
+
+	delay := base
+	je := New(j, WithNextRun(func(stats *Stats) (time.Duration, bool) {
+		if stats.Job.Error != "" {
+			delay /= 2 // poll sooner on failure
+		} else {
+			delay *= 2 // back off on success
+		}
+		return delay, true
+	}))
+
+	if _, ok := je.NextRun(); ok {
+		t.Fatal("next run should not be scheduled before first start")
+	}
+
+	_ = je.Start(context.Background(), nil)
+	next, ok := je.NextRun()
+	if !ok || next != base/2 {
+		t.Fatalf("expected next run in %s after failure, got %s (scheduled: %v)", base/2, next, ok)
+	}
+	if je.Stats().NextRun != base/2 {
+		t.Errorf("expected stats next run %s, got %s", base/2, je.Stats().NextRun)
+	}
+
+	fail = false
+	if err := je.Start(context.Background(), nil); err != nil {
+		t.Fatalf("job failed: %v", err)
+	}
+	next, ok = je.NextRun()
+	if !ok || next != base {
+		t.Fatalf("expected next run in %s after success, got %s (scheduled: %v)", base, next, ok)
+	}
+}
+
+func TestNextRunStop(t *testing.T) {
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		return nil
+	}))
+	je := New(j, WithNextRun(func(stats *Stats) (time.Duration, bool) {
+		return 0, false
+	}))
+	if err := je.Start(context.Background(), nil); err != nil {
+		t.Fatalf("job failed: %v", err)
+	}
+	if _, ok := je.NextRun(); ok {
+		t.Fatal("next run should not be scheduled")
+	}
+}
+
+func TestPause(t *testing.T) {
+	var attempts atomic.Int32
+	var je Executor
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		if attempts.Add(1) == 1 {
+			je.Pause()
+		}
+		return errors.Newf("error occurred")
+	}))
+	je = New(j, WithRetry(3, 10*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- je.Start(context.Background(), nil)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	if n := attempts.Load(); n != 1 {
+		t.Fatalf("expected 1 attempt while paused, got %d", n)
+	}
+	if !je.Stats().Paused {
+		t.Fatal("expected executor to report paused")
+	}
+
+	je.Resume()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("failed job completed without error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("executor did not continue after resume")
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Fatalf("expected 3 attempts after resume, got %d", n)
+	}
+}
+
+func TestPauseTimeoutBudget(t *testing.T) {
+	var attempts atomic.Int32
+	paused := make(chan struct{}, 5)
+	var je Executor
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		attempts.Add(1)
+		je.Pause()
+		paused <- struct{}{}
+		return errors.Newf("error occurred")
+	}))
+	je = New(j, WithRetry(5, time.Millisecond), WithTimeout(500*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- je.Start(context.Background(), nil)
+	}()
+	// every pause alone fits in the timeout, but the first two exceed it
+	for {
+		select {
+		case err := <-done:
+			if err == nil || !strings.Contains(err.Error(), "stayed paused") {
+				t.Fatalf("expected paused timeout, got %v", err)
+			}
+			if n := attempts.Load(); n != 2 {
+				t.Fatalf("expected 2 attempts within the timeout, got %d", n)
+			}
+			return
+		case <-paused:
+			time.AfterFunc(400*time.Millisecond, je.Resume)
+		}
+	}
+}
+
+func TestStartResult(t *testing.T) {
+	type report struct {
+		Count int `json:"count"`
+	}
+	succeeded := New(job.New("", func(jc job.Context) error {
+		jc.SetResult(map[string]int{"count": 3})
+		return nil
+	}))
+	result, err := succeeded.StartResult(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r, ok := result.(map[string]int); !ok || r["count"] != 3 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	typed, err := StartResultAs[report](context.Background(), succeeded, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if typed.Count != 3 {
+		t.Fatalf("expected count 3, got %d", typed.Count)
+	}
+
+	failed := New(job.New("", func(jc job.Context) error {
+		jc.SetResult("partial")
+		return errors.BadRequest.Newf("bad input")
+	}))
+	result, err = failed.StartResult(context.Background(), nil)
+	if !errors.Is(err, errors.BadRequest) {
+		t.Fatalf("expected bad request error, got %v", err)
+	}
+	if result != nil {
+		t.Fatalf("expected no result for a failed run, got %v", result)
+	}
+	if _, err := StartResultAs[report](context.Background(), failed, nil); !errors.Is(err, errors.BadRequest) {
+		t.Fatalf("expected bad request error, got %v", err)
+	}
+}
+
+func explode(m map[string]int) {
+	m["boom"]++ // nil map
+}
+
+func TestPanicStack(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"once":  nil,
+		"retry": {WithRetry(2, 0)},
+	} {
+		j := job.New("", job.Wrap(func(ctx context.Context) error {
+			explode(nil)
+			return nil
+		}))
+		err := New(j, opts...).Start(context.Background(), nil)
+		if err == nil {
+			t.Fatalf("%s: expected panic error", name)
+		}
+		// the innermost frame of this package is the function that panicked,
+		// not the test that started the job
+		stack := errutil.FormatStack(err)
+		i := slices.IndexFunc(stack, func(frame string) bool {
+			return strings.Contains(frame, "/job/executor.")
+		})
+		if i < 0 || !strings.HasSuffix(stack[i], "/job/executor.explode") {
+			t.Fatalf("%s: expected stack to lead to the panicking function, got %v", name, stack)
+		}
+	}
+}
+
+func TestMetricsHook(t *testing.T) {
+	type call struct {
+		retries uint
+		err     error
+	}
+	var calls []call
+	hook := WithMetricsHook(func(stats *Stats, err error) {
+		calls = append(calls, call{retries: stats.Retries, err: err})
+	})
+
+	attempt := 0
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		attempt++
+		if attempt < 3 {
+			return errors.Newf("temporary error")
+		}
+		return nil
+	}))
+	je := New(j, WithRetry(3, 10*time.Millisecond), hook)
+	if err := je.Start(context.Background(), nil); err != nil {
+		t.Fatalf("job should succeed after retries, got: %v", err)
+	}
+	if len(calls) != 1 || calls[0].retries != 2 || calls[0].err != nil {
+		t.Fatalf("expected one call with 2 retries and no error, got %+v", calls)
+	}
+
+	calls = nil
+	j = job.New("", job.Wrap(func(ctx context.Context) error {
+		return errors.Newf("permanent error")
+	}))
+	je = New(j, WithRetry(3, 10*time.Millisecond), Once(), hook)
+	err := je.Start(context.Background(), nil)
+	if err == nil || len(calls) != 1 || calls[0].retries != 3 || calls[0].err == nil || calls[0].err.Error() != err.Error() {
+		t.Fatalf("expected one call with 3 retries and the start error, got %+v", calls)
+	}
+
+	calls = nil
+	j = job.New("", job.Wrap(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	je = New(j, WithTimeout(50*time.Millisecond), hook)
+	err = je.Start(context.Background(), nil)
+	if err == nil || len(calls) != 1 || calls[0].err == nil || calls[0].err.Error() != err.Error() {
+		t.Fatalf("expected one call with the timeout error, got %+v", calls)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	var runs atomic.Int32
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+	started := make(chan struct{}, 2)
+	ended := make(chan error, 2)
+	clock := timeutil.NewFakeClock(time.Now())
+	je := New(j, WithCooldown(time.Hour), WithClock(clock), WithPrefetch(func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		ended <- ctx.Err()
+		return ctx.Err()
+	}))
+
+	if err := je.Start(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("expected prefetch to run during cooldown")
+	}
+	// a start rejected by the cooldown leaves the prefetch running
+	if err := je.Start(context.Background(), nil); err == nil {
+		t.Fatal("expected cooldown error")
+	}
+	select {
+	case err := <-ended:
+		t.Fatalf("expected prefetch to keep running, it ended with %v", err)
+	default:
+	}
+
+	// the next start cancels the prefetch before running the job
+	clock.Advance(time.Hour)
+	if err := je.Start(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-ended:
+		if err != context.Canceled {
+			t.Fatalf("expected prefetch to be canceled, got %v", err)
+		}
+	default:
+		t.Fatal("expected prefetch to have returned once Start ran")
+	}
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("expected 2 runs, got %d", n)
+	}
+	if stats := je.Stats(); stats.PrefetchError != "" {
+		t.Errorf("expected no prefetch error for a canceled prefetch, got %s", stats.PrefetchError)
+	}
+	<-started
+	if err := je.Stop(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-ended; err != context.Canceled {
+		t.Fatalf("expected Stop to cancel the prefetch, got %v", err)
+	}
+}
+
+func TestPrefetchBoundedByCooldown(t *testing.T) {
+	j := job.New("", job.Wrap(func(ctx context.Context) error {
+		return nil
+	}))
+	ended := make(chan error, 1)
+	je := New(j, WithCooldown(50*time.Millisecond), WithPrefetch(func(ctx context.Context) error {
+		<-ctx.Done()
+		ended <- ctx.Err()
+		return errors.Newf("cache not warmed")
+	}))
+	if err := je.Start(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-ended:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expected prefetch to end with the cooldown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected prefetch to end with the cooldown")
+	}
+	for deadline := time.Now().Add(time.Second); je.Stats().PrefetchError == "" && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := je.Stats(); !strings.Contains(stats.PrefetchError, "cache not warmed") {
+		t.Errorf("expected the prefetch error in stats, got %q", stats.PrefetchError)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	var runs atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	submit := func() job.Job {
+		return job.New("", job.Wrap(func(ctx context.Context) error {
+			runs.Add(1)
+			if fail.Load() {
+				return errors.Newf("transient failure")
+			}
+			return nil
+		}), job.WithIdempotencyKey("order-42"))
+	}
+	deduper := job.NewMemoryDeduper()
+
+	// failed runs are not remembered, so the submission can be retried
+	if err := New(submit(), WithDeduper(deduper, time.Minute)).Start(context.Background(), nil); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+	fail.Store(false)
+	if err := New(submit(), WithDeduper(deduper, time.Minute)).Start(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	// the duplicate submission is skipped
+	err := New(submit(), WithDeduper(deduper, time.Minute)).Start(context.Background(), nil)
+	if !errors.Is(err, AlreadyDone) {
+		t.Fatalf("expected AlreadyDone, got %v", err)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("expected 2 runs, got %d", n)
+	}
+
+	// once the ttl passed the key runs again
+	deduper.Mark("order-42", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err := New(submit(), WithDeduper(deduper, time.Minute)).Start(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := runs.Load(); n != 3 {
+		t.Fatalf("expected 3 runs, got %d", n)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var applied, spawnedDry atomic.Bool
+	j := job.New("dry-run", func(ctx job.Context) error {
+		child := ctx.Spawn("child", func(ctx job.Context) error {
+			spawnedDry.Store(ctx.IsDryRun())
+			return nil
+		})
+		child.Wait()
+		if ctx.IsDryRun() {
+			ctx.SetResult("would apply")
+			return nil
+		}
+		applied.Store(true)
+		ctx.SetResult("applied")
+		return nil
+	}, job.WithIdempotencyKey("dry-run-key"))
+
+	deduper := job.NewMemoryDeduper()
+	result, err := New(j, DryRun(), WithDeduper(deduper, time.Minute)).StartResult(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if applied.Load() || result != "would apply" {
+		t.Fatalf("dry run applied its changes, result %v", result)
+	}
+	if !spawnedDry.Load() {
+		t.Fatal("spawned child did not inherit the dry run")
+	}
+	if !j.Stats().DryRun {
+		t.Fatal("stats do not record the dry run")
+	}
+
+	// the dry run left the idempotency key unmarked
+	result, err = New(j, WithDeduper(deduper, time.Minute)).StartResult(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !applied.Load() || result != "applied" {
+		t.Fatalf("run did not apply its changes, result %v", result)
+	}
+	if j.Stats().DryRun {
+		t.Fatal("stats record a dry run")
+	}
+}
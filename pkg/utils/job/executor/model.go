@@ -3,15 +3,35 @@ package executor
 import (
 	"context"
 	"time"
+
+	"github.com/xhanio/framingo/pkg/utils/job"
 )
 
 type Stats struct {
 	Retries  uint          `json:"retries"`
 	Cooldown time.Duration `json:"cooldown"`
+	NextRun  time.Duration `json:"next_run,omitempty"`
+	Paused   bool          `json:"paused,omitempty"`
+	// PrefetchError is the error of the last prefetch step that was not
+	// canceled by Start or Stop, see WithPrefetch.
+	PrefetchError string     `json:"prefetch_error,omitempty"`
+	Job           *job.Stats `json:"job,omitempty"`
 }
 
 type Executor interface {
 	Start(ctx context.Context, params any) error
+	// StartResult runs the job like Start and returns its result, or nil
+	// along with the error if the run failed.
+	StartResult(ctx context.Context, params any) (any, error)
 	Stop(wait bool) error
 	Stats() *Stats
+	// NextRun reports the delay until the job should run again, as decided by
+	// WithNextRun after the last Start, or false if it should not run again.
+	NextRun() (time.Duration, bool)
+	// Pause holds the executor before its next retry attempt until Resume is
+	// called or the time paused during the Start adds up to the timeout. Time
+	// spent paused does not count against the cooldown, which only starts once
+	// Start returns.
+	Pause()
+	Resume()
 }
@@ -1,10 +1,12 @@
 package executor
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/xhanio/framingo/pkg/utils/job"
+	"github.com/xhanio/framingo/pkg/utils/timeutil"
 )
 
 type Option func(*executor)
@@ -91,6 +93,17 @@ func WithCooldown(cooldown time.Duration) Option {
 	}
 }
 
+// WithClock sets the clock cooldowns are measured against, time.Now by
+// default. Tests pass a timeutil.FakeClock to expire cooldowns without sleeping.
+func WithClock(clock timeutil.Clock) Option {
+	return func(e *executor) {
+		if clock == nil {
+			return
+		}
+		e.clock = clock
+	}
+}
+
 // Once configures the executor to allow only a single successful Start() call.
 // After the first successful execution, subsequent Start() calls will return an error.
 //
@@ -113,8 +126,124 @@ func Once() Option {
 	}
 }
 
+// DryRun makes every Start a dry run, to validate the wiring of a job without
+// side effects: the job function sees IsDryRun on its context and is expected
+// to skip its mutations, and the job stats record the run as a dry run. A dry
+// run ignores the idempotency key of the job: it runs even if the key already
+// succeeded, and does not mark it as done.
+//
+// Example:
+//
+//	je := New(job.New("sync", func(ctx job.Context) error {
+//		changes := diff()
+//		if ctx.IsDryRun() {
+//			ctx.SetResult(changes)
+//			return nil
+//		}
+//		return apply(changes)
+//	}), DryRun())
+func DryRun() Option {
+	return func(e *executor) {
+		e.dryRun = true
+	}
+}
+
+type nextRunOptions struct {
+	fn func(stats *Stats) (time.Duration, bool)
+
+	sync.RWMutex
+	delay     time.Duration
+	scheduled bool
+}
+
+// WithNextRun lets a self-rescheduling job decide when it should run again.
+// fn is called with the executor stats after every Start() and returns the delay
+// until the next run, or false to stop rescheduling. The result is reported by
+// NextRun(), e.g. for the task manager to re-queue the task.
+//
+// Example (adaptive polling):
+//
+//	je := New(job, WithNextRun(func(stats *Stats) (time.Duration, bool) {
+//		if stats.Job.Error != "" {
+//			return 5 * time.Second, true // retry soon on failure
+//		}
+//		return time.Minute, true
+//	}))
+func WithNextRun(fn func(stats *Stats) (time.Duration, bool)) Option {
+	return func(e *executor) {
+		if fn == nil {
+			return
+		}
+		e.nextRun = &nextRunOptions{
+			fn: fn,
+		}
+	}
+}
+
+type prefetchOptions struct {
+	fn func(ctx context.Context) error
+
+	sync.Mutex
+	cancel context.CancelFunc // nil when no prefetch is running
+	done   chan struct{}
+	err    error
+}
+
+// WithPrefetch runs fn in the background after every Start, to prepare the
+// next run while the executor is idle, e.g. to warm a cache between scheduled
+// runs. Its context is canceled when the next Start or Stop arrives, which
+// waits for fn to return, so fn never overlaps the job and must return
+// promptly once ctx is done. With a cooldown fn is also bounded by it. It is
+// skipped when there is no next run: with Once, or when WithNextRun decided
+// against one. fn is best effort, the job must not depend on it; its last
+// error is reported in Stats.
+func WithPrefetch(fn func(ctx context.Context) error) Option {
+	return func(e *executor) {
+		if fn == nil {
+			return
+		}
+		e.prefetch = &prefetchOptions{
+			fn: fn,
+		}
+	}
+}
+
+// WithDeduper sets where the idempotency keys of jobs are remembered, and for
+// how long, job.DefaultDeduper and job.DefaultIdempotencyTTL by default. Start
+// returns an AlreadyDone error instead of running a job whose key succeeded
+// within ttl. The check is not atomic with the run, so two Starts of the same
+// key racing each other may both run.
+func WithDeduper(d job.Deduper, ttl time.Duration) Option {
+	return func(e *executor) {
+		if d != nil {
+			e.deduper = d
+		}
+		if ttl > 0 {
+			e.dedupeTTL = ttl
+		}
+	}
+}
+
 func OnComplete(fn func(job.Job)) Option {
 	return func(e *executor) {
 		e.onComplete = fn
 	}
 }
+
+// WithMetricsHook calls fn after every Start that ran the job, with the
+// executor stats and the error Start returns, so the retries, execution time
+// and outcome can be reported to any metrics system. It is also called when
+// the job timed out or was canceled, but not when Start is rejected because
+// of Once, the cooldown or an idempotency key that already ran.
+//
+// Example:
+//
+//	je := New(job, WithRetry(3, time.Second), WithMetricsHook(func(stats *Stats, err error) {
+//		retries.Add(float64(stats.Retries))
+//		duration.Observe(stats.Job.ExecutionTime.Seconds())
+//	}))
+func WithMetricsHook(fn func(stats *Stats, err error)) Option {
+	return func(e *executor) {
+		e.metrics = fn
+	}
+}
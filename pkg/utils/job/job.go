@@ -2,6 +2,12 @@ package job
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
 	"sync"
 	"time"
 
@@ -10,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -22,20 +29,40 @@ type job struct {
 
 	log log.Logger
 
+	onStateChange func(old, new State)
+
+	heartbeatTimeout time.Duration
+	weight           int
+	idempotencyKey   string
+
 	sync.RWMutex // state lock
 	state        State
-	params       any // input
-	result       any // output
+	params       any             // input
+	result       any             // output
+	resultStats  json.RawMessage // result serialized for Stats, nil if too large
 	err          error
 	createdAt    time.Time
 	startedAt    time.Time
 	endedAt      time.Time
 
 	progress float64
+	stage    string // reported with SetStage, progress is the stage's
+	reason   string // why the job was canceled, empty for a plain Cancel
+	cause    error  // cancellation cause carrying the reason
+
+	lastHeartbeat time.Time
+	stalled       bool // canceled for missing heartbeats
+	dryRun        bool // run with DryRunContext
+
+	finalizers []func() // registered with Defer during the current run
+
+	detachChildren bool
+	parent         *job   // the job that started this one with Spawn, if any
+	children       []*job // started with Spawn during the current run
 
 	wg     *sync.WaitGroup
 	ctx    context.Context
-	cancel context.CancelFunc
+	cancel context.CancelCauseFunc
 }
 
 func New(id string, fn Func, opts ...Option) Job {
@@ -69,12 +96,55 @@ func (j *job) Key() string {
 	return j.id
 }
 
-func (j *job) initialize() {
-	j.state = StateRunning
+func (j *job) Clone() Job {
+	j.RLock()
+	defer j.RUnlock()
+	return &job{
+		id:               j.id,
+		labels:           maps.Clone(j.labels),
+		fn:               j.fn,
+		log:              j.log,
+		onStateChange:    j.onStateChange,
+		heartbeatTimeout: j.heartbeatTimeout,
+		weight:           j.weight,
+		idempotencyKey:   j.idempotencyKey,
+		detachChildren:   j.detachChildren,
+		state:            StateCreated,
+		createdAt:        time.Now(),
+		wg:               &sync.WaitGroup{},
+		progress:         -1,
+	}
+}
+
+// setState must be called with the lock held. It returns the previous state
+// so the caller can notify after unlocking.
+func (j *job) setState(state State) State {
+	old := j.state
+	j.state = state
+	return old
+}
+
+// notify fires the state change callback. It must be called without the lock held.
+func (j *job) notify(old, new State) {
+	if j.onStateChange != nil && old != new {
+		j.onStateChange(old, new)
+	}
+}
+
+func (j *job) initialize() State {
+	old := j.setState(StateRunning)
 	j.startedAt = time.Now()
 	j.endedAt = time.Time{}
 	j.result = nil
+	j.resultStats = nil
+	j.stage = ""
+	j.reason = ""
+	j.cause = nil
+	j.lastHeartbeat = j.startedAt
+	j.stalled = false
+	j.children = nil
 	// j.sendEvent(JobActionUpdate)
+	return old
 }
 
 func (j *job) Run(ctx context.Context, params any) bool {
@@ -92,36 +162,66 @@ func (j *job) Run(ctx context.Context, params any) bool {
 	go func() {
 		// finalize
 		defer func() {
+			r := recover()
+			j.waitChildren()
+			j.runFinalizers()
+			reason, cause, parentCanceled := j.parentCanceled()
 			j.Lock()
-			if r := recover(); r != nil {
-				if e, ok := r.(error); ok {
-					j.err = e
-				} else {
-					j.err = errors.Newf("job %s recovered from panic: %v", j.id, r)
-				}
+			if parentCanceled && j.state == StateRunning {
+				// the parent's context was canceled before this job ran far
+				// enough to be canceled along with it
+				j.state = StateCanceling
+				j.reason = reason
+				j.cause = cause
+			}
+			if r != nil {
+				// still on the panicking stack, so the error's stack trace
+				// points at the panic
+				j.err = errors.Wrapf(errutil.Recover(r), "job %s panicked", j.id)
 			}
 			j.endedAt = time.Now()
+			var old State
 			if j.state == StateCanceling {
-				j.state = StateCanceled
+				if j.cause != nil {
+					switch {
+					case errutil.HasCause(j.err, j.cause):
+						j.err = errors.Cancaled.Wrap(j.err)
+					case j.err != nil:
+						// surface the reason even if the job returned a generic error
+						j.err = errors.Cancaled.Wrapf(j.err, "%s", j.reason)
+					default:
+						j.err = errors.Cancaled.Wrap(j.cause)
+					}
+				}
+				old = j.setState(StateCanceled)
 			} else if j.err != nil {
 				// j.log.Error(j.err)
-				j.state = StateFailed
+				old = j.setState(StateFailed)
 			} else {
-				j.state = StateSucceeded
+				old = j.setState(StateSucceeded)
 			}
+			state := j.state
 			// j.sendEvent(JobActionUpdate)
 			j.Unlock()
+			j.notify(old, state)
 			// unblock job
 			j.wg.Done()
 		}()
 		j.Lock()
 		// initialize
-		j.initialize()
+		old := j.initialize()
 		// set params
 		j.params = params
+		j.dryRun = IsDryRunContext(ctx)
+		// set under the lock, a parent canceling its children reads it
+		// from another goroutine
+		j.ctx, j.cancel = context.WithCancelCause(ctx)
 		j.Unlock()
+		j.notify(old, StateRunning)
 
-		j.ctx, j.cancel = context.WithCancel(ctx)
+		if j.heartbeatTimeout > 0 {
+			defer j.watchHeartbeat()()
+		}
 		j.err = j.fn(j)
 	}()
 	return true
@@ -132,32 +232,110 @@ func (j *job) Wait() {
 }
 
 func (j *job) Cancel() bool {
-	if j.State() == StateRunning && j.cancel != nil {
-		j.log.Debugf("canceling job %s", j.id)
-		j.Lock()
-		j.state = StateCanceling
-		// j.sendEvent(JobActionUpdate)
+	return j.CancelWithReason("")
+}
+
+// CancelWithReason cancels the job and records why. The reason is set before
+// the job's context is done, so the job can read it via context.Cause. It is
+// reported by Err and Stats once the job ends.
+func (j *job) CancelWithReason(reason string) bool {
+	var cause error
+	if reason != "" {
+		cause = stderrors.New(reason)
+	}
+	j.Lock()
+	if j.state != StateRunning || j.cancel == nil {
 		j.Unlock()
-		j.cancel()
-		j.cancel = nil
-		return true
+		return false
+	}
+	j.log.Debugf("canceling job %s", j.id)
+	cancel := j.cancel
+	j.cancel = nil
+	old := j.setState(StateCanceling)
+	j.reason = reason
+	j.cause = cause
+	children := slices.Clone(j.children)
+	// j.sendEvent(JobActionUpdate)
+	j.Unlock()
+	// children first, so they end as canceled rather than failing on the
+	// context they inherit
+	for _, child := range children {
+		child.CancelWithReason(reason)
+	}
+	j.notify(old, StateCanceling)
+	cancel(cause)
+	return true
+}
+
+// watchHeartbeat cancels the job as stalled once no heartbeat arrives within
+// the heartbeat timeout. The returned func stops watching and waits for the
+// watcher to exit.
+func (j *job) watchHeartbeat() func() {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		timer := time.NewTimer(j.heartbeatTimeout)
+		defer timer.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-timer.C:
+			}
+			j.RLock()
+			idle := time.Since(j.lastHeartbeat)
+			j.RUnlock()
+			if idle < j.heartbeatTimeout {
+				timer.Reset(j.heartbeatTimeout - idle)
+				continue
+			}
+			j.log.Warnf("job %s stalled, no heartbeat for %s", j.id, idle.Round(time.Millisecond))
+			if j.CancelWithReason(fmt.Sprintf("job stalled, no heartbeat within %s", j.heartbeatTimeout)) {
+				j.Lock()
+				j.stalled = true
+				j.Unlock()
+			}
+			return
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
 	}
-	return false
 }
 
 func (j *job) Context() context.Context {
+	j.RLock()
+	defer j.RUnlock()
 	if j.ctx == nil {
 		return context.Background()
 	}
 	return j.ctx
 }
 
+func (j *job) Deadline() (time.Time, bool) {
+	return j.Context().Deadline()
+}
+
+func (j *job) RemainingTime() time.Duration {
+	deadline, ok := j.Deadline()
+	if !ok {
+		return math.MaxInt64
+	}
+	return max(time.Until(deadline), 0)
+}
+
 func (j *job) Result() any {
 	j.RLock()
 	defer j.RUnlock()
 	return j.result
 }
 
+func (j *job) ResultJSON() ([]byte, error) {
+	return marshalResult(j.Result())
+}
+
 func (j *job) Err() error {
 	j.RLock()
 	defer j.RUnlock()
@@ -207,6 +385,20 @@ func (j *job) Progress() float64 {
 	return j.progress
 }
 
+func (j *job) Stage() (string, float64) {
+	j.RLock()
+	defer j.RUnlock()
+	return j.stage, j.progress
+}
+
+func (j *job) Weight() int {
+	return max(j.weight, 1)
+}
+
+func (j *job) IdempotencyKey() string {
+	return j.idempotencyKey
+}
+
 func (j *job) Labels() labels.Set {
 	j.RLock()
 	defer j.RUnlock()
@@ -238,12 +430,107 @@ func (j *job) SetProgress(progress float64) {
 	j.Unlock()
 }
 
+func (j *job) SetStage(name string, progress float64) {
+	j.Lock()
+	j.stage = name
+	j.progress = progress
+	j.Unlock()
+}
+
 func (j *job) SetResult(result any) {
+	// serialized once here, outside the lock, rather than on every Stats call
+	var data []byte
+	if d, err := marshalResult(result); err == nil && len(d) <= maxStatsResultSize {
+		data = d
+	}
 	j.Lock()
 	j.result = result
+	j.resultStats = data
+	j.Unlock()
+}
+
+func (j *job) SetResultJSON(data []byte) error {
+	if !json.Valid(data) {
+		return errors.InvalidArgument.Newf("job %s result is not valid json", j.id)
+	}
+	j.SetResult(json.RawMessage(slices.Clone(data)))
+	return nil
+}
+
+func (j *job) Heartbeat() {
+	j.Lock()
+	j.lastHeartbeat = time.Now()
 	j.Unlock()
 }
 
+func (j *job) Defer(fn func()) {
+	if fn == nil {
+		return
+	}
+	j.Lock()
+	j.finalizers = append(j.finalizers, fn)
+	j.Unlock()
+}
+
+func (j *job) Spawn(id string, fn Func, opts ...Option) Job {
+	child := newJob(id, fn, opts...)
+	child.parent = j
+	j.Lock()
+	j.children = append(j.children, child)
+	j.Unlock()
+	child.Run(j.Context(), nil)
+	return child
+}
+
+// parentCanceled reports whether an ancestor that started the job with Spawn
+// is being or was canceled, returning its reason and cause.
+func (j *job) parentCanceled() (string, error, bool) {
+	for p := j.parent; p != nil; p = p.parent {
+		p.RLock()
+		state, reason, cause := p.state, p.reason, p.cause
+		p.RUnlock()
+		switch state {
+		case StateCanceling, StateCanceled:
+			return reason, cause, true
+		}
+	}
+	return "", nil, false
+}
+
+// waitChildren waits for the children started with Spawn during the current
+// run, unless they are detached.
+func (j *job) waitChildren() {
+	j.Lock()
+	children := j.children
+	j.children = nil
+	j.Unlock()
+	if j.detachChildren {
+		return
+	}
+	for _, child := range children {
+		child.Wait()
+	}
+}
+
+// runFinalizers runs the finalizers registered with Defer in LIFO order. A
+// panicking finalizer is logged and does not stop the remaining ones.
+func (j *job) runFinalizers() {
+	j.Lock()
+	finalizers := j.finalizers
+	j.finalizers = nil
+	j.Unlock()
+	for i := len(finalizers) - 1; i >= 0; i-- {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					j.log.Errorf("job %s finalizer panicked: %v", j.id, r)
+				}
+			}()
+			finalizers[i]()
+		}()
+	}
+}
+
 func (j *job) GetParams() any {
 	return j.params
 }
@@ -254,13 +541,24 @@ func (j *job) GetParams() any {
 // 	}
 // }
 
+func (j *job) IsDryRun() bool {
+	j.RLock()
+	defer j.RUnlock()
+	return j.dryRun
+}
+
 func (j *job) stats() *Stats {
 	stats := &Stats{
-		ID:        j.id,
-		State:     string(j.state),
-		Progress:  j.progress,
-		StartedAt: j.startedAt,
-		Labels:    j.labels,
+		ID:            j.id,
+		State:         string(j.state),
+		Progress:      j.progress,
+		Stage:         j.stage,
+		StartedAt:     j.startedAt,
+		Labels:        j.labels,
+		Reason:        j.reason,
+		LastHeartbeat: j.lastHeartbeat,
+		Stalled:       j.stalled,
+		DryRun:        j.dryRun,
 	}
 	if IsPending(j.state) {
 		stats.ExecutionTime = time.Since(j.startedAt)
@@ -269,7 +567,10 @@ func (j *job) stats() *Stats {
 	}
 	if j.err != nil {
 		stats.Error = j.err.Error()
+		stats.ErrorCategory = errutil.CategoryOf(j.err).Error()
+		stats.ErrorCode, _ = errutil.CodeOf(j.err)
 	}
+	stats.Result = j.resultStats
 	return stats
 }
 
@@ -3,9 +3,13 @@ package job
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
+	ferrors "github.com/xhanio/errors"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -50,7 +54,7 @@ func TestJobPanicked(t *testing.T) {
 	t.Logf("job id is %s", j.ID())
 	j.Run(context.Background(), nil)
 	j.Wait()
-	if j.Err() == nil || j.Err().Error() != "?!" {
+	if j.Err() == nil || j.Err().Error() != fmt.Sprintf("job %s panicked: ?!", j.ID()) {
 		t.Fatal("panicked job completed without error or with incorrect err message")
 	}
 }
@@ -152,6 +156,82 @@ func TestJobProgress(t *testing.T) {
 	}
 }
 
+func TestJobStage(t *testing.T) {
+	downloading := make(chan struct{})
+	processing := make(chan struct{})
+	resume := make(chan struct{})
+	j := New("", func(ctx Context) error {
+		ctx.SetStage("downloading", 0.4)
+		downloading <- struct{}{}
+		<-resume
+		ctx.SetStage("processing", 0)
+		ctx.SetProgress(0.1)
+		processing <- struct{}{}
+		<-resume
+		return nil
+	})
+	if stage, _ := j.Stage(); stage != "" {
+		t.Errorf("expected no stage before running, got %q", stage)
+	}
+
+	j.Run(context.Background(), nil)
+	<-downloading
+	if stage, progress := j.Stage(); stage != "downloading" || progress != 0.4 {
+		t.Errorf("expected downloading at 0.4, got %s at %f", stage, progress)
+	}
+	resume <- struct{}{}
+	<-processing
+	if stage, progress := j.Stage(); stage != "processing" || progress != 0.1 {
+		t.Errorf("expected processing at 0.1, got %s at %f", stage, progress)
+	}
+	if j.Progress() != 0.1 {
+		t.Errorf("expected progress of the current stage, got %f", j.Progress())
+	}
+	if stats := j.Stats(); stats.Stage != "processing" || stats.Progress != 0.1 {
+		t.Errorf("unexpected stats stage %q progress %f", stats.Stage, stats.Progress)
+	}
+	resume <- struct{}{}
+	j.Wait()
+}
+
+func TestJobRemainingTime(t *testing.T) {
+	var first, second time.Duration
+	var deadline time.Time
+	var ok bool
+	j := New("", func(ctx Context) error {
+		deadline, ok = ctx.Deadline()
+		first = ctx.RemainingTime()
+		time.Sleep(50 * time.Millisecond)
+		second = ctx.RemainingTime()
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	j.Run(ctx, nil)
+	j.Wait()
+	if !ok || deadline.IsZero() {
+		t.Fatal("expected the job to see the deadline of its context")
+	}
+	if first <= 0 || first > time.Second {
+		t.Errorf("expected remaining time within the timeout, got %s", first)
+	}
+	if second > first-50*time.Millisecond {
+		t.Errorf("expected remaining time to decrease, got %s then %s", first, second)
+	}
+
+	var unlimited time.Duration
+	j = New("", func(ctx Context) error {
+		_, ok = ctx.Deadline()
+		unlimited = ctx.RemainingTime()
+		return nil
+	})
+	j.Run(context.Background(), nil)
+	j.Wait()
+	if ok || unlimited != math.MaxInt64 {
+		t.Errorf("expected no deadline and maximum remaining time, got %v and %s", ok, unlimited)
+	}
+}
+
 func TestJobResultAndParams(t *testing.T) {
 	testParams := map[string]string{"key": "value"}
 	testResult := "test result"
@@ -214,6 +294,29 @@ func TestJobStatsWithError(t *testing.T) {
 	}
 }
 
+func TestJobStatsWithCategorizedError(t *testing.T) {
+	j := New("", Wrap(func(ctx context.Context) error {
+		return ferrors.BadRequest.New(
+			ferrors.WithCode("INVALID_PARAMS", nil),
+			ferrors.WithMessage("invalid params"),
+		)
+	}))
+
+	j.Run(context.Background(), nil)
+	j.Wait()
+
+	stats := j.Stats()
+	if stats.Error != "invalid params" {
+		t.Errorf("expected error %s, got %s", "invalid params", stats.Error)
+	}
+	if stats.ErrorCategory != ferrors.BadRequest.Error() {
+		t.Errorf("expected error category %s, got %s", ferrors.BadRequest, stats.ErrorCategory)
+	}
+	if stats.ErrorCode != "INVALID_PARAMS" {
+		t.Errorf("expected error code %s, got %s", "INVALID_PARAMS", stats.ErrorCode)
+	}
+}
+
 func TestJobIsDone(t *testing.T) {
 	j := New("", Wrap(func(ctx context.Context) error {
 		time.Sleep(100 * time.Millisecond)
@@ -495,3 +598,421 @@ func TestJobKey(t *testing.T) {
 		t.Errorf("expected Key() to return %s, got %s", testID, j.Key())
 	}
 }
+
+func TestJobOnStateChange(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		transitions []string
+	)
+	record := func(old, new State) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, string(old)+"->"+string(new))
+	}
+	expect := func(t *testing.T, want ...string) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if len(transitions) != len(want) {
+			t.Fatalf("expected transitions %v, got %v", want, transitions)
+		}
+		for i := range want {
+			if transitions[i] != want[i] {
+				t.Fatalf("expected transitions %v, got %v", want, transitions)
+			}
+		}
+		transitions = nil
+	}
+
+	t.Run("succeeded then failed", func(t *testing.T) {
+		fail := false
+		var j Job
+		j = New("", func(ctx Context) error {
+			// the callback runs outside the lock, so reading state here must not deadlock
+			if j.State() != StateRunning {
+				return errors.New("unexpected state")
+			}
+			if fail {
+				return errors.New("failed")
+			}
+			return nil
+		}, WithOnStateChange(func(old, new State) {
+			_ = j.State()
+			record(old, new)
+		}))
+		j.Run(context.Background(), nil)
+		j.Wait()
+		expect(t, "created->running", "running->succeeded")
+
+		fail = true
+		j.Run(context.Background(), nil)
+		j.Wait()
+		expect(t, "succeeded->running", "running->failed")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		started := make(chan struct{})
+		j := New("", func(ctx Context) error {
+			close(started)
+			<-ctx.Context().Done()
+			return ctx.Context().Err()
+		}, WithOnStateChange(record))
+		j.Run(context.Background(), nil)
+		<-started
+		j.Cancel()
+		j.Wait()
+		expect(t, "created->running", "running->canceling", "canceling->canceled")
+	})
+}
+
+func TestJobCancelWithReason(t *testing.T) {
+	newBlockingJob := func(started chan struct{}, fn func(ctx context.Context) error) Job {
+		return New("", func(jc Context) error {
+			close(started)
+			<-jc.Context().Done()
+			return fn(jc.Context())
+		})
+	}
+
+	t.Run("job observes the reason", func(t *testing.T) {
+		started := make(chan struct{})
+		j := newBlockingJob(started, func(ctx context.Context) error {
+			return fmt.Errorf("stopped: %w", context.Cause(ctx))
+		})
+		j.Run(context.Background(), nil)
+		<-started
+		if !j.CancelWithReason("superseded by newer run") {
+			t.Fatal("expected running job to be canceled")
+		}
+		j.Wait()
+		if !j.IsState(StateCanceled) {
+			t.Fatalf("expected canceled state, got %s", j.State())
+		}
+		if msg := j.Err().Error(); msg != "stopped: superseded by newer run" {
+			t.Fatalf("unexpected error message: %q", msg)
+		}
+		if reason := j.Stats().Reason; reason != "superseded by newer run" {
+			t.Fatalf("unexpected stats reason: %q", reason)
+		}
+	})
+
+	t.Run("generic error gets the reason", func(t *testing.T) {
+		started := make(chan struct{})
+		j := newBlockingJob(started, func(ctx context.Context) error {
+			return errors.New("job canceled")
+		})
+		j.Run(context.Background(), nil)
+		<-started
+		j.CancelWithReason("node draining")
+		j.Wait()
+		if msg := j.Err().Error(); msg != "node draining: job canceled" {
+			t.Fatalf("unexpected error message: %q", msg)
+		}
+		if c := j.Stats().ErrorCategory; c != ferrors.Cancaled.Error() {
+			t.Fatalf("expected canceled category, got %q", c)
+		}
+	})
+
+	t.Run("plain cancel keeps the job error", func(t *testing.T) {
+		started := make(chan struct{})
+		j := newBlockingJob(started, func(ctx context.Context) error {
+			return errors.New("job canceled")
+		})
+		j.Run(context.Background(), nil)
+		<-started
+		j.Cancel()
+		j.Wait()
+		if msg := j.Err().Error(); msg != "job canceled" {
+			t.Fatalf("unexpected error message: %q", msg)
+		}
+		if reason := j.Stats().Reason; reason != "" {
+			t.Fatalf("expected no reason, got %q", reason)
+		}
+	})
+}
+
+func TestJobDefer(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	record := func(s string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, s)
+			mu.Unlock()
+		}
+	}
+	var stateAtFinalize State
+	var j Job
+	j = New("", func(jc Context) error {
+		jc.Defer(record("first"))
+		jc.Defer(func() { panic("finalizer panic") })
+		jc.Defer(record("second"))
+		jc.Defer(func() { stateAtFinalize = j.State() })
+		panic("job panic")
+	})
+	j.Run(context.Background(), nil)
+	j.Wait()
+
+	if !j.IsState(StateFailed) {
+		t.Fatalf("expected failed state, got %s", j.State())
+	}
+	if stateAtFinalize != StateRunning {
+		t.Errorf("expected finalizers to run before the terminal state, got %s", stateAtFinalize)
+	}
+	if len(order) != 2 || order[0] != "second" || order[1] != "first" {
+		t.Errorf("expected finalizers in LIFO order, got %v", order)
+	}
+
+	// finalizers do not carry over to the next run
+	order = nil
+	j.Run(context.Background(), nil)
+	j.Wait()
+	if len(order) != 2 {
+		t.Errorf("expected only the finalizers of the last run, got %v", order)
+	}
+}
+
+type reportResult struct {
+	Name  string         `json:"name"`
+	Count int            `json:"count"`
+	Tags  []string       `json:"tags"`
+	Extra map[string]int `json:"extra"`
+}
+
+func TestJobResultJSON(t *testing.T) {
+	want := reportResult{Name: "report", Count: 3, Tags: []string{"a", "b"}, Extra: map[string]int{"x": 1}}
+	j := New("producer", func(ctx Context) error {
+		ctx.SetResult(want)
+		return nil
+	})
+	j.Run(context.Background(), nil)
+	j.Wait()
+
+	data, err := j.ResultJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(j.Stats().Result) != string(data) {
+		t.Errorf("expected stats to carry the small result %s, got %s", data, j.Stats().Result)
+	}
+
+	// a job restoring the persisted output hands it back as the same struct
+	restored := New("consumer", func(ctx Context) error {
+		return ctx.SetResultJSON(data)
+	})
+	restored.Run(context.Background(), nil)
+	restored.Wait()
+	if restored.Err() != nil {
+		t.Fatal(restored.Err())
+	}
+	got, err := ResultAs[reportResult](restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	invalid := New("invalid", func(ctx Context) error {
+		return ctx.SetResultJSON([]byte("{"))
+	})
+	invalid.Run(context.Background(), nil)
+	invalid.Wait()
+	if !ferrors.Is(invalid.Err(), ferrors.InvalidArgument) {
+		t.Errorf("expected invalid argument for malformed json, got %v", invalid.Err())
+	}
+}
+
+type panickingResult struct{}
+
+func (panickingResult) MarshalJSON() ([]byte, error) { panic("boom") }
+
+func TestJobResultJSONUnserializable(t *testing.T) {
+	for _, result := range []any{make(chan int), panickingResult{}} {
+		j := New("", func(ctx Context) error {
+			ctx.SetResult(result)
+			return nil
+		})
+		j.Run(context.Background(), nil)
+		j.Wait()
+
+		if _, err := j.ResultJSON(); !ferrors.Is(err, ferrors.InvalidArgument) {
+			t.Errorf("expected invalid argument for %T result, got %v", result, err)
+		}
+		if j.Stats().Result != nil {
+			t.Errorf("expected stats to omit the %T result", result)
+		}
+	}
+}
+
+func TestJobHeartbeatTimeout(t *testing.T) {
+	t.Run("stalled job is canceled", func(t *testing.T) {
+		beats := make(chan struct{})
+		j := New("", func(jc Context) error {
+			for i := 0; i < 3; i++ {
+				jc.Heartbeat()
+				beats <- struct{}{}
+				time.Sleep(20 * time.Millisecond)
+			}
+			// stop heartbeating until canceled
+			<-jc.Context().Done()
+			return context.Cause(jc.Context())
+		}, WithHeartbeatTimeout(100*time.Millisecond))
+		j.Run(context.Background(), nil)
+		var last time.Time
+		for i := 0; i < 3; i++ {
+			<-beats
+			last = j.Stats().LastHeartbeat
+		}
+		j.Wait()
+		if !j.IsState(StateCanceled) {
+			t.Fatalf("expected canceled state, got %s", j.State())
+		}
+		stats := j.Stats()
+		if !stats.Stalled {
+			t.Fatal("expected job to be marked as stalled")
+		}
+		if !stats.LastHeartbeat.Equal(last) {
+			t.Fatalf("expected last heartbeat %s, got %s", last, stats.LastHeartbeat)
+		}
+		if c := stats.ErrorCategory; c != ferrors.Cancaled.Error() {
+			t.Fatalf("expected canceled category, got %q", c)
+		}
+		if stats.Reason == "" {
+			t.Fatal("expected a stall reason")
+		}
+	})
+
+	t.Run("heartbeating job outlives the timeout", func(t *testing.T) {
+		j := New("", func(jc Context) error {
+			for i := 0; i < 10; i++ {
+				jc.Heartbeat()
+				time.Sleep(10 * time.Millisecond)
+			}
+			return nil
+		}, WithHeartbeatTimeout(50*time.Millisecond))
+		j.Run(context.Background(), nil)
+		j.Wait()
+		if !j.IsState(StateSucceeded) {
+			t.Fatalf("expected succeeded state, got %s: %v", j.State(), j.Err())
+		}
+		if j.Stats().Stalled {
+			t.Fatal("expected job not to be stalled")
+		}
+	})
+}
+
+func TestJobClone(t *testing.T) {
+	var runs int
+	j := New("clone", func(ctx Context) error {
+		runs++
+		ctx.SetProgress(1)
+		ctx.SetResult(fmt.Sprintf("run %d", runs))
+		return nil
+	}, WithLabel("kind", "test"))
+	j.Run(context.Background(), nil)
+	j.Wait()
+
+	c := j.Clone()
+	if c.ID() != j.ID() {
+		t.Errorf("expected clone id %s, got %s", j.ID(), c.ID())
+	}
+	if c.Labels()["kind"] != "test" {
+		t.Errorf("expected clone to keep labels, got %v", c.Labels())
+	}
+	if !c.IsState(StateCreated) || c.Result() != nil || c.Progress() != -1 {
+		t.Errorf("expected fresh clone, got state %s result %v progress %v", c.State(), c.Result(), c.Progress())
+	}
+	if !c.StartedAt().IsZero() || !c.EndedAt().IsZero() {
+		t.Error("expected clone to have zeroed timestamps")
+	}
+	c.Labels()["kind"] = "changed"
+	if j.Labels()["kind"] != "test" {
+		t.Error("clone labels should be independent of the original")
+	}
+
+	c.Run(context.Background(), nil)
+	c.Wait()
+	if c.Result() != "run 2" {
+		t.Errorf("expected clone result %q, got %v", "run 2", c.Result())
+	}
+	if j.Result() != "run 1" || !j.IsState(StateSucceeded) {
+		t.Errorf("original should keep its history, got state %s result %v", j.State(), j.Result())
+	}
+}
+
+func TestJobSpawn(t *testing.T) {
+	blocking := func(jc Context) error {
+		<-jc.Context().Done()
+		return jc.Context().Err()
+	}
+
+	t.Run("cancel propagates", func(t *testing.T) {
+		spawned := make(chan Job, 1)
+		var grandchild Job
+		parent := New("parent", func(jc Context) error {
+			child := jc.Spawn("child", func(cc Context) error {
+				grandchild = cc.Spawn("grandchild", blocking)
+				return blocking(cc)
+			})
+			spawned <- child
+			return blocking(jc)
+		})
+		parent.Run(context.Background(), nil)
+		child := <-spawned
+		parent.CancelWithReason("shutting down")
+		parent.Wait()
+		if !child.IsDone() || !grandchild.IsDone() {
+			t.Fatal("parent ended before its children")
+		}
+		for _, j := range []Job{parent, child, grandchild} {
+			if !j.IsState(StateCanceled) {
+				t.Fatalf("expected %s to be canceled, got %s", j.ID(), j.State())
+			}
+			if j.Stats().Reason != "shutting down" {
+				t.Fatalf("expected %s to be canceled with the parent's reason, got %q", j.ID(), j.Stats().Reason)
+			}
+		}
+	})
+
+	t.Run("parent waits for children", func(t *testing.T) {
+		var child Job
+		parent := New("parent", func(jc Context) error {
+			child = jc.Spawn("child", func(cc Context) error {
+				time.Sleep(100 * time.Millisecond)
+				return errors.New("child failed")
+			})
+			return nil
+		})
+		parent.Run(context.Background(), nil)
+		parent.Wait()
+		if !child.IsDone() {
+			t.Fatal("parent ended before its child")
+		}
+		if !parent.IsState(StateSucceeded) || !child.IsState(StateFailed) {
+			t.Fatalf("unexpected states: parent %s child %s", parent.State(), child.State())
+		}
+	})
+
+	t.Run("detached children", func(t *testing.T) {
+		release := make(chan struct{})
+		var child Job
+		parent := New("parent", func(jc Context) error {
+			child = jc.Spawn("child", func(cc Context) error {
+				<-release
+				return nil
+			})
+			return nil
+		}, WithDetachedChildren())
+		parent.Run(context.Background(), nil)
+		parent.Wait()
+		if child.IsDone() {
+			t.Fatal("expected the detached child to outlive its parent")
+		}
+		close(release)
+		child.Wait()
+		if !child.IsState(StateSucceeded) {
+			t.Fatalf("unexpected child state %s", child.State())
+		}
+	})
+}
@@ -2,9 +2,10 @@ package job
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
-	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
 	"github.com/xhanio/framingo/pkg/utils/log"
 
 	"k8s.io/apimachinery/pkg/labels"
@@ -14,39 +15,91 @@ type Func func(ctx Context) error
 
 func Wrap(fn func(context.Context) error) Func {
 	return func(jc Context) error {
-		return errors.Wrap(fn(jc.Context()))
+		return errutil.Wrap(fn(jc.Context()))
 	}
 }
 
 type Context interface {
 	ID() string
 	Context() context.Context
+	// Deadline returns when the job's context expires, e.g. at the end of the
+	// executor's timeout, or false if it has no deadline.
+	Deadline() (time.Time, bool)
+	// RemainingTime returns the time left until Deadline, or 0 once it has
+	// passed. Without a deadline it is the maximum duration, so jobs pacing
+	// themselves with it never stop early.
+	RemainingTime() time.Duration
 	Logger() log.Logger
 	Labels() labels.Set
 	SetProgress(progress float64)
+	// SetStage starts the named stage of a multi-stage job and sets its
+	// progress. SetProgress then updates the progress of that stage.
+	SetStage(name string, progress float64)
 	SetResult(result any)
+	// SetResultJSON sets the result to data, an already serialized JSON
+	// value, e.g. an output restored from a store.
+	SetResultJSON(data []byte) error
 	GetParams() any
+	// IsDryRun reports whether the job runs as a dry run, to validate its
+	// wiring without side effects. Job functions check it to skip their
+	// mutations, see DryRunContext.
+	IsDryRun() bool
+	// Heartbeat signals the job is making progress, see WithHeartbeatTimeout.
+	Heartbeat()
+	// Defer registers fn to run once the job function returns or panics, in
+	// LIFO order, before the job reaches its terminal state.
+	Defer(fn func())
+	// Spawn starts a child job whose context derives from the job's, so
+	// canceling the job cancels the child too. Unless the job was created
+	// with WithDetachedChildren, it does not end before its children do.
+	// Errors of the child are not propagated, check them with Err.
+	Spawn(id string, fn Func, opts ...Option) Job
 }
 
 type Job interface {
 	ID() string
 	Labels() labels.Set
+	// Weight returns the cost of the job set with WithWeight, at least 1.
+	Weight() int
+	// IdempotencyKey returns the key set with WithIdempotencyKey, or "".
+	IdempotencyKey() string
 	CreatedAt() time.Time
 	StartedAt() time.Time
 	EndedAt() time.Time
 	Run(ctx context.Context, params any) bool
 	Wait()
 	Cancel() bool
+	CancelWithReason(reason string) bool
 	Result() any
+	// ResultJSON returns the result serialized as JSON, or nil if there is no
+	// result.
+	ResultJSON() ([]byte, error)
 	Err() error
 	State() State
 	Context() context.Context
+	// Progress returns the progress of the current stage, see Stage. It is
+	// not combined across stages, since their relative weight is unknown.
 	Progress() float64
+	// Stage returns the current stage and its progress, or "" if the job
+	// reports no stages.
+	Stage() (string, float64)
 	ExecutionTime() time.Duration
 	IsExecuting() bool
 	IsDone() bool
 	IsState(state State) bool
 	Stats() *Stats
+	// Clone returns a new job in the created state with the same ID, function,
+	// labels, options and logger, leaving the original and its history intact.
+	Clone() Job
+}
+
+// Deduper remembers the idempotency keys of the jobs that ran, see
+// WithIdempotencyKey. Implementations must be safe for concurrent use.
+type Deduper interface {
+	// SeenRecently reports whether key was marked less than its ttl ago.
+	SeenRecently(key string) bool
+	// Mark records key as seen for ttl.
+	Mark(key string, ttl time.Duration)
 }
 
 type State string
@@ -66,8 +119,18 @@ type Stats struct {
 	ID            string        `json:"id"`
 	State         string        `json:"state"`
 	Progress      float64       `json:"progress"`
+	Stage         string        `json:"stage,omitempty"`
 	StartedAt     time.Time     `json:"started_at"`
 	ExecutionTime time.Duration `json:"execution_time"`
 	Labels        labels.Set    `json:"labels"`
 	Error         string        `json:"error"`
+	ErrorCategory string        `json:"error_category"`
+	ErrorCode     string        `json:"error_code"`
+	Reason        string        `json:"reason,omitempty"` // cancellation reason
+	LastHeartbeat time.Time     `json:"last_heartbeat"`
+	Stalled       bool          `json:"stalled,omitempty"` // canceled for missing heartbeats
+	DryRun        bool          `json:"dry_run,omitempty"` // the last run was a dry run
+	// Result is the result as serialized when it was set, omitted when it is
+	// larger than 4KiB or not serializable.
+	Result json.RawMessage `json:"result,omitempty"`
 }
@@ -1,6 +1,8 @@
 package job
 
 import (
+	"time"
+
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -24,6 +26,52 @@ func WithLabels(labels map[string]string) Option {
 	}
 }
 
+// WithOnStateChange registers fn to be called on every state transition. fn
+// runs synchronously on the goroutine making the transition, after the job's
+// internal lock is released, so it may safely call back into the job.
+func WithOnStateChange(fn func(old, new State)) Option {
+	return func(t *job) {
+		t.onStateChange = fn
+	}
+}
+
+// WithHeartbeatTimeout makes the job cancel itself as stalled when its function
+// goes longer than d without calling Heartbeat, which catches hung or looping
+// jobs regardless of their total run time. The first heartbeat is due d after
+// the job starts.
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(t *job) {
+		t.heartbeatTimeout = d
+	}
+}
+
+// WithWeight sets the cost of the job relative to others, 1 by default. Batch
+// runs and the task manager admit jobs by their total weight, so a job of
+// weight 3 takes the place of three jobs of weight 1.
+func WithWeight(weight int) Option {
+	return func(t *job) {
+		t.weight = weight
+	}
+}
+
+// WithIdempotencyKey identifies the logical operation of the job, e.g. an
+// order id, so that a job submitted twice, as at-least-once delivery may do,
+// only runs once. Executors skip a job whose key succeeded within the TTL of
+// their Deduper.
+func WithIdempotencyKey(key string) Option {
+	return func(t *job) {
+		t.idempotencyKey = key
+	}
+}
+
+// WithDetachedChildren lets the job end without waiting for the children
+// started with Spawn. They are still canceled with the job while it runs.
+func WithDetachedChildren() Option {
+	return func(t *job) {
+		t.detachChildren = true
+	}
+}
+
 func WithLogger(logger log.Logger) Option {
 	return func(t *job) {
 		t.log = logger
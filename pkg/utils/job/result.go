@@ -0,0 +1,58 @@
+package job
+
+import (
+	"encoding/json"
+
+	"github.com/xhanio/errors"
+)
+
+// maxStatsResultSize bounds the serialized result embedded in Stats, so
+// listing jobs does not copy large outputs around. Larger results are only
+// available through ResultJSON.
+const maxStatsResultSize = 4 << 10
+
+// marshalResult serializes result, turning both marshal errors and panics
+// raised by a custom MarshalJSON into an InvalidArgument error. A nil result
+// serializes to nil.
+func marshalResult(result any) (data []byte, err error) {
+	if result == nil {
+		return nil, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.InvalidArgument.Newf("job result of type %T is not serializable: %v", result, r)
+		}
+	}()
+	data, err = json.Marshal(result)
+	if err != nil {
+		return nil, errors.InvalidArgument.Wrapf(err, "job result of type %T is not serializable", result)
+	}
+	return data, nil
+}
+
+// ResultAs returns the result of j as a T. A result set with SetResultJSON,
+// or of another type with the same JSON shape, is decoded into a T.
+func ResultAs[T any](j Job) (T, error) {
+	v, err := DecodeResult[T](j.Result())
+	if err != nil {
+		return v, errors.Wrapf(err, "job %s", j.ID())
+	}
+	return v, nil
+}
+
+// DecodeResult converts result, as returned by Job.Result, to a T: as is when
+// it already is one, otherwise by decoding its JSON form into a T.
+func DecodeResult[T any](result any) (T, error) {
+	var v T
+	if r, ok := result.(T); ok {
+		return r, nil
+	}
+	data, err := marshalResult(result)
+	if err != nil || data == nil {
+		return v, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, errors.InvalidArgument.Wrapf(err, "failed to decode result as %T", v)
+	}
+	return v, nil
+}
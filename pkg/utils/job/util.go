@@ -1,5 +1,21 @@
 package job
 
+import "context"
+
+type dryRunKey struct{}
+
+// DryRunContext returns a copy of ctx that makes the jobs run with it dry
+// runs, see Context.IsDryRun. Children started with Spawn inherit it.
+func DryRunContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRunContext reports whether ctx was returned by DryRunContext.
+func IsDryRunContext(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
 func IsDone(state State) bool {
 	return state == StateSucceeded || state == StateFailed || state == StateCanceled
 }
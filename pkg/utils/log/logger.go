@@ -6,6 +6,7 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/xhanio/framingo/pkg/types/common"
 	"github.com/xhanio/framingo/pkg/utils/pathutil"
@@ -15,7 +16,9 @@ type logger struct {
 	level      zapcore.Level
 	timeFormat string
 	fileWriter io.Writer
+	compress   bool
 	noStdout   bool
+	redactKeys []string
 
 	core *zap.SugaredLogger
 }
@@ -29,11 +32,18 @@ func newLogger(opts ...Option) *logger {
 		timeFormat: "01/02/2006 15:04:05.00",
 	}
 	l.apply(opts...)
+	if lj, ok := l.fileWriter.(*lumberjack.Logger); ok {
+		lj.Compress = l.compress
+	}
 	var zopts []zap.Option
 	if zapcore.Level(l.level) == zapcore.DebugLevel {
 		zopts = append(zopts, zap.AddCaller(), zap.AddCallerSkip(1))
 	}
-	l.core = zap.New(zapcore.NewTee(l.newCores(l.fileWriter)...), zopts...).Sugar()
+	core := zapcore.NewTee(l.newCores(l.fileWriter)...)
+	if len(l.redactKeys) > 0 {
+		core = newRedactCore(core, l.redactKeys)
+	}
+	l.core = zap.New(core, zopts...).Sugar()
 	return l
 }
 
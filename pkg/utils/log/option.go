@@ -27,6 +27,11 @@ func WithTimeFormat(format string) Option {
 	}
 }
 
+// WithFileWriter writes json log records to file, rotating it once it reaches
+// maxSize megabytes. Rotated files are pruned when there are more than
+// maxBackups of them or when they are older than maxAge days, whichever comes
+// first; a zero value disables the corresponding limit, so with both zero
+// every rotated file is kept.
 func WithFileWriter(file string, maxSize, maxBackups, maxAge int) Option {
 	return func(l *logger) {
 		if file != "" {
@@ -40,6 +45,25 @@ func WithFileWriter(file string, maxSize, maxBackups, maxAge int) Option {
 	}
 }
 
+// WithLogCompression gzips rotated log files in the background. Compressed
+// files count towards maxBackups and maxAge of WithFileWriter like the
+// uncompressed ones.
+func WithLogCompression(compress bool) Option {
+	return func(l *logger) {
+		l.compress = compress
+	}
+}
+
+// WithRedactedKeys logs the value of every field with one of keys, matched
+// case-insensitively, as "[REDACTED]", e.g. for "password" or "token". It
+// applies to the fields of single entries and to those added with With or By,
+// and carries over to the loggers derived from this one.
+func WithRedactedKeys(keys ...string) Option {
+	return func(l *logger) {
+		l.redactKeys = append(l.redactKeys, keys...)
+	}
+}
+
 // NoStdout suppresses the colored console core so log records are only
 // written to the file writer (when configured). Useful for daemons that pipe
 // stdout into an external log collector or want silent-by-default binaries.
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// waitFiles polls dir until match holds for its entries, since lumberjack
+// compresses and prunes rotated files in the background.
+func waitFiles(t *testing.T, dir string, match func(names []string) bool) []string {
+	t.Helper()
+	var names []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = names[:0]
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if match(names) {
+			return names
+		}
+	}
+	t.Fatalf("unexpected files in %s: %v", dir, names)
+	return nil
+}
+
+func TestLogCompression(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	// a stale compressed backup from a previous run, past max age
+	stale := filepath.Join(dir, "app-"+time.Now().AddDate(0, 0, -10).UTC().Format("2006-01-02T15-04-05.000")+".log.gz")
+	if err := os.WriteFile(stale, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newLogger(WithFileWriter(file, 1, 3, 7), WithLogCompression(true), NoStdout())
+	defer l.fileWriter.(*lumberjack.Logger).Close()
+	// write a bit over 1MB to trigger a rotation
+	line := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		l.Info(line)
+	}
+
+	names := waitFiles(t, dir, func(names []string) bool {
+		var compressed int
+		for _, name := range names {
+			if strings.HasSuffix(name, ".log.gz") {
+				compressed++
+			}
+		}
+		return compressed == 1 && len(names) == 2
+	})
+	for _, name := range names {
+		if name == filepath.Base(stale) {
+			t.Fatalf("expected stale backup %s to be pruned", name)
+		}
+		if !strings.HasSuffix(name, ".gz") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("rotated file %s is not gzipped: %v", name, err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), line) {
+			t.Fatalf("rotated file %s misses the log records", name)
+		}
+	}
+}
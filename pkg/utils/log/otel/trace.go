@@ -0,0 +1,21 @@
+// Package otel correlates log entries with OpenTelemetry traces, keeping the
+// OpenTelemetry dependency out of the log package itself.
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xhanio/framingo/pkg/utils/log"
+)
+
+// WithTraceContext returns a logger that tags entries of l with the trace_id
+// and span_id of the span active in ctx, or l itself if there is none.
+func WithTraceContext(l log.Logger, ctx context.Context) log.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return l.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+}
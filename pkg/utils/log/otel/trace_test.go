@@ -0,0 +1,47 @@
+package otel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xhanio/framingo/pkg/utils/log"
+)
+
+func TestWithTraceContext(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.log")
+	l := log.New(log.WithFileWriter(file, 1, 1, 1), log.NoStdout())
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10},
+		SpanID:     trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	WithTraceContext(l, ctx).Info("traced")
+	WithTraceContext(l, context.Background()).Info("untraced")
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d", len(lines))
+	}
+	for _, field := range []string{
+		`"trace_id":"0102030405060708090a0b0c0d0e0f10"`,
+		`"span_id":"0102030405060708"`,
+	} {
+		if !strings.Contains(lines[0], field) {
+			t.Errorf("expected %s in %s", field, lines[0])
+		}
+	}
+	if strings.Contains(lines[1], "trace_id") {
+		t.Errorf("expected no trace fields in %s", lines[1])
+	}
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// redactCore replaces the value of fields with one of the redacted keys,
+// matched case-insensitively, before they reach the wrapped core. Fields added
+// with With are redacted as well, so children of the logger inherit it.
+type redactCore struct {
+	zapcore.Core
+	keys map[string]bool
+}
+
+func newRedactCore(core zapcore.Core, keys []string) zapcore.Core {
+	c := &redactCore{Core: core, keys: make(map[string]bool, len(keys))}
+	for _, key := range keys {
+		c.keys[strings.ToLower(key)] = true
+	}
+	return c
+}
+
+func (c *redactCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var result []zapcore.Field
+	for i, f := range fields {
+		if !c.keys[strings.ToLower(f.Key)] {
+			continue
+		}
+		if result == nil {
+			// copy on first match, fields may be shared with the caller
+			result = append([]zapcore.Field(nil), fields...)
+		}
+		result[i] = zap.String(f.Key, redacted)
+	}
+	if result == nil {
+		return fields
+	}
+	return result
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redact(fields)), keys: c.keys}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.redact(fields))
+}
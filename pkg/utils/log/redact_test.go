@@ -0,0 +1,42 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type named string
+
+func (n named) Name() string { return string(n) }
+
+func TestRedactedKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	l := newLogger(WithFileWriter(file, 1, 0, 0), NoStdout(), WithRedactedKeys("password", "Token"))
+
+	l.With("user", "alice", "password", "hunter2").Info("login")
+	l.By(named("auth")).Sugared().Infow("refresh", "token", "s3cr3t", "expires", 60)
+	l.Sugared().Infow("uppercase", "TOKEN", "abc123")
+	if err := l.fileWriter.(*lumberjack.Logger).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, secret := range []string{"hunter2", "s3cr3t", "abc123"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, out)
+		}
+	}
+	for _, field := range []string{`"password":"[REDACTED]"`, `"token":"[REDACTED]"`, `"TOKEN":"[REDACTED]"`, `"user":"alice"`, `"expires":60`} {
+		if !strings.Contains(out, field) {
+			t.Errorf("expected %s in %s", field, out)
+		}
+	}
+}
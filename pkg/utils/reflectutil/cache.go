@@ -0,0 +1,65 @@
+package reflectutil
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// upper bound of cached struct types, types beyond it are parsed on every call
+const maxCachedTypes = 4096
+
+type fieldInfo struct {
+	index   int
+	name    string
+	tag     reflect.StructTag
+	tags    []string
+	typ     reflect.Type
+	rules   rules
+	appends bool // merged by appending, see Merge
+}
+
+var (
+	fieldCache  sync.Map // reflect.Type -> []fieldInfo
+	cachedTypes atomic.Int64
+)
+
+// fieldsOf returns the scannable fields of struct type t. Parsed metadata of named
+// types is cached, while unnamed types (e.g. built by reflect.StructOf) are not so
+// dynamically generated types cannot grow the cache.
+func fieldsOf(t reflect.Type) []fieldInfo {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+	fields := parseFields(t)
+	if t.Name() == "" || cachedTypes.Load() >= maxCachedTypes {
+		return fields
+	}
+	if _, loaded := fieldCache.LoadOrStore(t, fields); !loaded {
+		cachedTypes.Add(1)
+	}
+	return fields
+}
+
+func parseFields(t reflect.Type) []fieldInfo {
+	var fields []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tags := strings.Split(field.Tag.Get(tagKey), ",")
+		if len(tags) > 0 && tags[0] == "-" {
+			continue
+		}
+		fields = append(fields, fieldInfo{
+			index:   i,
+			name:    field.Name,
+			tag:     field.Tag,
+			tags:    tags,
+			typ:     field.Type,
+			rules:   parseRules(field, tags),
+			appends: slices.Contains(tags[1:], tagAppend),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,96 @@
+package reflectutil
+
+import "reflect"
+
+// visit identifies a pointer, slice or map already copied by DeepCopy.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// DeepCopy returns a copy of src that shares no pointers, slices or maps with
+// it, so mutating one never shows through the other. Pointers, slices, maps,
+// arrays, structs and interfaces are copied recursively, channels and funcs as
+// is. Unexported struct fields cannot be set through reflection and are copied
+// shallowly. A pointer, slice or map reached more than once, cycles included,
+// is copied once and shared the same way in the copy.
+func DeepCopy[T any](src T) T {
+	var dst T
+	deepCopy(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(&src).Elem(), make(map[visit]reflect.Value))
+	return dst
+}
+
+func deepCopy(dst, src reflect.Value, visited map[visit]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		key := visit{ptr: src.Pointer(), typ: src.Type()}
+		if v, ok := visited[key]; ok {
+			dst.Set(v)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		visited[key] = p
+		deepCopy(p.Elem(), src.Elem(), visited)
+		dst.Set(p)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		v := reflect.New(elem.Type()).Elem()
+		deepCopy(v, elem, visited)
+		dst.Set(v)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := visit{ptr: src.Pointer(), typ: src.Type(), len: src.Len()}
+		if v, ok := visited[key]; ok {
+			dst.Set(v)
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		visited[key] = s
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(s.Index(i), src.Index(i), visited)
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i), visited)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := visit{ptr: src.Pointer(), typ: src.Type()}
+		if v, ok := visited[key]; ok {
+			dst.Set(v)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		visited[key] = m
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			deepCopy(k, iter.Key(), visited)
+			v := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(v, iter.Value(), visited)
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if field := dst.Field(i); field.CanSet() {
+				deepCopy(field, src.Field(i), visited)
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,131 @@
+package reflectutil
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+
+	"github.com/xhanio/errors"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// nested reports whether struct type t is converted field by field rather
+// than as a single value. Structs that marshal themselves, such as time.Time,
+// are values.
+func nested(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(jsonMarshalerType) && !pt.Implements(textMarshalerType)
+}
+
+// ToMap converts the fields of obj, a struct or a pointer to one, into a map
+// keyed by field name, like Scan but with native Go values instead of bytes.
+// Fields tagged `scan:"-"` and unexported fields are skipped. Nested structs
+// become nested maps, nil pointers become nil and other pointers are
+// dereferenced.
+func ToMap(obj any) (map[string]any, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, errors.Newf("unsupported obj kind: %s", v.Kind())
+	}
+	return toMap(v), nil
+}
+
+func toMap(v reflect.Value) map[string]any {
+	result := make(map[string]any)
+	for _, field := range fieldsOf(v.Type()) {
+		if !v.Type().Field(field.index).IsExported() {
+			continue
+		}
+		result[field.name] = toValue(v.Field(field.index))
+	}
+	return result
+}
+
+func toValue(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if nested(v.Type()) {
+		return toMap(v)
+	}
+	return v.Interface()
+}
+
+// FromMap sets the fields of obj, a pointer to a struct, from m as produced by
+// ToMap, leaving fields missing from m untouched. Values are converted to the
+// field types where needed, e.g. the float64 numbers and []any slices of
+// decoded JSON, or the RFC 3339 string of a time.Time.
+func FromMap(obj any, m map[string]any) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.Newf("obj must be a non-nil pointer to a struct")
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return errors.Newf("unsupported obj kind: %s", v.Kind())
+	}
+	return fromMap(v, m, "")
+}
+
+func fromMap(v reflect.Value, m map[string]any, prefix string) error {
+	for _, field := range fieldsOf(v.Type()) {
+		value, ok := m[field.name]
+		if !ok || !v.Type().Field(field.index).IsExported() {
+			continue
+		}
+		if err := fromValue(v.Field(field.index), value, prefix+field.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fromValue(dst reflect.Value, value any, name string) error {
+	if value == nil {
+		dst.SetZero()
+		return nil
+	}
+	src := reflect.ValueOf(value)
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+		return nil
+	case dst.Kind() == reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := fromValue(elem.Elem(), value, name); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case nested(dst.Type()):
+		if m, ok := value.(map[string]any); ok {
+			return fromMap(dst, m, name+".")
+		}
+	}
+	// let json convert between compatible shapes, rejecting lossy ones such
+	// as 1.5 into an int
+	b, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(b, dst.Addr().Interface())
+	}
+	if err != nil {
+		return errors.InvalidArgument.Wrapf(err, "cannot set field %s of type %s from %T", name, dst.Type(), value)
+	}
+	return nil
+}
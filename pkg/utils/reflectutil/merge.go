@@ -0,0 +1,73 @@
+package reflectutil
+
+import "reflect"
+
+const tagAppend = "append"
+
+// Merge returns base overlaid with the non-zero values of override, for
+// layering configuration such as defaults, then file, then environment:
+//
+//	cfg := reflectutil.Merge(reflectutil.Merge(defaults, fromFile), fromEnv)
+//
+// Structs are merged field by field, nested ones recursively. A nil pointer
+// inherits the base value, a non-nil pointer to a struct is merged with the
+// base one and any other non-nil pointer replaces it. Other values, including
+// structs that marshal themselves such as time.Time, are replaced when the
+// override is non-zero, so false, 0 or "" never override.
+//
+// Slices and maps are replaced when the override is non-nil, so an empty
+// non-nil one clears them. Fields tagged `scan:",append"` append the override
+// slice to the base one instead, or add the override map entries to the base
+// ones. Fields tagged `scan:"-"` and unexported fields keep the base value.
+// The result shares no pointers, slices or maps with base or override.
+func Merge[T any](base, override T) T {
+	dst := DeepCopy(base)
+	src := DeepCopy(override)
+	merge(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(&src).Elem(), false)
+	return dst
+}
+
+func merge(dst, src reflect.Value, appends bool) {
+	switch {
+	case src.Kind() == reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() || !nested(src.Type().Elem()) {
+			dst.Set(src)
+			return
+		}
+		// merged into a new pointer since the base one may be shared
+		p := reflect.New(src.Type().Elem())
+		p.Elem().Set(dst.Elem())
+		merge(p.Elem(), src.Elem(), appends)
+		dst.Set(p)
+	case nested(src.Type()):
+		for _, field := range fieldsOf(src.Type()) {
+			if !src.Type().Field(field.index).IsExported() {
+				continue
+			}
+			merge(dst.Field(field.index), src.Field(field.index), field.appends)
+		}
+	case appends && src.Kind() == reflect.Slice:
+		if !src.IsNil() {
+			dst.Set(reflect.AppendSlice(dst, src))
+		}
+	case appends && src.Kind() == reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(src)
+			return
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(iter.Key(), iter.Value())
+		}
+	default:
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package reflectutil
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -14,6 +16,12 @@ import (
 const tagKey = "scan"
 
 // returns pkg dir (relative to project dir) and virable name
+//
+// Unnamed types have no package or name of their own. Functions and closures
+// are located by their runtime symbol, e.g. ("example.com/pkg", "New.func1").
+// Other unnamed types, such as anonymous structs, get a stable synthetic name
+// derived from the type, e.g. "struct_1f2e3d4c", and take the package of their
+// first unexported field if there is one.
 func Locate(obj any) (string, string) {
 	ot := reflect.TypeOf(obj)
 	if ot == nil {
@@ -22,7 +30,46 @@ func Locate(obj any) (string, string) {
 	for ot.Kind() == reflect.Pointer {
 		ot = ot.Elem()
 	}
-	return ot.PkgPath(), ot.Name()
+	if ot.Name() != "" {
+		return ot.PkgPath(), ot.Name()
+	}
+	if ot.Kind() == reflect.Func {
+		if pkg, name := locateFunc(reflect.ValueOf(obj)); name != "" {
+			return pkg, name
+		}
+	}
+	var pkg string
+	if ot.Kind() == reflect.Struct {
+		for i := 0; i < ot.NumField(); i++ {
+			if p := ot.Field(i).PkgPath; p != "" {
+				pkg = p
+				break
+			}
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ot.String()))
+	return pkg, fmt.Sprintf("%s_%08x", ot.Kind(), h.Sum32())
+}
+
+// locateFunc splits the runtime symbol of a function value into its package
+// path and the function name within the package.
+func locateFunc(v reflect.Value) (string, string) {
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "", ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return "", ""
+	}
+	symbol := fn.Name()
+	slash := strings.LastIndex(symbol, "/")
+	dot := strings.Index(symbol[slash+1:], ".")
+	if dot < 0 {
+		return "", symbol
+	}
+	dot += slash + 1
+	return symbol[:dot], symbol[dot+1:]
 }
 
 func ToBytes(value reflect.Value) []byte {
@@ -255,23 +302,12 @@ func Scan(obj any) ([]common.Pair[string, []byte], error) {
 		return nil, errors.Newf("unsupported obj kind: %s", objValue.Kind())
 	}
 	var result []common.Pair[string, []byte]
-	for i := 0; i < objType.NumField(); i++ {
-		fieldType := objType.Field(i)
-		fieldValue := objValue.Field(i)
-		key := fieldType.Name
-		// fmt.Println("field name is", key)
-		tags := strings.Split(fieldType.Tag.Get(tagKey), ",")
-		if len(tags) > 0 {
-			// fmt.Println("tags are", tags)
-			if tags[0] == "-" {
-				continue
-			}
-		}
-		// fmt.Println("type is", fieldType, "value is", fieldValue)
-		value := ToBytes(fieldValue)
-		// if sliceutil.In(tagEncrypt, tags...) {
+	for _, field := range fieldsOf(objType) {
+		// fmt.Println("field name is", field.name)
+		value := ToBytes(objValue.Field(field.index))
+		// if sliceutil.In(tagEncrypt, field.tags...) {
 		// 	if kp == nil {
-		// 		return nil, errors.Newf("failed to encrypt key %s: rsa key does not exists", key)
+		// 		return nil, errors.Newf("failed to encrypt key %s: rsa key does not exists", field.name)
 		// 	}
 		// 	ciphertext, err := rsa.EncryptPKCS1v15(rand.Reader, &kp.PublicKey, value)
 		// 	if err != nil {
@@ -279,7 +315,7 @@ func Scan(obj any) ([]common.Pair[string, []byte], error) {
 		// 	}
 		// 	value = ciphertext
 		// }
-		pair := common.NewPair(key, value)
+		pair := common.NewPair(field.name, value)
 		// fmt.Println("pair is", pair)
 		result = append(result, pair)
 	}
@@ -305,25 +341,14 @@ func Apply(obj any, fields []common.Pair[string, []byte]) error {
 	for _, field := range fields {
 		values[field.GetKey()] = []byte(field.GetValue())
 	}
-	for i := 0; i < objType.NumField(); i++ {
-		fieldType := objType.Field(i)
-		key := fieldType.Name
-		// fmt.Println("field name is", key)
-		// fmt.Println("type is", fieldType.Type)
-		tags := strings.Split(fieldType.Tag.Get(tagKey), ",")
-		if len(tags) > 0 {
-			// fmt.Println("tags are", tags)
-			if tags[0] == "-" {
-				continue
-			}
-		}
-		value, ok := values[key]
+	for _, field := range fieldsOf(objType) {
+		value, ok := values[field.name]
 		if !ok || len(value) == 0 {
 			continue
 		}
-		// if sliceutil.In(tagEncrypt, tags...) {
+		// if sliceutil.In(tagEncrypt, field.tags...) {
 		// 	if kp == nil {
-		// 		return errors.Newf("failed to encrypt key %s: rsa key does not exists", key)
+		// 		return errors.Newf("failed to encrypt key %s: rsa key does not exists", field.name)
 		// 	}
 		// 	plaintext, err := rsa.DecryptPKCS1v15(rand.Reader, kp, value)
 		// 	if err != nil {
@@ -331,12 +356,11 @@ func Apply(obj any, fields []common.Pair[string, []byte]) error {
 		// 	}
 		// 	value = plaintext
 		// }
-		v, err := FromBytes(fieldType.Type, value)
+		v, err := FromBytes(field.typ, value)
 		if err != nil {
 			return errors.Wrap(err)
 		}
-		fieldValue := objValue.Field(i)
-		fieldValue.Set(v)
+		objValue.Field(field.index).Set(v)
 	}
 	return nil
 }
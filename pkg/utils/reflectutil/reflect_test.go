@@ -0,0 +1,469 @@
+package reflectutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xhanio/errors"
+
+	"github.com/xhanio/framingo/pkg/types/common"
+)
+
+type record struct {
+	Name    string
+	Age     int
+	Score   *float64
+	Tags    []string
+	Enabled bool
+	Secret  string `scan:"-"`
+}
+
+func TestScanApply(t *testing.T) {
+	score := 9.5
+	in := &record{
+		Name:    "foo",
+		Age:     42,
+		Score:   &score,
+		Tags:    []string{"a", "b"},
+		Enabled: true,
+		Secret:  "hidden",
+	}
+	fields, err := Scan(in)
+	assert.NoError(t, err)
+	assert.Len(t, fields, 5)
+
+	out := &record{}
+	assert.NoError(t, Apply(out, fields))
+	assert.Equal(t, in.Name, out.Name)
+	assert.Equal(t, in.Age, out.Age)
+	assert.Equal(t, *in.Score, *out.Score)
+	assert.Equal(t, in.Tags, out.Tags)
+	assert.Equal(t, in.Enabled, out.Enabled)
+	assert.Empty(t, out.Secret)
+}
+
+func TestFieldCache(t *testing.T) {
+	t.Run("named types are cached", func(t *testing.T) {
+		typ := reflect.TypeOf(record{})
+		fields := fieldsOf(typ)
+		cached, ok := fieldCache.Load(typ)
+		assert.True(t, ok)
+		assert.Equal(t, fields, cached)
+	})
+
+	t.Run("unnamed types are not cached", func(t *testing.T) {
+		typ := reflect.StructOf([]reflect.StructField{
+			{Name: "Dynamic", Type: reflect.TypeOf("")},
+		})
+		fields := fieldsOf(typ)
+		assert.Len(t, fields, 1)
+		_, ok := fieldCache.Load(typ)
+		assert.False(t, ok)
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fields, err := Scan(&record{Name: "foo"})
+				assert.NoError(t, err)
+				assert.NoError(t, Apply(&record{}, fields))
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkScan(b *testing.B) {
+	score := 9.5
+	in := &record{Name: "foo", Age: 42, Score: &score, Tags: []string{"a", "b"}}
+
+	b.Run("uncached fields", func(b *testing.B) {
+		typ := reflect.TypeOf(in).Elem()
+		for i := 0; i < b.N; i++ {
+			_ = parseFields(typ)
+		}
+	})
+
+	b.Run("cached fields", func(b *testing.B) {
+		typ := reflect.TypeOf(in).Elem()
+		for i := 0; i < b.N; i++ {
+			_ = fieldsOf(typ)
+		}
+	})
+
+	b.Run("Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = Scan(in)
+		}
+	})
+
+	b.Run("Apply", func(b *testing.B) {
+		fields, _ := Scan(in)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = Apply(&record{}, fields)
+		}
+	})
+}
+
+type locatable struct{}
+
+func newLocateClosure() func() {
+	return func() {}
+}
+
+func TestLocate(t *testing.T) {
+	const pkg = "github.com/xhanio/framingo/pkg/utils/reflectutil"
+
+	t.Run("pointer", func(t *testing.T) {
+		p, n := Locate(&locatable{})
+		assert.Equal(t, pkg, p)
+		assert.Equal(t, "locatable", n)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		p, n := Locate(locatable{})
+		assert.Equal(t, pkg, p)
+		assert.Equal(t, "locatable", n)
+	})
+
+	t.Run("anonymous struct", func(t *testing.T) {
+		obj := &struct{ name string }{}
+		p, n := Locate(obj)
+		assert.Equal(t, pkg, p)
+		assert.Regexp(t, `^struct_[0-9a-f]{8}$`, n)
+		// stable across calls and values of the same type
+		_, again := Locate(struct{ name string }{name: "other"})
+		assert.Equal(t, n, again)
+		// distinct for a different anonymous type
+		_, other := Locate(struct{ id int }{})
+		assert.NotEqual(t, n, other)
+	})
+
+	t.Run("function", func(t *testing.T) {
+		p, n := Locate(newLocateClosure)
+		assert.Equal(t, pkg, p)
+		assert.Equal(t, "newLocateClosure", n)
+
+		p, n = Locate(newLocateClosure())
+		assert.Equal(t, pkg, p)
+		// closure symbols vary with inlining, e.g. "newLocateClosure.func1" or "newLocateClosure.1"
+		assert.Regexp(t, `^newLocateClosure\.`, n)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		p, n := Locate(nil)
+		assert.Empty(t, p)
+		assert.Empty(t, n)
+	})
+}
+
+type validated struct {
+	Host    string   `scan:",required"`
+	Port    int      `validate:"min=1,max=65535"`
+	Name    string   `validate:"required,max=8,regex=^[a-z]+(-[a-z]+){0,2}$"`
+	Peers   []string `validate:"min=1"`
+	Timeout *int     `validate:"required"`
+	Skipped string   `scan:"-" validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	timeout := 0
+	valid := validated{Host: "localhost", Port: 8080, Name: "api-gw", Peers: []string{"a"}, Timeout: &timeout}
+	assert.NoError(t, Validate(&valid))
+	assert.NoError(t, Validate(valid))
+
+	err := Validate(&validated{Port: 70000, Name: "API"})
+	assert.True(t, errors.Is(err, errors.BadRequest))
+	for _, msg := range []string{
+		"Host is required",
+		"Port must be at most 65535",
+		"Name must match",
+		"Peers must have at least 1 items",
+		"Timeout is required",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+	assert.NotContains(t, err.Error(), "Skipped")
+
+	err = Validate(&validated{Host: "h", Port: 1, Name: "a-b-c-d", Peers: []string{"a"}, Timeout: &timeout})
+	assert.ErrorContains(t, err, "Name must match")
+	assert.NotContains(t, err.Error(), "Host")
+
+	// Apply followed by Validate catches values missing from the loaded data
+	var loaded validated
+	assert.NoError(t, Apply(&loaded, []common.Pair[string, []byte]{common.NewPair("Port", []byte("0"))}))
+	assert.ErrorContains(t, Validate(&loaded), "Host is required")
+
+	type malformed struct {
+		Pattern string `validate:"regex=("`
+	}
+	err = Validate(malformed{})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, errors.BadRequest))
+}
+
+func TestValidateOneOf(t *testing.T) {
+	type dbConfig struct {
+		Type  string `scan:",required,oneof=mysql|postgres|sqlite"`
+		Level int    `validate:"oneof=1|2|3"`
+	}
+	assert.NoError(t, Validate(dbConfig{Type: "postgres", Level: 2}))
+
+	err := Validate(dbConfig{Type: "postgre", Level: 4})
+	assert.True(t, errors.Is(err, errors.BadRequest))
+	assert.ErrorContains(t, err, `Type must be one of mysql, postgres, sqlite, got "postgre"`)
+	assert.ErrorContains(t, err, `Level must be one of 1, 2, 3, got "4"`)
+
+	// required still reports a missing value as missing
+	assert.ErrorContains(t, Validate(dbConfig{Level: 1}), "Type is required")
+}
+
+func TestValidateTagged(t *testing.T) {
+	type request struct {
+		UserName string `json:"user_name,omitempty" validate:"required"`
+		Email    string `json:"-" validate:"required"`
+		Age      int    `validate:"required"`
+	}
+	err := ValidateTagged(&request{}, "json")
+	assert.True(t, errors.Is(err, errors.BadRequest))
+	assert.ErrorContains(t, err, "user_name is required")
+	assert.ErrorContains(t, err, "Email is required")
+	assert.ErrorContains(t, err, "Age is required")
+	assert.NotContains(t, err.Error(), "UserName")
+}
+
+type copyConfig struct {
+	Name     string
+	Limit    *int
+	Hosts    []string
+	Labels   map[string][]string
+	Children []*copyConfig
+	Extra    any
+	Parent   *copyConfig
+	Notify   chan struct{}
+	hidden   []int
+}
+
+func TestDeepCopy(t *testing.T) {
+	limit := 10
+	src := &copyConfig{
+		Name:   "root",
+		Limit:  &limit,
+		Hosts:  []string{"a", "b"},
+		Labels: map[string][]string{"env": {"prod"}},
+		Children: []*copyConfig{
+			{Name: "child", Hosts: []string{"c"}},
+		},
+		Extra:  map[string]any{"nested": []int{1, 2}},
+		Notify: make(chan struct{}),
+		hidden: []int{1},
+	}
+	src.Children[0].Parent = src // cycle
+
+	dst := DeepCopy(src)
+	assert.Equal(t, src.Name, dst.Name)
+	assert.Equal(t, src.Hosts, dst.Hosts)
+	assert.Equal(t, src.Labels, dst.Labels)
+	assert.Equal(t, src.Extra, dst.Extra)
+
+	// pointers are independent
+	assert.NotSame(t, src, dst)
+	assert.NotSame(t, src.Limit, dst.Limit)
+	*dst.Limit = 20
+	assert.Equal(t, 10, *src.Limit)
+
+	// nested slices and maps are independent
+	dst.Hosts[0] = "x"
+	dst.Labels["env"][0] = "dev"
+	dst.Labels["new"] = nil
+	dst.Children[0].Hosts[0] = "y"
+	dst.Extra.(map[string]any)["nested"].([]int)[0] = 9
+	assert.Equal(t, []string{"a", "b"}, src.Hosts)
+	assert.Equal(t, map[string][]string{"env": {"prod"}}, src.Labels)
+	assert.Equal(t, []string{"c"}, src.Children[0].Hosts)
+	assert.Equal(t, []int{1, 2}, src.Extra.(map[string]any)["nested"])
+
+	// cycles point back into the copy
+	assert.Same(t, dst, dst.Children[0].Parent)
+
+	// channels and unexported fields are shallow
+	assert.Equal(t, src.Notify, dst.Notify)
+	assert.Equal(t, src.hidden, dst.hidden)
+
+	// values, nils and interfaces
+	assert.Equal(t, copyConfig{Name: "value"}, DeepCopy(copyConfig{Name: "value"}))
+	assert.Nil(t, DeepCopy[*copyConfig](nil))
+	assert.Nil(t, DeepCopy[any](nil))
+	list := []any{[]string{"a"}}
+	copied := DeepCopy(list)
+	copied[0].([]string)[0] = "b"
+	assert.Equal(t, []any{[]string{"a"}}, list)
+}
+
+type mapAddress struct {
+	City string
+	Zip  *int
+}
+
+type mapRecord struct {
+	Name     string
+	Age      int
+	Tags     []string
+	Address  mapAddress
+	Previous *mapAddress
+	Created  time.Time
+	Secret   string `scan:"-"`
+	internal string
+}
+
+func TestToMapFromMap(t *testing.T) {
+	zip := 10001
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := &mapRecord{
+		Name:     "foo",
+		Age:      42,
+		Tags:     []string{"a", "b"},
+		Address:  mapAddress{City: "nyc", Zip: &zip},
+		Created:  created,
+		Secret:   "hidden",
+		internal: "internal",
+	}
+	m, err := ToMap(in)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"Name":     "foo",
+		"Age":      42,
+		"Tags":     []string{"a", "b"},
+		"Address":  map[string]any{"City": "nyc", "Zip": 10001},
+		"Previous": nil,
+		"Created":  created,
+	}, m)
+
+	out := &mapRecord{Secret: "kept"}
+	require.NoError(t, FromMap(out, m))
+	assert.Equal(t, &mapRecord{
+		Name:    "foo",
+		Age:     42,
+		Tags:    []string{"a", "b"},
+		Address: mapAddress{City: "nyc", Zip: &zip},
+		Created: created,
+		Secret:  "kept",
+	}, out)
+
+	// through json, numbers become float64, slices []any and times strings
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	decoded["Previous"] = map[string]any{"City": "sf"}
+	out = &mapRecord{}
+	require.NoError(t, FromMap(out, decoded))
+	assert.Equal(t, 42, out.Age)
+	assert.Equal(t, []string{"a", "b"}, out.Tags)
+	assert.Equal(t, 10001, *out.Address.Zip)
+	assert.Equal(t, "sf", out.Previous.City)
+	assert.True(t, created.Equal(out.Created))
+
+	err = FromMap(&mapRecord{}, map[string]any{"Address": map[string]any{"Zip": 1.5}})
+	assert.True(t, errors.Is(err, errors.InvalidArgument))
+	assert.Contains(t, err.Error(), "Address.Zip")
+	assert.Error(t, FromMap(mapRecord{}, m))
+	m, err = ToMap((*mapRecord)(nil))
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+type mergeDB struct {
+	Host    string
+	Port    int
+	Options map[string]string
+}
+
+type mergeConfig struct {
+	Name     string
+	Debug    bool
+	Timeout  time.Duration
+	DB       mergeDB
+	Cache    *mergeDB
+	Replicas *int
+	Tags     []string
+	Plugins  []string          `scan:",append"`
+	Labels   map[string]string `scan:",append"`
+	Started  time.Time
+	Secret   string `scan:"-"`
+}
+
+func TestMerge(t *testing.T) {
+	one, three := 1, 3
+	defaults := mergeConfig{
+		Name:     "app",
+		Timeout:  time.Second,
+		DB:       mergeDB{Host: "localhost", Port: 5432, Options: map[string]string{"sslmode": "disable"}},
+		Cache:    &mergeDB{Host: "cache", Port: 6379},
+		Replicas: &one,
+		Tags:     []string{"a"},
+		Plugins:  []string{"auth"},
+		Labels:   map[string]string{"team": "core", "tier": "1"},
+		Secret:   "default",
+	}
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	override := mergeConfig{
+		Debug:   true,
+		DB:      mergeDB{Host: "db.internal"},
+		Cache:   &mergeDB{Port: 6380},
+		Tags:    []string{"b", "c"},
+		Plugins: []string{"metrics"},
+		Labels:  map[string]string{"tier": "2"},
+		Started: started,
+		Secret:  "ignored",
+	}
+	merged := Merge(defaults, override)
+	assert.Equal(t, mergeConfig{
+		Name:     "app",
+		Debug:    true,
+		Timeout:  time.Second,
+		DB:       mergeDB{Host: "db.internal", Port: 5432, Options: map[string]string{"sslmode": "disable"}},
+		Cache:    &mergeDB{Host: "cache", Port: 6380},
+		Replicas: &one,
+		Tags:     []string{"b", "c"},
+		Plugins:  []string{"auth", "metrics"},
+		Labels:   map[string]string{"team": "core", "tier": "2"},
+		Started:  started,
+		Secret:   "default",
+	}, merged)
+
+	// the inputs are left untouched and share nothing with the result
+	assert.Equal(t, 6379, defaults.Cache.Port)
+	assert.Equal(t, []string{"auth"}, defaults.Plugins)
+	assert.Equal(t, "1", defaults.Labels["tier"])
+	merged.DB.Options["sslmode"] = "require"
+	*merged.Replicas = 2
+	assert.Equal(t, "disable", defaults.DB.Options["sslmode"])
+	assert.Equal(t, 1, one)
+
+	// layers apply left to right, an empty non-nil slice clears
+	layered := Merge(Merge(defaults, override), mergeConfig{Replicas: &three, Tags: []string{}})
+	assert.Equal(t, 3, *layered.Replicas)
+	assert.Empty(t, layered.Tags)
+	assert.NotNil(t, layered.Tags)
+	assert.Equal(t, "db.internal", layered.DB.Host)
+
+	// a zero override inherits everything
+	assert.Equal(t, defaults, Merge(defaults, mergeConfig{}))
+
+	// pointers and scalars at the top level
+	merged2 := Merge(&mergeDB{Host: "a", Port: 1}, &mergeDB{Port: 2})
+	assert.Equal(t, &mergeDB{Host: "a", Port: 2}, merged2)
+	assert.Equal(t, &mergeDB{Host: "a"}, Merge(&mergeDB{Host: "a"}, nil))
+	assert.Equal(t, "b", Merge("a", "b"))
+	assert.Equal(t, "a", Merge("a", ""))
+}
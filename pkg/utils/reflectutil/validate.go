@@ -0,0 +1,218 @@
+package reflectutil
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/errutil"
+)
+
+const (
+	validateTagKey = "validate"
+	tagRequired    = "required"
+	tagOneOf       = "oneof"
+)
+
+// rules holds the constraints declared on a field by its validate tag, e.g.
+// `validate:"required,min=1,max=64,regex=^[a-z]+$"`, or by the required and
+// oneof options of its scan tag, e.g. `scan:",required,oneof=mysql|postgres"`.
+// min and max bound numbers by value and strings, slices and maps by length.
+// oneof lists the allowed values of a string or integer. regex must come last
+// since the pattern may itself contain commas.
+type rules struct {
+	required bool
+	min      *float64
+	max      *float64
+	oneof    []string
+	regex    *regexp.Regexp
+	err      error // malformed tag, reported on Validate
+}
+
+func (r rules) empty() bool {
+	return !r.required && r.min == nil && r.max == nil && r.oneof == nil && r.regex == nil && r.err == nil
+}
+
+func parseRules(field reflect.StructField, scanTags []string) rules {
+	var r rules
+	for _, tag := range scanTags[min(len(scanTags), 1):] {
+		key, value, _ := strings.Cut(tag, "=")
+		switch key {
+		case tagRequired:
+			r.required = true
+		case tagOneOf:
+			r.oneof = strings.Split(value, "|")
+		}
+	}
+	tag := field.Tag.Get(validateTagKey)
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case tagRequired:
+			r.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				r.err = errors.Newf("invalid %s constraint %q on field %s", key, value, field.Name)
+				continue
+			}
+			if key == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "regex":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				r.err = errors.Wrapf(err, "invalid regex constraint on field %s", field.Name)
+				continue
+			}
+			r.regex = re
+		case tagOneOf:
+			r.oneof = strings.Split(value, "|")
+		case "":
+		default:
+			r.err = errors.Newf("unknown constraint %q on field %s", key, field.Name)
+		}
+	}
+	return r
+}
+
+// Validate checks the scannable fields of obj, a struct or a pointer to one,
+// against their validate tags and the required option of their scan tags. It
+// is meant to run right after Apply, and reports every violation at once as an
+// BadRequest error whose details map each offending field to its violation.
+func Validate(obj any) error {
+	return validate(obj, "")
+}
+
+// ValidateTagged validates obj like Validate, but names each offending field
+// by its name in the struct tag key, e.g. "json", so the violations match the
+// keys of the request they were decoded from. Fields without a name in that
+// tag keep their Go name.
+func ValidateTagged(obj any, key string) error {
+	return validate(obj, key)
+}
+
+func validate(obj any, key string) error {
+	objValue := reflect.ValueOf(obj)
+	if objValue.Kind() == reflect.Pointer {
+		if objValue.IsNil() {
+			return errors.InvalidArgument.Newf("obj must not be nil")
+		}
+		objValue = objValue.Elem()
+	}
+	if objValue.Kind() != reflect.Struct {
+		return errors.Newf("unsupported obj kind: %s", objValue.Kind())
+	}
+	var errs []error
+	fields := labels.Set{}
+	for _, field := range fieldsOf(objValue.Type()) {
+		if field.rules.empty() {
+			continue
+		}
+		if field.rules.err != nil {
+			return field.rules.err
+		}
+		name := field.name
+		if key != "" {
+			if tagged, _, _ := strings.Cut(field.tag.Get(key), ","); tagged != "" && tagged != "-" {
+				name = tagged
+			}
+		}
+		if err := field.rules.check(name, objValue.Field(field.index)); err != nil {
+			errs = append(errs, err)
+			fields[name] = err.Error()
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errutil.WithFields(errors.BadRequest.Wrapf(errors.Combine(errs...), "invalid %s", objValue.Type().Name()), fields)
+}
+
+// check reports the first constraint v violates. A required pointer only needs
+// to be set, so a pointer to a zero value is how a field opts into zero being
+// a valid setting.
+func (r rules) check(name string, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if r.required {
+				return errors.Newf("%s is required", name)
+			}
+			return nil
+		}
+		v = v.Elem()
+	} else if r.required && v.IsZero() {
+		return errors.Newf("%s is required", name)
+	}
+	size, sized := measure(v)
+	if r.min != nil && sized && size < *r.min {
+		return boundError(name, "at least", v, *r.min)
+	}
+	if r.max != nil && sized && size > *r.max {
+		return boundError(name, "at most", v, *r.max)
+	}
+	if s, ok := enumValue(v); ok && r.oneof != nil && !slices.Contains(r.oneof, s) {
+		return errors.Newf("%s must be one of %s, got %q", name, strings.Join(r.oneof, ", "), s)
+	}
+	if r.regex != nil && v.Kind() == reflect.String && !r.regex.MatchString(v.String()) {
+		return errors.Newf("%s must match %s", name, r.regex)
+	}
+	return nil
+}
+
+// measure returns the value compared against min and max: the value itself for
+// numbers, and the length for strings, slices and maps.
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	default:
+		return math.NaN(), false
+	}
+}
+
+// enumValue returns the value compared against oneof: strings as they are
+// and integers in decimal.
+func enumValue(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	default:
+		return "", false
+	}
+}
+
+func boundError(name string, relation string, v reflect.Value, bound float64) error {
+	s := strconv.FormatFloat(bound, 'f', -1, 64)
+	switch v.Kind() {
+	case reflect.String:
+		return errors.Newf("%s must be %s %s characters long", name, relation, s)
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return errors.Newf("%s must have %s %s items", name, relation, s)
+	}
+	return errors.Newf("%s must be %s %s", name, relation, s)
+}
@@ -103,3 +103,86 @@ func Changes[T comparable](from []T, to []T) ([]T, []T) {
 	}
 	return toAdd, toRemove
 }
+
+// Union returns the distinct elements of a followed by those of b that are not
+// in a. Elements keep the order of their first occurrence.
+func Union[T comparable](a, b []T) []T {
+	return Deduplicate(append(Copy(a), b...)...)
+}
+
+// Intersect returns the distinct elements of a that are also in b, in the
+// order of their first occurrence in a.
+func Intersect[T comparable](a, b []T) []T {
+	in := make(map[T]bool, len(b))
+	for _, elem := range b {
+		in[elem] = true
+	}
+	return filterUnique(a, func(elem T) bool { return in[elem] })
+}
+
+// Difference returns the distinct elements of a that are not in b, in the
+// order of their first occurrence in a.
+func Difference[T comparable](a, b []T) []T {
+	in := make(map[T]bool, len(b))
+	for _, elem := range b {
+		in[elem] = true
+	}
+	return filterUnique(a, func(elem T) bool { return !in[elem] })
+}
+
+// filterUnique returns the distinct elements of elements that satisfy keep,
+// in the order of their first occurrence.
+func filterUnique[T comparable](elements []T, keep func(T) bool) []T {
+	var result []T
+	seen := make(map[T]bool)
+	for _, elem := range elements {
+		if !seen[elem] && keep(elem) {
+			result = append(result, elem)
+		}
+		seen[elem] = true
+	}
+	return result
+}
+
+// GroupBy groups elements by key, keeping their order within each group.
+func GroupBy[T any, K comparable](elements []T, key func(T) K) map[K][]T {
+	result := make(map[K][]T)
+	for _, elem := range elements {
+		k := key(elem)
+		result[k] = append(result[k], elem)
+	}
+	return result
+}
+
+// ToMap indexes elements by key. On duplicate keys the last element wins.
+func ToMap[T any, K comparable](elements []T, key func(T) K) map[K]T {
+	result := make(map[K]T, len(elements))
+	for _, elem := range elements {
+		result[key(elem)] = elem
+	}
+	return result
+}
+
+// Partition splits elements into those satisfying pred and the rest, in one
+// pass and keeping their order.
+func Partition[T any](elements []T, pred func(T) bool) (matching, rest []T) {
+	for _, elem := range elements {
+		if pred(elem) {
+			matching = append(matching, elem)
+		} else {
+			rest = append(rest, elem)
+		}
+	}
+	return matching, rest
+}
+
+// FindIndex returns the index of the first element satisfying pred, or -1 if
+// there is none.
+func FindIndex[T any](elements []T, pred func(T) bool) int {
+	for i, elem := range elements {
+		if pred(elem) {
+			return i
+		}
+	}
+	return -1
+}
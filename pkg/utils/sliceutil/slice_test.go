@@ -465,3 +465,249 @@ func slicesEqualIgnoreOrder(a, b []int) bool {
 
 	return reflect.DeepEqual(countA, countB)
 }
+
+type keyed struct {
+	id    int
+	group string
+}
+
+func TestGroupBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []keyed
+		expected map[string][]keyed
+	}{
+		{
+			name:     "groups keep element order",
+			elements: []keyed{{1, "a"}, {2, "b"}, {3, "a"}},
+			expected: map[string][]keyed{
+				"a": {{1, "a"}, {3, "a"}},
+				"b": {{2, "b"}},
+			},
+		},
+		{
+			name:     "empty slice",
+			elements: []keyed{},
+			expected: map[string][]keyed{},
+		},
+		{
+			name:     "nil slice",
+			elements: nil,
+			expected: map[string][]keyed{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GroupBy(tt.elements, func(k keyed) string { return k.group })
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("GroupBy() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []keyed
+		expected map[int]keyed
+	}{
+		{
+			name:     "unique keys",
+			elements: []keyed{{1, "a"}, {2, "b"}},
+			expected: map[int]keyed{1: {1, "a"}, 2: {2, "b"}},
+		},
+		{
+			name:     "duplicate keys last wins",
+			elements: []keyed{{1, "a"}, {2, "b"}, {1, "c"}},
+			expected: map[int]keyed{1: {1, "c"}, 2: {2, "b"}},
+		},
+		{
+			name:     "empty slice",
+			elements: []keyed{},
+			expected: map[int]keyed{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ToMap(tt.elements, func(k keyed) int { return k.id })
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ToMap() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		name      string
+		a         []string
+		b         []string
+		union     []string
+		intersect []string
+		diff      []string
+	}{
+		{
+			name:      "overlapping sets keep first occurrence order",
+			a:         []string{"c", "a", "b", "a"},
+			b:         []string{"d", "b", "c", "d"},
+			union:     []string{"c", "a", "b", "d"},
+			intersect: []string{"c", "b"},
+			diff:      []string{"a"},
+		},
+		{
+			name:      "disjoint sets",
+			a:         []string{"a", "b"},
+			b:         []string{"c"},
+			union:     []string{"a", "b", "c"},
+			intersect: nil,
+			diff:      []string{"a", "b"},
+		},
+		{
+			name:      "identical sets",
+			a:         []string{"a", "b"},
+			b:         []string{"b", "a"},
+			union:     []string{"a", "b"},
+			intersect: []string{"a", "b"},
+			diff:      nil,
+		},
+		{
+			name:      "empty a",
+			a:         []string{},
+			b:         []string{"b", "b", "a"},
+			union:     []string{"b", "a"},
+			intersect: nil,
+			diff:      nil,
+		},
+		{
+			name:      "nil b",
+			a:         []string{"a", "a"},
+			b:         nil,
+			union:     []string{"a"},
+			intersect: nil,
+			diff:      []string{"a"},
+		},
+		{
+			name:      "both nil",
+			a:         nil,
+			b:         nil,
+			union:     nil,
+			intersect: nil,
+			diff:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Union(tt.a, tt.b); !reflect.DeepEqual(result, tt.union) {
+				t.Errorf("Union() = %v, want %v", result, tt.union)
+			}
+			if result := Intersect(tt.a, tt.b); !reflect.DeepEqual(result, tt.intersect) {
+				t.Errorf("Intersect() = %v, want %v", result, tt.intersect)
+			}
+			if result := Difference(tt.a, tt.b); !reflect.DeepEqual(result, tt.diff) {
+				t.Errorf("Difference() = %v, want %v", result, tt.diff)
+			}
+		})
+	}
+
+	// inputs are left untouched
+	a := make([]string, 2, 4)
+	a[0], a[1] = "a", "b"
+	Union(a, []string{"c"})
+	if got := a[:cap(a)][2]; got != "" {
+		t.Errorf("Union() wrote past the end of a: %q", got)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name     string
+		elements []int
+		matching []int
+		rest     []int
+	}{
+		{
+			name:     "mixed keeps order",
+			elements: []int{1, 2, 3, 4, 6, 5},
+			matching: []int{2, 4, 6},
+			rest:     []int{1, 3, 5},
+		},
+		{
+			name:     "all match",
+			elements: []int{2, 4},
+			matching: []int{2, 4},
+			rest:     nil,
+		},
+		{
+			name:     "no match",
+			elements: []int{1, 3},
+			matching: nil,
+			rest:     []int{1, 3},
+		},
+		{
+			name:     "empty slice",
+			elements: []int{},
+			matching: nil,
+			rest:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matching, rest := Partition(tt.elements, even)
+			if !reflect.DeepEqual(matching, tt.matching) {
+				t.Errorf("Partition() matching = %v, want %v", matching, tt.matching)
+			}
+			if !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("Partition() rest = %v, want %v", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestFindIndex(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name     string
+		elements []int
+		expected int
+	}{
+		{
+			name:     "first match",
+			elements: []int{1, 3, 4, 6},
+			expected: 2,
+		},
+		{
+			name:     "all match",
+			elements: []int{2, 4},
+			expected: 0,
+		},
+		{
+			name:     "no match",
+			elements: []int{1, 3},
+			expected: -1,
+		},
+		{
+			name:     "empty slice",
+			elements: []int{},
+			expected: -1,
+		},
+		{
+			name:     "nil slice",
+			elements: nil,
+			expected: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := FindIndex(tt.elements, even); result != tt.expected {
+				t.Errorf("FindIndex() = %d, want %d", result, tt.expected)
+			}
+		})
+	}
+}
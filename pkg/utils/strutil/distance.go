@@ -0,0 +1,49 @@
+package strutil
+
+// Levenshtein returns the edit distance between a and b: the number of
+// single-character insertions, deletions and substitutions turning a into b.
+// Characters are compared as runes, so multi-byte characters count once.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	// one row of the distance matrix, indexed by the position in the shorter string
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			next := min(row[j]+1, row[j-1]+1, diag+cost)
+			diag, row[j] = row[j], next
+		}
+	}
+	return row[len(rb)]
+}
+
+// ClosestMatch returns the candidate with the smallest Levenshtein distance to
+// input along with that distance, preferring the earlier candidate on ties. It
+// returns "" and -1 if there are no candidates. Callers suggesting corrections
+// typically drop matches whose distance exceeds a small threshold.
+//
+// Example:
+//
+//	if match, d := ClosestMatch("statsu", []string{"start", "status", "stop"}); d >= 0 && d <= 2 {
+//		fmt.Printf("unknown command, did you mean %q?\n", match)
+//	}
+func ClosestMatch(input string, candidates []string) (string, int) {
+	match, best := "", -1
+	for _, c := range candidates {
+		if d := Levenshtein(input, c); best < 0 || d < best {
+			match, best = c, d
+		}
+	}
+	return match, best
+}
@@ -0,0 +1,50 @@
+package strutil
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"status", "stauts", 2},
+		{"config", "config", 0},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := Levenshtein(tt.b, tt.a); got != tt.expected {
+			t.Errorf("Levenshtein(%q, %q) = %d, expected %d", tt.b, tt.a, got, tt.expected)
+		}
+	}
+}
+
+func TestClosestMatch(t *testing.T) {
+	commands := []string{"start", "status", "stop", "restart"}
+	tests := []struct {
+		input    string
+		match    string
+		distance int
+	}{
+		{"status", "status", 0},
+		{"statsu", "status", 2},
+		{"stp", "stop", 1},
+		{"restrat", "restart", 2},
+	}
+	for _, tt := range tests {
+		match, d := ClosestMatch(tt.input, commands)
+		if match != tt.match || d != tt.distance {
+			t.Errorf("ClosestMatch(%q) = %q, %d, expected %q, %d", tt.input, match, d, tt.match, tt.distance)
+		}
+	}
+	if match, d := ClosestMatch("stop", nil); match != "" || d != -1 {
+		t.Errorf("ClosestMatch without candidates = %q, %d, expected \"\", -1", match, d)
+	}
+}
@@ -0,0 +1,125 @@
+package strutil
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type human struct {
+	precision int
+	si        bool
+}
+
+// HumanOption configures HumanBytes and HumanDuration.
+type HumanOption func(*human)
+
+// Precision sets the number of decimals HumanBytes keeps (default 1), or the
+// number of consecutive units HumanDuration renders (default 2). Trailing
+// zero decimals are dropped.
+func Precision(n int) HumanOption {
+	return func(h *human) {
+		if n >= 0 {
+			h.precision = n
+		}
+	}
+}
+
+// SI makes HumanBytes use powers of 1000 (kB, MB, ...) instead of powers of
+// 1024 (KiB, MiB, ...).
+func SI() HumanOption {
+	return func(h *human) {
+		h.si = true
+	}
+}
+
+func newHuman(precision int, opts ...HumanOption) *human {
+	h := &human{precision: precision}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+var (
+	binaryUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits     = []string{"kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// HumanBytes renders a byte count with the largest unit that keeps it at or
+// above 1, e.g. "1.5 GiB", or "1.6 GB" with SI.
+func HumanBytes(n int64, opts ...HumanOption) string {
+	h := newHuman(1, opts...)
+	base, units := 1024.0, binaryUnits
+	if h.si {
+		base, units = 1000.0, siUnits
+	}
+	value := math.Abs(float64(n))
+	if value < base {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	scale := math.Pow(10, float64(h.precision))
+	i := -1
+	// keep dividing while rounding would show a full next unit, e.g. 1023.96 KiB
+	for i+1 < len(units) && math.Round(value*scale)/scale >= base {
+		value /= base
+		i++
+	}
+	s := strconv.FormatFloat(value, 'f', h.precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if n < 0 {
+		s = "-" + s
+	}
+	return s + " " + units[i]
+}
+
+var durationUnits = []struct {
+	size uint64
+	name string
+}{
+	{uint64(24 * time.Hour), "d"},
+	{uint64(time.Hour), "h"},
+	{uint64(time.Minute), "m"},
+	{uint64(time.Second), "s"},
+	{uint64(time.Millisecond), "ms"},
+	{uint64(time.Microsecond), "µs"},
+	{uint64(time.Nanosecond), "ns"},
+}
+
+// HumanDuration renders d with its largest unit and the units following it,
+// truncating the rest, e.g. "2d3h" for 51h20m. Zero components are left out,
+// so 2d5m renders as "2d" with the default precision.
+func HumanDuration(d time.Duration, opts ...HumanOption) string {
+	h := newHuman(2, opts...)
+	if d == 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+	}
+	// negating in uint64 also covers math.MinInt64
+	rest := uint64(d)
+	if d < 0 {
+		rest = uint64(-d)
+	}
+	shown := 0
+	for _, unit := range durationUnits {
+		if shown == 0 && rest < unit.size {
+			continue
+		}
+		if shown == max(h.precision, 1) {
+			break
+		}
+		shown++
+		if count := rest / unit.size; count > 0 {
+			b.WriteString(strconv.FormatUint(count, 10))
+			b.WriteString(unit.name)
+		}
+		rest %= unit.size
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package strutil
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		n        int64
+		opts     []HumanOption
+		expected string
+	}{
+		{0, nil, "0 B"},
+		{1023, nil, "1023 B"},
+		{1024, nil, "1 KiB"},
+		{1536, nil, "1.5 KiB"},
+		{1048575, nil, "1 MiB"},
+		{3 * 1 << 29, nil, "1.5 GiB"},
+		{-1536, nil, "-1.5 KiB"},
+		{1234567, []HumanOption{Precision(3)}, "1.177 MiB"},
+		{1234567, []HumanOption{Precision(0)}, "1 MiB"},
+		{999, []HumanOption{SI()}, "999 B"},
+		{1000, []HumanOption{SI()}, "1 kB"},
+		{1500000000, []HumanOption{SI()}, "1.5 GB"},
+		{math.MaxInt64, nil, "8 EiB"},
+	}
+	for _, tt := range tests {
+		if got := HumanBytes(tt.n, tt.opts...); got != tt.expected {
+			t.Errorf("HumanBytes(%d) = %q, want %q", tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		opts     []HumanOption
+		expected string
+	}{
+		{0, nil, "0s"},
+		{500 * time.Nanosecond, nil, "500ns"},
+		{1500 * time.Microsecond, nil, "1ms500µs"},
+		{90 * time.Second, nil, "1m30s"},
+		{51*time.Hour + 20*time.Minute, nil, "2d3h"},
+		{48*time.Hour + 5*time.Minute, nil, "2d"},
+		{-90 * time.Minute, nil, "-1h30m"},
+		{51*time.Hour + 20*time.Minute + 7*time.Second, []HumanOption{Precision(4)}, "2d3h20m7s"},
+		{51 * time.Hour, []HumanOption{Precision(1)}, "2d"},
+		{math.MinInt64, []HumanOption{Precision(1)}, "-106751d"},
+	}
+	for _, tt := range tests {
+		if got := HumanDuration(tt.d, tt.opts...); got != tt.expected {
+			t.Errorf("HumanDuration(%s) = %q, want %q", tt.d, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package strutil
+
+import (
+	"strings"
+
+	"github.com/xhanio/errors"
+)
+
+// Interpolate replaces ${key} placeholders in s with values returned by lookup.
+// ${key:-default} falls back to default when the key is unset or empty, and
+// \$ emits a literal "$". It fails on an unresolved key without a default.
+func Interpolate(s string, lookup func(key string) (string, bool)) (string, error) {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", errors.InvalidArgument.Newf("unterminated placeholder at position %d", i)
+			}
+			expr := s[i+2 : i+2+end]
+			key, def, hasDefault := strings.Cut(expr, ":-")
+			if key == "" {
+				return "", errors.InvalidArgument.Newf("empty placeholder at position %d", i)
+			}
+			value, ok := lookup(key)
+			if !ok || value == "" {
+				if hasDefault {
+					value = def
+				} else if !ok {
+					return "", errors.NotFound.Newf("unresolved placeholder ${%s}", key)
+				}
+			}
+			b.WriteString(value)
+			i += 2 + end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
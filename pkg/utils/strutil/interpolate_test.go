@@ -0,0 +1,123 @@
+package strutil
+
+import (
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "5432",
+		"EMPTY": "",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "no placeholders",
+			input:    "plain string",
+			expected: "plain string",
+		},
+		{
+			name:     "single placeholder",
+			input:    "${HOST}",
+			expected: "localhost",
+		},
+		{
+			name:     "multiple placeholders",
+			input:    "postgres://${HOST}:${PORT}/db",
+			expected: "postgres://localhost:5432/db",
+		},
+		{
+			name:     "default for unset key",
+			input:    "${USER:-admin}@${HOST}",
+			expected: "admin@localhost",
+		},
+		{
+			name:     "default ignored for set key",
+			input:    "${PORT:-3306}",
+			expected: "5432",
+		},
+		{
+			name:     "default for empty key",
+			input:    "${EMPTY:-fallback}",
+			expected: "fallback",
+		},
+		{
+			name:     "empty default",
+			input:    "[${USER:-}]",
+			expected: "[]",
+		},
+		{
+			name:     "default containing colon",
+			input:    "${ADDR:-127.0.0.1:80}",
+			expected: "127.0.0.1:80",
+		},
+		{
+			name:     "empty value without default",
+			input:    "[${EMPTY}]",
+			expected: "[]",
+		},
+		{
+			name:     "escaped placeholder",
+			input:    `\${HOST} is ${HOST}`,
+			expected: "${HOST} is localhost",
+		},
+		{
+			name:     "escaped dollar",
+			input:    `cost \$5`,
+			expected: "cost $5",
+		},
+		{
+			name:     "lone dollar kept",
+			input:    "$HOST $",
+			expected: "$HOST $",
+		},
+		{
+			name:     "other backslashes kept",
+			input:    `C:\path\${HOST}`,
+			expected: `C:\path${HOST}`,
+		},
+		{
+			name:    "unresolved key",
+			input:   "${USER}",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated placeholder",
+			input:   "${HOST",
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			input:   "${:-default}",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Interpolate(tt.input, lookup)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Interpolate(%q) expected error, got %q", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Interpolate(%q) unexpected error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Interpolate(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
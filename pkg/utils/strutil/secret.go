@@ -0,0 +1,43 @@
+package strutil
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const secretMask = "****"
+
+// Secret is a string, e.g. a password or a token, that prints as "****" with
+// fmt, in JSON and thereby in printutil tables and log fields, so it cannot be
+// leaked by printing the struct holding it. Reveal returns the value for the
+// code that actually needs it. Being a string type, it is assigned from string
+// literals and decoded from config and JSON like a plain string.
+type Secret string
+
+// Reveal returns the value of s.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	return secretMask
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(secretMask)
+}
+
+// Format masks s for every verb, including %x and %v with flags, which would
+// otherwise format the underlying string.
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'q', verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, strconv.Quote(secretMask))
+	default:
+		fmt.Fprint(f, secretMask)
+	}
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(secretMask)), nil
+}
@@ -0,0 +1,46 @@
+package strutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	secret := Secret("hunter2")
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%10s"} {
+		if got := fmt.Sprintf(format, secret); strings.Contains(got, "hunter2") || strings.Contains(got, "68756e74657232") {
+			t.Errorf("Sprintf(%q) = %q leaks the secret", format, got)
+		}
+	}
+	if got := fmt.Sprintf("%s", secret); got != "****" {
+		t.Errorf("Sprintf(%%s) = %q, want ****", got)
+	}
+
+	config := struct {
+		User     string `json:"user"`
+		Password Secret `json:"password"`
+	}{User: "admin", Password: secret}
+	if got := fmt.Sprintf("%+v", config); got != "{User:admin Password:****}" {
+		t.Errorf("Sprintf(%%+v) = %q", got)
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"user":"admin","password":"****"}` {
+		t.Errorf("json.Marshal = %s", b)
+	}
+
+	// decoding is not masked, so secrets load from config as usual
+	if err := json.Unmarshal([]byte(`{"user":"root","password":"s3cret"}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Password.Reveal(); got != "s3cret" {
+		t.Errorf("Reveal() = %q, want s3cret", got)
+	}
+	if got := secret.Reveal(); got != "hunter2" {
+		t.Errorf("Reveal() = %q, want hunter2", got)
+	}
+}
@@ -2,8 +2,11 @@ package task
 
 import (
 	"context"
+	"io"
 	"path"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/robfig/cron/v3"
 
@@ -13,11 +16,16 @@ import (
 	"github.com/xhanio/framingo/pkg/utils/infra"
 	"github.com/xhanio/framingo/pkg/utils/job/executor"
 	"github.com/xhanio/framingo/pkg/utils/log"
+	"github.com/xhanio/framingo/pkg/utils/printutil"
 	"github.com/xhanio/framingo/pkg/utils/reflectutil"
 )
 
 var exiting = &Task{}
 
+// maxCatchUp bounds the runs MisfireRunAll makes up for, so a frequent
+// schedule missed over a long outage does not flood the queue.
+const maxCatchUp = 100
+
 var _ Manager = (*manager)(nil)
 
 type manager struct {
@@ -25,18 +33,31 @@ type manager struct {
 
 	name string
 
-	cm    *cron.Cron
-	cl    *sync.RWMutex // lock for crons
-	crons map[string]cron.EntryID
+	store   Store
+	resolve Resolver
+
+	cm       *cron.Cron
+	parser   cron.Parser
+	cl       *sync.RWMutex // lock for crons
+	crons    map[string]cron.EntryID
+	nexts    map[string]*time.Timer // self-rescheduled tasks waiting to be re-queued
+	catchUps map[string]int         // missed fire times still to run under MisfireRunAll
 
-	pq   staque.Priority[*Task]
-	pipe chan *Task
+	pq    staque.Priority[*Task]
+	queue staque.Persistable[*Definition] // nil when queued tasks are kept in memory only
+	pipe  chan *Task
 
 	concurrent int
 	workers    chan struct{}
 	el         *sync.RWMutex   // lock for executing
 	ew         *sync.WaitGroup // wait group for executing
 	executing  map[string]executor.Executor
+	completed  atomic.Uint64
+	failed     atomic.Uint64
+
+	pl      sync.Mutex    // lock for paused, resumed and cancel
+	paused  chan struct{} // closed while paused
+	resumed chan struct{} // non-nil while paused, closed on Resume
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -51,24 +72,34 @@ func newScheduler(opts ...Option) *manager {
 	m := &manager{
 		log:       log.Default,
 		cl:        &sync.RWMutex{},
+		parser:    cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
 		crons:     make(map[string]cron.EntryID),
+		nexts:     make(map[string]*time.Timer),
+		catchUps:  make(map[string]int),
 		el:        &sync.RWMutex{},
 		ew:        &sync.WaitGroup{},
 		executing: make(map[string]executor.Executor),
 		wg:        &sync.WaitGroup{},
+		store:     nopStore{},
+		paused:    make(chan struct{}),
 	}
 	m.apply(opts...)
 	if m.cm == nil {
 		m.cm = cron.New(
 			cron.WithLocation(infra.Timezone),
-			cron.WithParser(cron.NewParser(cron.SecondOptional|cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow)),
+			cron.WithParser(m.parser),
 		)
 	}
-	m.pq = staque.NewPriority(
+	pqOpts := []staque.Option[*Task]{
 		staque.WithLessFunc(priorityFunc),
 		staque.WithLogger[*Task](m.log),
 		staque.BlockIfEmpty[*Task](),
-	)
+	}
+	if m.queue != nil {
+		m.pq = staque.NewPersistent[*Task](&queueBackend{m: m}, pqOpts...)
+	} else {
+		m.pq = staque.NewPriority(pqOpts...)
+	}
 	m.pipe = make(chan *Task)
 	m.workers = make(chan struct{}, m.concurrent)
 	return m
@@ -87,25 +118,145 @@ func (m *manager) Dependencies() []common.Service {
 
 func (m *manager) Add(tasks ...*Task) error {
 	for _, t := range tasks {
-		key := t.Key()
-		if key == "" {
+		if err := m.add(t, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *manager) add(t *Task, persist bool) error {
+	key := t.Key()
+	if key == "" {
+		return nil
+	}
+	if t.Schedule == "" {
+		// run directly
+		m.pq.Push(t)
+		return nil
+	}
+	// scheduled by cron
+	cronID, err := m.cm.AddFunc(t.Schedule, func() {
+		m.pq.Push(t)
+		m.fired(t, time.Now())
+	})
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	m.cl.Lock()
+	if cid, ok := m.crons[key]; ok {
+		// replace the previous schedule of the same task
+		m.cm.Remove(cid)
+	}
+	m.crons[key] = cronID
+	m.cl.Unlock()
+	if persist {
+		def, err := newDefinition(t)
+		if err != nil {
+			return err
+		}
+		if err := m.store.Save(def); err != nil {
+			return errors.Wrapf(err, "failed to persist task %s", key)
+		}
+	}
+	m.recoverMisfires(t)
+	return nil
+}
+
+// fired records that t fired at, so missed fire times can be found after a
+// restart. Tasks that skip misfires have nothing to recover and are not
+// recorded.
+func (m *manager) fired(t *Task, at time.Time) {
+	if t.Misfire == MisfireSkip {
+		return
+	}
+	if err := m.store.SetLastFire(t.Key(), at); err != nil {
+		m.log.Warnf("failed to record last fire of task %s: %s", t.Key(), err)
+	}
+}
+
+// recoverMisfires queues t according to its misfire policy if fire times of
+// its schedule passed since it last fired.
+func (m *manager) recoverMisfires(t *Task) {
+	if t.Misfire == MisfireSkip {
+		return
+	}
+	key := t.Key()
+	last, err := m.store.LastFire(key)
+	if err != nil {
+		m.log.Warnf("failed to get last fire of task %s: %s", key, err)
+		return
+	}
+	if last.IsZero() {
+		return
+	}
+	schedule, err := m.parser.Parse(t.Schedule)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	missed := 0
+	latest := last
+	for next := schedule.Next(last.In(infra.Timezone)); !next.After(now) && missed < maxCatchUp; next = schedule.Next(next) {
+		missed++
+		latest = next
+	}
+	if missed == 0 {
+		return
+	}
+	m.log.Infof("task %s missed %d fire times since %s", key, missed, last.Local().Format(time.RFC3339))
+	if t.Misfire == MisfireRunAll && missed > 1 {
+		m.cl.Lock()
+		m.catchUps[key] = missed - 1
+		m.cl.Unlock()
+	}
+	m.pq.Push(t)
+	m.fired(t, latest)
+}
+
+// catchUp reports whether t still has missed fire times to run, consuming one.
+func (m *manager) catchUp(t *Task) bool {
+	m.cl.Lock()
+	defer m.cl.Unlock()
+	n, ok := m.catchUps[t.Key()]
+	if !ok {
+		return false
+	}
+	if n <= 1 {
+		delete(m.catchUps, t.Key())
+	} else {
+		m.catchUps[t.Key()] = n - 1
+	}
+	return true
+}
+
+// reload registers the scheduled tasks persisted in the store, skipping the
+// ones already added from code.
+func (m *manager) reload() error {
+	defs, err := m.store.Load()
+	if err != nil {
+		return errors.Wrapf(err, "failed to load tasks")
+	}
+	for _, def := range defs {
+		m.cl.RLock()
+		_, exists := m.crons[def.Key]
+		m.cl.RUnlock()
+		if exists {
 			continue
 		}
-		if t.Schedule != "" {
-			// scheduled by cron
-			cronID, err := m.cm.AddFunc(t.Schedule, func() {
-				m.pq.Push(t)
-			})
-			if err != nil {
-				return errors.Wrap(err)
-			}
-			m.cl.Lock()
-			m.crons[key] = cronID
-			m.cl.Unlock()
-		} else {
-			// run directly
-			m.pq.Push(t)
+		if m.resolve == nil {
+			m.log.Warnf("no resolver to restore task %s", def.Key)
+			continue
+		}
+		fn, err := m.resolve(def)
+		if err != nil {
+			m.log.Warnf("failed to restore task %s: %s", def.Key, err)
+			continue
 		}
+		if err := m.add(def.newTask(fn), false); err != nil {
+			return err
+		}
+		m.log.Debugf("task %s restored with schedule %s", def.Key, def.Schedule)
 	}
 	return nil
 }
@@ -122,22 +273,50 @@ func (m *manager) Remove(tasks ...*Task) {
 				m.cm.Remove(cid)
 				delete(m.crons, key)
 			}
+			delete(m.catchUps, key)
 			m.cl.Unlock()
+			if err := m.store.Delete(key); err != nil {
+				m.log.Warnf("failed to delete task %s from store: %s", key, err)
+			}
 		}
+		m.cl.Lock()
+		if timer, ok := m.nexts[key]; ok {
+			timer.Stop()
+			delete(m.nexts, key)
+		}
+		m.cl.Unlock()
 		t.Job.Cancel()
 		m.pq.Remove(t) // try removing anyway since task could be executing already
 	}
 }
 
 func (m *manager) Start(ctx context.Context) error {
-	if m.cancel != nil {
+	if m.started() {
 		m.log.Warnf("service already started")
 		return nil
 	}
-	m.cm.Start()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	if pq, ok := m.pq.(staque.Persistent[*Task]); ok {
+		n, err := pq.Recover()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			m.log.Infof("recovered %d queued tasks", n)
+		}
+	}
 	m.pipe = make(chan *Task)
 	m.workers = make(chan struct{}, m.concurrent)
+	m.completed.Store(0)
+	m.failed.Store(0)
+	m.pl.Lock()
 	m.ctx, m.cancel = context.WithCancel(ctx)
+	if m.resumed == nil {
+		m.cm.Start()
+	}
+	m.pl.Unlock()
 	m.wg.Add(2)
 	// goroutine to fetch tasks
 	go func() {
@@ -150,20 +329,44 @@ func (m *manager) Start(ctx context.Context) error {
 			} else if !task.IsValid() {
 				continue
 			}
+			paused, resumed := m.pauseState()
+			if resumed != nil && task != exiting {
+				// hand the task back until Resume, so it keeps its place in the queue
+				m.pq.Push(task)
+				select {
+				case <-m.ctx.Done():
+					m.stopFetching()
+					return
+				case <-resumed:
+				}
+				continue
+			}
 			if task.Exclusive {
 				m.log.Debugf("task %s wait for all other tasks to complete...", task.Key())
 				m.ew.Wait()
 				m.log.Debugf("continue on current exclusive task %s...", task.Key())
 			}
+			// counted ahead of the hand-over, a worker may finish the task
+			// before this goroutine resumes
+			m.ew.Add(1)
+			weight := m.weightOf(task)
+			if !m.acquireWorkers(weight, paused) {
+				m.ew.Done()
+				if m.ctx.Err() != nil {
+					m.stopFetching()
+					return
+				}
+				// paused while waiting for a worker
+				m.pq.Push(task)
+				continue
+			}
 			select {
 			case <-m.ctx.Done():
-				close(m.pipe)
-				close(m.workers)
-				m.pq.Reset()
-				m.log.Infof("stopped fetching execution tasks")
+				m.releaseWorkers(weight)
+				m.ew.Done()
+				m.stopFetching()
 				return
 			case m.pipe <- task:
-				m.ew.Add(1)
 			}
 			if task.Exclusive {
 				// block all other tasks from being popped
@@ -174,6 +377,7 @@ func (m *manager) Start(ctx context.Context) error {
 		}
 	}()
 	// goroutine to execute tasks concurrently
+	pipe := m.pipe
 	go func() {
 		defer m.wg.Done()
 		for {
@@ -186,6 +390,11 @@ func (m *manager) Start(ctx context.Context) error {
 					m.cm.Remove(cid)
 					delete(m.crons, key)
 				}
+				for key, timer := range m.nexts {
+					timer.Stop()
+					delete(m.nexts, key)
+				}
+				clear(m.catchUps)
 				m.cm.Stop()
 				// push a task with a nil task to unblock m.pq.Pop() and enter the exiting loop above
 				m.pq.Push(exiting)
@@ -197,15 +406,27 @@ func (m *manager) Start(ctx context.Context) error {
 				}
 				m.log.Infof("stopped executing tasks")
 				return
-			case m.workers <- struct{}{}:
+			case task, ok := <-pipe:
+				if !ok {
+					pipe = nil
+					continue
+				}
 				go func() {
-					task := <-m.pipe
-					defer func() {
-						<-m.workers
-					}()
+					defer m.releaseWorkers(m.weightOf(task))
 					if !task.IsValid() {
 						return
 					}
+					var (
+						stats *executor.Stats
+						err   error
+					)
+					// deferred ahead of the cleanup below, so it runs after the
+					// task has left the executing set and the exclusive gate
+					defer func(task *Task) {
+						if stats != nil {
+							m.complete(task, stats, err)
+						}
+					}(task)
 					defer func(task *Task) {
 						m.el.Lock()
 						delete(m.executing, task.Key())
@@ -220,16 +441,30 @@ func (m *manager) Start(ctx context.Context) error {
 					opts = append(opts, executor.WithTimeout(task.Timeout))
 					opts = append(opts, executor.WithRetry(task.RetryAttempts, task.RetryDelay))
 					opts = append(opts, executor.WithCooldown(task.Cooldown))
+					opts = append(opts, executor.WithNextRun(task.NextRun))
 					te := executor.New(task.Job, opts...)
 					m.el.Lock()
 					m.executing[task.Key()] = te
 					m.el.Unlock()
-					err := te.Start(task.Ctx, task.Params)
-					if err != nil {
+					err = te.Start(task.Ctx, task.Params)
+					stats = te.Stats()
+					switch {
+					case errors.Is(err, executor.AlreadyDone):
+						// a duplicate of a task that already succeeded
+						m.completed.Add(1)
+						m.log.Debugf("task %s skipped: %s", task.Key(), err)
+					case err != nil:
+						m.failed.Add(1)
 						m.log.Debugf("task %s ended with err: %s", task.Key(), err)
-					} else {
+					default:
+						m.completed.Add(1)
 						m.log.Debugf("task %s completed successfully", task.Key())
 					}
+					if delay, ok := te.NextRun(); ok {
+						m.requeue(task, delay)
+					} else if m.catchUp(task) {
+						m.pq.Push(task)
+					}
 				}()
 			}
 		}
@@ -237,15 +472,158 @@ func (m *manager) Start(ctx context.Context) error {
 	return nil
 }
 
+// weightOf returns the number of worker slots task takes, the weight of its
+// job capped at the concurrency so a heavy task can still run, alone.
+func (m *manager) weightOf(task *Task) int {
+	if task.Job == nil {
+		return 1
+	}
+	return max(min(task.Job.Weight(), m.concurrent), 1)
+}
+
+// acquireWorkers takes n worker slots for a task about to be handed over. Only
+// the fetching goroutine takes slots, so a heavy task waiting for enough of
+// them holds back the tasks queued after it rather than being starved by
+// them. It gives back the slots taken so far and returns false once the
+// manager is stopped or paused.
+func (m *manager) acquireWorkers(n int, paused <-chan struct{}) bool {
+	for i := range n {
+		select {
+		case <-m.ctx.Done():
+		case <-paused:
+		case m.workers <- struct{}{}:
+			continue
+		}
+		m.releaseWorkers(i)
+		return false
+	}
+	return true
+}
+
+func (m *manager) releaseWorkers(n int) {
+	for range n {
+		<-m.workers
+	}
+}
+
+func (m *manager) stopFetching() {
+	close(m.pipe)
+	close(m.workers)
+	if _, ok := m.pq.(staque.Persistent[*Task]); ok {
+		// queued tasks stay in the backend for the next Start
+		m.pq.Remove(exiting)
+	} else {
+		m.pq.Reset()
+	}
+	m.log.Infof("stopped fetching execution tasks")
+}
+
+// Pause stops dispatching queued tasks and suspends the cron schedules until
+// Resume. Executing tasks run to completion and Add keeps queueing tasks.
+// Schedules that would have fired while paused are skipped, not made up for.
+func (m *manager) Pause() {
+	m.pl.Lock()
+	defer m.pl.Unlock()
+	if m.resumed != nil {
+		return
+	}
+	m.resumed = make(chan struct{})
+	close(m.paused)
+	m.cm.Stop()
+	m.log.Infof("task dispatch paused")
+}
+
+// Resume dispatches queued tasks and fires the cron schedules again.
+func (m *manager) Resume() {
+	m.pl.Lock()
+	defer m.pl.Unlock()
+	if m.resumed == nil {
+		return
+	}
+	close(m.resumed)
+	m.resumed = nil
+	m.paused = make(chan struct{})
+	if m.cancel != nil {
+		m.cm.Start()
+	}
+	m.log.Infof("task dispatch resumed")
+}
+
+func (m *manager) started() bool {
+	m.pl.Lock()
+	defer m.pl.Unlock()
+	return m.cancel != nil
+}
+
+func (m *manager) Paused() bool {
+	m.pl.Lock()
+	defer m.pl.Unlock()
+	return m.resumed != nil
+}
+
+func (m *manager) pauseState() (paused, resumed <-chan struct{}) {
+	m.pl.Lock()
+	defer m.pl.Unlock()
+	return m.paused, m.resumed
+}
+
+// complete runs the OnComplete callback of t, recovering from its panics.
+func (m *manager) complete(t *Task, stats *executor.Stats, err error) {
+	if t.OnComplete == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.log.Errorf("on complete callback of task %s panicked: %v", t.Key(), r)
+		}
+	}()
+	t.OnComplete(stats, err)
+}
+
+// requeue pushes the task back to the queue once delay has passed, unless the
+// task is removed or the manager is stopped in the meantime.
+func (m *manager) requeue(t *Task, delay time.Duration) {
+	ctx := m.ctx
+	key := t.Key()
+	m.cl.Lock()
+	defer m.cl.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	if timer, ok := m.nexts[key]; ok {
+		timer.Stop()
+	}
+	m.log.Debugf("task %s will run again in %s", key, delay)
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		m.cl.Lock()
+		if m.nexts[key] != timer {
+			m.cl.Unlock()
+			return
+		}
+		delete(m.nexts, key)
+		m.cl.Unlock()
+		if ctx.Err() == nil {
+			m.pq.Push(t)
+		}
+	})
+	m.nexts[key] = timer
+}
+
 func (m *manager) Stop(wait bool) error {
-	if m.cancel == nil {
+	m.pl.Lock()
+	cancel := m.cancel
+	m.pl.Unlock()
+	if cancel == nil {
 		return nil
 	}
-	m.cancel()
+	cancel()
 	if wait {
 		m.wg.Wait()
 	}
+	m.pl.Lock()
 	m.cancel = nil
+	m.pl.Unlock()
 	return nil
 }
 
@@ -257,3 +635,44 @@ func (m *manager) Stats(id string) *executor.Stats {
 	}
 	return nil
 }
+
+func (m *manager) DryRun(ctx context.Context, t *Task) (*executor.Stats, error) {
+	if !t.IsValid() {
+		return nil, errors.InvalidArgument.Newf("task without a job")
+	}
+	if ctx == nil {
+		ctx = t.Ctx
+	}
+	te := executor.New(t.Job.Clone(), executor.DryRun(), executor.WithTimeout(t.Timeout))
+	err := te.Start(ctx, t.Params)
+	return te.Stats(), err
+}
+
+func (m *manager) Metrics() *Metrics {
+	m.el.RLock()
+	executing := len(m.executing)
+	m.el.RUnlock()
+	return &Metrics{
+		Queued:    m.pq.Length(),
+		Executing: executing,
+		Idle:      max(m.concurrent-len(m.workers), 0),
+		Completed: m.completed.Load(),
+		Failed:    m.failed.Load(),
+		Paused:    m.Paused(),
+	}
+}
+
+func (m *manager) Info(w io.Writer, debug bool) {
+	metrics := m.Metrics()
+	t := printutil.NewTable(w)
+	t.Header(m.Name())
+	t.Title("stat", "value")
+	t.Row("queued", metrics.Queued)
+	t.Row("executing", metrics.Executing)
+	t.Row("idle", metrics.Idle)
+	t.Row("completed", metrics.Completed)
+	t.Row("failed", metrics.Failed)
+	t.Row("paused", metrics.Paused)
+	t.NewLine()
+	t.Flush()
+}
@@ -2,15 +2,23 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/robfig/cron/v3"
+
 	"github.com/xhanio/errors"
 	"github.com/xhanio/framingo/pkg/structs/staque"
+	"github.com/xhanio/framingo/pkg/utils/infra"
 	"github.com/xhanio/framingo/pkg/utils/job"
+	"github.com/xhanio/framingo/pkg/utils/job/executor"
 	"github.com/xhanio/framingo/pkg/utils/log"
 	"github.com/xhanio/framingo/pkg/utils/printutil"
 	"github.com/xhanio/framingo/pkg/utils/strutil"
@@ -59,6 +67,44 @@ func TestMaxConcurrency(t *testing.T) {
 	_ = s.Stop(true)
 }
 
+func TestWeightedTasks(t *testing.T) {
+	var running, peak atomic.Int32
+	var done sync.WaitGroup
+	tasks := make([]*Task, 6)
+	for i := range tasks {
+		done.Add(1)
+		tasks[i] = &Task{
+			Job: job.New(fmt.Sprintf("heavy-%d", i), func(tc job.Context) error {
+				defer done.Done()
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(50 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			}, job.WithWeight(2)),
+		}
+	}
+	s := newScheduler(MaxConcurrency(4))
+	if err := s.Add(tasks...); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(true)
+
+	done.Wait()
+	// each task takes two of the four worker slots
+	if p := peak.Load(); p != 2 {
+		t.Errorf("expected at most 2 weighted tasks at once, got %d", p)
+	}
+}
+
 func TestExclusivePlan(t *testing.T) {
 	tasks := make([]*Task, 20)
 	for i := range 20 {
@@ -86,6 +132,54 @@ func TestExclusivePlan(t *testing.T) {
 	time.Sleep(1 * time.Second)
 }
 
+func TestExclusiveHandOver(t *testing.T) {
+	const n = 200
+	var running atomic.Int32
+	var exclusiveRunning atomic.Bool
+	var overlaps atomic.Int32
+	var finished sync.WaitGroup
+	finished.Add(n)
+	tasks := make([]*Task, n)
+	for i := range n {
+		exclusive := i%10 == 0
+		tasks[i] = &Task{
+			// instant jobs, so workers finish them while the fetcher is
+			// still handing them over
+			Job: job.New(fmt.Sprintf("#%d", i), func(tc job.Context) error {
+				defer finished.Done()
+				if exclusive {
+					exclusiveRunning.Store(true)
+					defer exclusiveRunning.Store(false)
+				}
+				if running.Add(1) > 1 && exclusive || exclusiveRunning.Load() && !exclusive {
+					overlaps.Add(1)
+				}
+				running.Add(-1)
+				return nil
+			}),
+			Exclusive: exclusive,
+		}
+	}
+	s := newScheduler(MaxConcurrency(4))
+	_ = s.Start(context.Background())
+	defer s.Stop(true)
+	_ = s.Add(tasks...)
+
+	done := make(chan struct{})
+	go func() {
+		finished.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("tasks did not complete")
+	}
+	if n := overlaps.Load(); n > 0 {
+		t.Fatalf("exclusive tasks overlapped other tasks %d times", n)
+	}
+}
+
 func TestPriorityQueue(t *testing.T) {
 	pq := staque.NewPriority(
 		staque.WithLessFunc(priorityFunc),
@@ -111,3 +205,391 @@ func TestPriorityQueue(t *testing.T) {
 	}
 	table.Flush()
 }
+
+func TestNextRun(t *testing.T) {
+	var runs atomic.Int32
+	task := &Task{
+		Job: job.New("self-rescheduling", func(tc job.Context) error {
+			runs.Add(1)
+			return nil
+		}),
+		NextRun: func(stats *executor.Stats) (time.Duration, bool) {
+			return 100 * time.Millisecond, runs.Load() < 3
+		},
+	}
+	s := newScheduler(MaxConcurrency(1))
+	_ = s.Start(context.Background())
+	_ = s.Add(task)
+	time.Sleep(1 * time.Second)
+	_ = s.Stop(true)
+	if n := runs.Load(); n != 3 {
+		t.Fatalf("expected task to run 3 times, got %d", n)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var applied atomic.Int32
+	task := &Task{
+		Job: job.New("dry-run", func(tc job.Context) error {
+			if tc.IsDryRun() {
+				return nil
+			}
+			applied.Add(1)
+			return nil
+		}),
+		Schedule: "@every 1h",
+	}
+	s := newScheduler(MaxConcurrency(1))
+	_ = s.Start(context.Background())
+	defer s.Stop(true)
+	_ = s.Add(task)
+
+	stats, err := s.DryRun(context.Background(), task)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !stats.Job.DryRun || stats.Job.State != string(job.StateSucceeded) {
+		t.Fatalf("unexpected dry run stats: %+v", stats.Job)
+	}
+	if applied.Load() != 0 {
+		t.Fatal("dry run applied its changes")
+	}
+	if task.Job.State() != job.StateCreated {
+		t.Fatalf("dry run changed the scheduled job to %s", task.Job.State())
+	}
+
+	// a task whose idempotency key already ran can still be dry run
+	done := &Task{Job: job.New("done", func(tc job.Context) error {
+		if !tc.IsDryRun() {
+			applied.Add(1)
+		}
+		return nil
+	}, job.WithIdempotencyKey("dry-run-done"))}
+	job.DefaultDeduper.Mark("dry-run-done", time.Hour)
+	if stats, err := s.DryRun(context.Background(), done); err != nil || !stats.Job.DryRun {
+		t.Fatalf("dry run of a completed task failed: %v", err)
+	}
+	if applied.Load() != 0 {
+		t.Fatal("dry run of a completed task applied its changes")
+	}
+	if _, err := s.DryRun(context.Background(), &Task{}); !errors.Is(err, errors.InvalidArgument) {
+		t.Fatalf("expected invalid argument, got %v", err)
+	}
+}
+
+func TestOnComplete(t *testing.T) {
+	type completion struct {
+		stats *executor.Stats
+		err   error
+	}
+	done := make(chan completion, 2)
+	onComplete := func(stats *executor.Stats, err error) {
+		done <- completion{stats, err}
+	}
+	s := newScheduler(MaxConcurrency(2))
+	_ = s.Start(context.Background())
+	defer s.Stop(true)
+	_ = s.Add(&Task{
+		Job: newTestJob("panicking", 10*time.Millisecond, false),
+		OnComplete: func(stats *executor.Stats, err error) {
+			panic("boom")
+		},
+	})
+	_ = s.Add(&Task{Job: newTestJob("failing", 50*time.Millisecond, true), OnComplete: onComplete})
+
+	select {
+	case c := <-done:
+		if c.err == nil || c.err.Error() != "job failing failed" {
+			t.Fatalf("expected the task error, got %v", c.err)
+		}
+		if c.stats == nil || c.stats.Job.ID != "failing" || c.stats.Job.State != string(job.StateFailed) {
+			t.Fatalf("unexpected stats: %+v", c.stats)
+		}
+		if s.Stats("failing") != nil {
+			t.Fatal("task should no longer be executing when OnComplete is called")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnComplete was not called")
+	}
+	// the panicking callback did not take its worker down
+	_ = s.Add(&Task{Job: newTestJob("succeeding", 10*time.Millisecond, false), OnComplete: onComplete})
+	select {
+	case c := <-done:
+		if c.err != nil {
+			t.Fatalf("expected no error, got %v", c.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnComplete was not called")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := newScheduler(MaxConcurrency(2))
+	for i := range 5 {
+		_ = s.Add(&Task{Job: newTestJob(fmt.Sprintf("#%d", i), 100*time.Millisecond, i == 0)})
+	}
+	// pushed but not yet executed
+	metrics := s.Metrics()
+	if metrics.Queued != 5 || metrics.Executing != 0 || metrics.Idle != 2 {
+		t.Fatalf("unexpected metrics before start: %+v", metrics)
+	}
+
+	_ = s.Start(context.Background())
+	defer s.Stop(true)
+	time.Sleep(50 * time.Millisecond)
+	metrics = s.Metrics()
+	if metrics.Executing != 2 || metrics.Idle != 0 {
+		t.Fatalf("expected 2 executing tasks, got %+v", metrics)
+	}
+
+	time.Sleep(time.Second)
+	metrics = s.Metrics()
+	if metrics.Queued != 0 || metrics.Executing != 0 || metrics.Completed != 4 || metrics.Failed != 1 {
+		t.Fatalf("unexpected metrics after completion: %+v", metrics)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	s := newScheduler(MaxConcurrency(2))
+	_ = s.Start(context.Background())
+	defer s.Stop(true)
+	_ = s.Add(&Task{Job: newTestJob("running", 200*time.Millisecond, false)})
+	time.Sleep(50 * time.Millisecond)
+
+	s.Pause()
+	for i := range 3 {
+		_ = s.Add(&Task{Job: newTestJob(fmt.Sprintf("#%d", i), 50*time.Millisecond, false)})
+	}
+	// the executing task finishes, the queued ones do not start
+	time.Sleep(300 * time.Millisecond)
+	metrics := s.Metrics()
+	if !metrics.Paused || metrics.Queued != 3 || metrics.Executing != 0 || metrics.Completed != 1 {
+		t.Fatalf("unexpected metrics while paused: %+v", metrics)
+	}
+
+	s.Resume()
+	time.Sleep(300 * time.Millisecond)
+	metrics = s.Metrics()
+	if metrics.Paused || metrics.Queued != 0 || metrics.Completed != 4 {
+		t.Fatalf("expected queued tasks to run after resume: %+v", metrics)
+	}
+}
+
+func TestPauseResumeWhileStopping(t *testing.T) {
+	s := newScheduler(MaxConcurrency(1))
+	_ = s.Start(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			s.Pause()
+			s.Resume()
+		}
+	}()
+	_ = s.Stop(true)
+	<-done
+	if s.Paused() {
+		t.Fatal("expected dispatch to be resumed")
+	}
+}
+
+func TestQueueBackend(t *testing.T) {
+	backend := staque.NewFileBackend[*Definition](t.TempDir())
+	var runs atomic.Int32
+	resolve := func(def *Definition) (job.Func, error) {
+		return func(tc job.Context) error {
+			runs.Add(1)
+			return nil
+		}, nil
+	}
+
+	// tasks queued before the process goes down are never dispatched
+	s1 := newScheduler(MaxConcurrency(1), WithQueueBackend(backend))
+	_ = s1.Add(
+		&Task{Job: newTestJob("queued-1", 0, false), Params: map[string]string{"target": "db"}},
+		&Task{Job: newTestJob("queued-2", 0, false), Priority: 1},
+	)
+	defs, err := backend.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 2 {
+		t.Fatalf("expected 2 queued tasks in the backend, got %d", len(defs))
+	}
+
+	// after a restart they are recovered and run
+	s2 := newScheduler(MaxConcurrency(1), WithQueueBackend(backend), WithResolver(resolve))
+	_ = s2.Start(context.Background())
+	time.Sleep(500 * time.Millisecond)
+	_ = s2.Stop(true)
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("expected 2 recovered tasks to run, got %d", n)
+	}
+	if defs, _ := backend.Load(); len(defs) != 0 {
+		t.Fatalf("expected dispatched tasks to leave the backend, got %d", len(defs))
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store := NewFileStore(path)
+
+	var runs atomic.Int32
+	var params atomic.Value
+	resolve := func(def *Definition) (job.Func, error) {
+		if def.Key != "persisted" {
+			return nil, errors.NotFound.Newf("unknown task %s", def.Key)
+		}
+		return func(tc job.Context) error {
+			var p map[string]string
+			if err := json.Unmarshal(tc.GetParams().(json.RawMessage), &p); err != nil {
+				return err
+			}
+			params.Store(p["target"])
+			runs.Add(1)
+			return nil
+		}, nil
+	}
+
+	// first run registers the task from code
+	s1 := newScheduler(MaxConcurrency(1), WithStore(store))
+	_ = s1.Start(context.Background())
+	err := s1.Add(&Task{
+		Job:      job.New("persisted", func(tc job.Context) error { return nil }, job.WithLabel("team", "ops")),
+		Params:   map[string]string{"target": "db"},
+		Schedule: "* * * * * *",
+		Priority: 3,
+		Timeout:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s1.Stop(true)
+
+	defs, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 1 {
+		t.Fatalf("expected 1 persisted task, got %d", len(defs))
+	}
+	def := defs[0]
+	if def.Key != "persisted" || def.Schedule != "* * * * * *" || def.Priority != 3 || def.Timeout != time.Minute || def.Labels["team"] != "ops" {
+		t.Fatalf("unexpected persisted definition: %+v", def)
+	}
+
+	// after a restart the task is restored from the store without re-registering
+	s2 := newScheduler(MaxConcurrency(1), WithStore(store), WithResolver(resolve))
+	_ = s2.Start(context.Background())
+	time.Sleep(1500 * time.Millisecond)
+	_ = s2.Stop(true)
+	if runs.Load() == 0 {
+		t.Fatal("expected restored task to run")
+	}
+	if p := params.Load(); p != "db" {
+		t.Fatalf("unexpected restored params: %v", p)
+	}
+
+	// removing the task deletes its definition
+	s2.Remove(&Task{Job: job.New("persisted", nil), Schedule: def.Schedule})
+	defs, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 0 {
+		t.Fatalf("expected no persisted tasks after remove, got %d", len(defs))
+	}
+}
+
+func TestMisfire(t *testing.T) {
+	// a daily schedule that last fired a bit over two days ago missed two or
+	// three fire times depending on the time of day
+	const schedule = "0 0 * * *"
+	lastFire := time.Now().Add(-49 * time.Hour)
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missed := 0
+	for next := sched.Next(lastFire.In(infra.Timezone)); !next.After(time.Now()); next = sched.Next(next) {
+		missed++
+	}
+
+	tests := []struct {
+		name     string
+		policy   MisfirePolicy
+		expected int
+	}{
+		{"skip", MisfireSkip, 0},
+		{"run once", MisfireRunOnce, 1},
+		{"run all", MisfireRunAll, missed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewFileStore(filepath.Join(t.TempDir(), "tasks.json"))
+			var runs atomic.Int32
+			task := &Task{
+				Job: job.New("nightly", func(tc job.Context) error {
+					runs.Add(1)
+					return nil
+				}),
+				Schedule: schedule,
+				Misfire:  tt.policy,
+			}
+			def, err := newDefinition(task)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the process went down after the task fired
+			if err := store.Save(def); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.SetLastFire(def.Key, lastFire); err != nil {
+				t.Fatal(err)
+			}
+
+			s := newScheduler(MaxConcurrency(1), WithStore(store))
+			if err := s.Add(task); err != nil {
+				t.Fatal(err)
+			}
+			_ = s.Start(context.Background())
+			time.Sleep(500 * time.Millisecond)
+			_ = s.Stop(true)
+
+			if got := int(runs.Load()); got != tt.expected {
+				t.Fatalf("expected %d runs, got %d", tt.expected, got)
+			}
+			recorded, err := store.LastFire(def.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.policy != MisfireSkip && !recorded.After(lastFire) {
+				t.Fatal("expected the recovered fire time to be recorded")
+			}
+		})
+	}
+}
+
+func TestMisfireRecordsFire(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "tasks.json"))
+	s := newScheduler(MaxConcurrency(1), WithStore(store))
+	_ = s.Start(context.Background())
+	err := s.Add(&Task{
+		Job:      job.New("ticker", func(tc job.Context) error { return nil }),
+		Schedule: "* * * * * *",
+		Misfire:  MisfireRunOnce,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	_ = s.Stop(true)
+
+	last, err := store.LastFire("ticker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(last) > 2*time.Second {
+		t.Fatalf("expected a recent fire to be recorded, got %s", last)
+	}
+}
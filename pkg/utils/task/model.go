@@ -17,11 +17,49 @@ var (
 type Manager interface {
 	common.Service
 	common.Daemon
+	common.Debuggable
 	Add(tasks ...*Task) error
 	Remove(tasks ...*Task)
 	Stats(id string) *executor.Stats
+	// DryRun runs a copy of the job of t right away as a dry run, see
+	// executor.DryRun, bypassing the queue, the workers and the schedule, so
+	// operators can test a scheduled task safely. It returns once the run
+	// ends, with the stats of the copy.
+	DryRun(ctx context.Context, t *Task) (*executor.Stats, error)
+	Metrics() *Metrics
+	// Pause holds queued tasks and cron schedules without canceling executing
+	// tasks, e.g. during maintenance, until Resume. Unlike Stop it keeps the
+	// schedules, and it outlasts a Stop and Start.
+	Pause()
+	Resume()
+	Paused() bool
 }
 
+// Metrics is a snapshot of how busy the manager is.
+type Metrics struct {
+	Queued    int    `json:"queued"`    // tasks waiting in the priority queue
+	Executing int    `json:"executing"` // tasks currently running
+	Idle      int    `json:"idle"`      // worker slots available for more tasks, see job.WithWeight
+	Completed uint64 `json:"completed"` // tasks succeeded since start
+	Failed    uint64 `json:"failed"`    // tasks failed since start
+	Paused    bool   `json:"paused"`    // dispatch held by Pause
+}
+
+// MisfirePolicy selects what happens to the fire times of a scheduled task
+// that passed while the process was down. Detecting them needs a Store, see
+// WithStore.
+type MisfirePolicy int
+
+const (
+	// MisfireSkip drops missed fire times; the task waits for its next one.
+	MisfireSkip MisfirePolicy = iota
+	// MisfireRunOnce runs the task once for any number of missed fire times.
+	MisfireRunOnce
+	// MisfireRunAll runs the task once per missed fire time, one after the
+	// other, up to maxCatchUp runs.
+	MisfireRunAll
+)
+
 type Task struct {
 	Job           job.Job         `json:"-"`
 	Ctx           context.Context `json:"-"`
@@ -34,6 +72,13 @@ type Task struct {
 	Once          bool            `json:"once"`
 	RetryAttempts int             `json:"retry_attempts,omitempty"`
 	RetryDelay    time.Duration   `json:"retry_delay,omitempty"`
+	Misfire       MisfirePolicy   `json:"misfire,omitempty"`
+	// NextRun re-queues the task after it completes, see executor.WithNextRun
+	NextRun func(stats *executor.Stats) (time.Duration, bool) `json:"-"`
+	// OnComplete is called after every run of the task with its final stats and
+	// error, once the task no longer counts as executing. A panic in it is
+	// recovered and logged.
+	OnComplete func(stats *executor.Stats, err error) `json:"-"`
 }
 
 func (t *Task) Key() string {
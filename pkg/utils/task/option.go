@@ -1,6 +1,7 @@
 package task
 
 import (
+	"github.com/xhanio/framingo/pkg/structs/staque"
 	"github.com/xhanio/framingo/pkg/utils/log"
 )
 
@@ -18,6 +19,32 @@ func WithLogger(logger log.Logger) Option {
 	}
 }
 
+// WithStore persists the definitions of scheduled tasks so they are registered
+// again on Start after a restart. Loaded definitions need a Resolver to get
+// their job functions back, see WithResolver.
+func WithStore(store Store) Option {
+	return func(m *manager) {
+		m.store = store
+	}
+}
+
+// WithQueueBackend keeps the tasks waiting in the queue in backend, e.g. a
+// staque.NewFileBackend, so they are queued again on Start after a crash or
+// restart, scheduled or not. A task is stored until it is dispatched to a
+// worker. Like WithStore, recovering needs a Resolver, see WithResolver.
+func WithQueueBackend(backend staque.Persistable[*Definition]) Option {
+	return func(m *manager) {
+		m.queue = backend
+	}
+}
+
+// WithResolver sets how job functions are looked up for definitions loaded from the store.
+func WithResolver(resolve Resolver) Option {
+	return func(m *manager) {
+		m.resolve = resolve
+	}
+}
+
 func MaxConcurrency(size int) Option {
 	return func(m *manager) {
 		m.concurrent = size
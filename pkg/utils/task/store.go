@@ -0,0 +1,270 @@
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xhanio/errors"
+	"github.com/xhanio/framingo/pkg/utils/job"
+)
+
+// Definition is the persisted form of a scheduled task. It only carries what
+// is needed to register the task again, not its execution state.
+type Definition struct {
+	Key           string            `json:"key"`
+	Schedule      string            `json:"schedule"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Params        json.RawMessage   `json:"params,omitempty"`
+	Priority      int               `json:"priority"`
+	Exclusive     bool              `json:"exclusive"`
+	Timeout       time.Duration     `json:"timeout,omitempty"`
+	Cooldown      time.Duration     `json:"cooldown,omitempty"`
+	Once          bool              `json:"once"`
+	RetryAttempts int               `json:"retry_attempts,omitempty"`
+	RetryDelay    time.Duration     `json:"retry_delay,omitempty"`
+	Misfire       MisfirePolicy     `json:"misfire,omitempty"`
+	// LastFire is when the task last fired, recorded with SetLastFire.
+	LastFire time.Time `json:"last_fire,omitempty"`
+}
+
+// Store persists task definitions across restarts.
+type Store interface {
+	// Save adds or replaces the definition of def.Key, keeping the last fire
+	// time already recorded for it.
+	Save(def *Definition) error
+	Load() ([]*Definition, error)
+	Delete(key string) error
+	// LastFire returns when the task key last fired, or the zero time if it
+	// never did.
+	LastFire(key string) (time.Time, error)
+	// SetLastFire records that the task key fired at t.
+	SetLastFire(key string, t time.Time) error
+}
+
+// Resolver returns the job function for a definition loaded from the store.
+type Resolver func(def *Definition) (job.Func, error)
+
+func newDefinition(t *Task) (*Definition, error) {
+	def := &Definition{
+		Key:           t.Key(),
+		Schedule:      t.Schedule,
+		Labels:        t.Job.Labels(),
+		Priority:      t.Priority,
+		Exclusive:     t.Exclusive,
+		Timeout:       t.Timeout,
+		Cooldown:      t.Cooldown,
+		Once:          t.Once,
+		RetryAttempts: t.RetryAttempts,
+		RetryDelay:    t.RetryDelay,
+		Misfire:       t.Misfire,
+	}
+	if t.Params != nil {
+		params, err := json.Marshal(t.Params)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode params of task %s", def.Key)
+		}
+		def.Params = params
+	}
+	return def, nil
+}
+
+// newTask rebuilds a task from its definition. Params are handed to the job
+// as the raw json they were stored as.
+func (def *Definition) newTask(fn job.Func) *Task {
+	t := &Task{
+		Job:           job.New(def.Key, fn, job.WithLabels(def.Labels)),
+		Schedule:      def.Schedule,
+		Priority:      def.Priority,
+		Exclusive:     def.Exclusive,
+		Timeout:       def.Timeout,
+		Cooldown:      def.Cooldown,
+		Once:          def.Once,
+		RetryAttempts: def.RetryAttempts,
+		RetryDelay:    def.RetryDelay,
+		Misfire:       def.Misfire,
+	}
+	if len(def.Params) > 0 {
+		t.Params = def.Params
+	}
+	return t
+}
+
+type nopStore struct{}
+
+func (nopStore) Save(*Definition) error       { return nil }
+func (nopStore) Load() ([]*Definition, error) { return nil, nil }
+func (nopStore) Delete(string) error          { return nil }
+
+func (nopStore) LastFire(string) (time.Time, error)  { return time.Time{}, nil }
+func (nopStore) SetLastFire(string, time.Time) error { return nil }
+
+type fileStore struct {
+	sync.Mutex
+	path string
+}
+
+// NewFileStore returns a Store keeping all definitions in a single json file.
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) read() (map[string]*Definition, error) {
+	defs := make(map[string]*Definition)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return defs, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err)
+	}
+	if len(data) == 0 {
+		return defs, nil
+	}
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode task store %s", s.path)
+	}
+	return defs, nil
+}
+
+// write replaces the file atomically so a crash never leaves it half written.
+func (s *fileStore) write(defs map[string]*Definition) error {
+	data, err := json.MarshalIndent(defs, "", "  ")
+	if err != nil {
+		return errors.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return errors.Wrap(err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.Wrap(err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.Wrap(err)
+	}
+	return nil
+}
+
+func (s *fileStore) Save(def *Definition) error {
+	s.Lock()
+	defer s.Unlock()
+	defs, err := s.read()
+	if err != nil {
+		return err
+	}
+	if prev, ok := defs[def.Key]; ok && def.LastFire.IsZero() {
+		kept := *def
+		kept.LastFire = prev.LastFire
+		def = &kept
+	}
+	defs[def.Key] = def
+	return s.write(defs)
+}
+
+func (s *fileStore) Load() ([]*Definition, error) {
+	s.Lock()
+	defer s.Unlock()
+	defs, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Definition, 0, len(defs))
+	for _, def := range defs {
+		result = append(result, def)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.Lock()
+	defer s.Unlock()
+	defs, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := defs[key]; !ok {
+		return nil
+	}
+	delete(defs, key)
+	return s.write(defs)
+}
+
+func (s *fileStore) LastFire(key string) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+	defs, err := s.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if def, ok := defs[key]; ok {
+		return def.LastFire, nil
+	}
+	return time.Time{}, nil
+}
+
+func (s *fileStore) SetLastFire(key string, t time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	defs, err := s.read()
+	if err != nil {
+		return err
+	}
+	def, ok := defs[key]
+	if !ok {
+		return errors.NotFound.Newf("task %s not found in store", key)
+	}
+	def.LastFire = t
+	return s.write(defs)
+}
+
+// queueBackend stores the tasks of a persistent queue as definitions in the
+// backend set by WithQueueBackend.
+type queueBackend struct {
+	m *manager
+}
+
+func (b *queueBackend) Save(key string, t *Task) error {
+	def, err := newDefinition(t)
+	if err != nil {
+		return err
+	}
+	return b.m.queue.Save(key, def)
+}
+
+func (b *queueBackend) Delete(key string) error {
+	return b.m.queue.Delete(key)
+}
+
+// Load rebuilds the stored tasks, skipping the ones the resolver cannot
+// restore.
+func (b *queueBackend) Load() ([]*Task, error) {
+	defs, err := b.m.queue.Load()
+	if err != nil {
+		return nil, err
+	}
+	var tasks []*Task
+	for _, def := range defs {
+		if b.m.resolve == nil {
+			b.m.log.Warnf("no resolver to recover queued task %s", def.Key)
+			continue
+		}
+		fn, err := b.m.resolve(def)
+		if err != nil {
+			b.m.log.Warnf("failed to recover queued task %s: %s", def.Key, err)
+			continue
+		}
+		tasks = append(tasks, def.newTask(fn))
+	}
+	return tasks, nil
+}
+
+func (b *queueBackend) Clear() error {
+	return b.m.queue.Clear()
+}